@@ -0,0 +1,105 @@
+// Package alerts turns fire events into notifications for citizens whose
+// watch areas contain the fire.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"fmt"
+)
+
+// Consumer enqueues notifications for fire reports and status changes.
+// Notifications are de-duplicated per user, fire, status and channel
+// address, so overlapping watch areas and redelivered outbox entries do not
+// produce repeated alerts for the same incident.
+type Consumer struct {
+	subscriptionsRepo *repository.AreaSubscriptionsRepository
+	notificationsRepo *repository.NotificationsRepository
+	pushRepo          *repository.PushSubscriptionsRepository
+}
+
+func NewConsumer(subscriptionsRepo *repository.AreaSubscriptionsRepository, notificationsRepo *repository.NotificationsRepository, pushRepo *repository.PushSubscriptionsRepository) *Consumer {
+	return &Consumer{
+		subscriptionsRepo: subscriptionsRepo,
+		notificationsRepo: notificationsRepo,
+		pushRepo:          pushRepo,
+	}
+}
+
+func (c *Consumer) Name() string {
+	return "area_alerts"
+}
+
+func (c *Consumer) Handle(ctx context.Context, entry *models.OutboxEntry) error {
+	if entry.EventType != events.FireCreated && entry.EventType != events.FireStatusChanged {
+		return nil
+	}
+
+	var fire models.Fire
+	if err := json.Unmarshal(entry.Payload, &fire); err != nil {
+		return err
+	}
+
+	subs, err := c.subscriptionsRepo.GetActiveContaining(ctx, fire.Latitude, fire.Longitude)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		// Reporters already know about the fire they reported.
+		if entry.EventType == events.FireCreated && fire.ReporterID != nil && sub.UserID == *fire.ReporterID {
+			continue
+		}
+
+		channels, err := c.expandChannels(ctx, sub)
+		if err != nil {
+			return err
+		}
+
+		for _, channel := range channels {
+			subscriptionID := sub.ID
+			_, err := c.notificationsRepo.Enqueue(ctx, &models.Notification{
+				UserID:         sub.UserID,
+				SubscriptionID: &subscriptionID,
+				FireID:         fire.ID,
+				EventType:      entry.EventType,
+				FireStatus:     fire.Status,
+				Channel:        channel.Type,
+				Address:        channel.Address,
+				Language:       sub.Language,
+			}, dedupKey(sub.UserID, &fire, channel))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandChannels replaces a push channel with one channel per browser the
+// user has registered for push.
+func (c *Consumer) expandChannels(ctx context.Context, sub *models.AreaSubscription) ([]models.NotificationChannel, error) {
+	channels := make([]models.NotificationChannel, 0, len(sub.Channels))
+	for _, channel := range sub.Channels {
+		if channel.Type != "push" {
+			channels = append(channels, channel)
+			continue
+		}
+
+		pushSubs, err := c.pushRepo.GetByUserID(ctx, sub.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, pushSub := range pushSubs {
+			channels = append(channels, models.NotificationChannel{Type: "push", Address: pushSub.Endpoint})
+		}
+	}
+	return channels, nil
+}
+
+func dedupKey(userID int, fire *models.Fire, channel models.NotificationChannel) string {
+	return fmt.Sprintf("user:%d:fire:%d:%s:%s:%s", userID, fire.ID, fire.Status, channel.Type, channel.Address)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/pow"
+	"fire-tracker/internal/repository"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AnonymousReportsHandler lets people report a fire without an account.
+// To make scripted spam expensive, each report must carry the solution to
+// a proof-of-work challenge fetched beforehand.
+type AnonymousReportsHandler struct {
+	firesHandler   *FiresHandler
+	challengesRepo *repository.ReportChallengesRepository
+	difficulty     int
+	challengeTTL   time.Duration
+}
+
+func NewAnonymousReportsHandler(firesHandler *FiresHandler, challengesRepo *repository.ReportChallengesRepository, difficulty int, challengeTTL time.Duration) *AnonymousReportsHandler {
+	return &AnonymousReportsHandler{
+		firesHandler:   firesHandler,
+		challengesRepo: challengesRepo,
+		difficulty:     difficulty,
+		challengeTTL:   challengeTTL,
+	}
+}
+
+type ReportChallengeResponse struct {
+	Challenge interface{} `json:"challenge"`
+}
+
+// Challenge issues a new proof-of-work challenge.
+func (h *AnonymousReportsHandler) Challenge(w http.ResponseWriter, r *http.Request) {
+	value, err := pow.NewChallenge()
+	if err != nil {
+		http.Error(w, "Failed to create challenge", http.StatusInternalServerError)
+		return
+	}
+
+	challenge := &models.ReportChallenge{
+		Challenge:  value,
+		Difficulty: h.difficulty,
+		ExpiresAt:  time.Now().Add(h.challengeTTL),
+	}
+	if err := h.challengesRepo.Create(r.Context(), challenge); err != nil {
+		http.Error(w, "Failed to create challenge", http.StatusInternalServerError)
+		return
+	}
+
+	response := ReportChallengeResponse{Challenge: challenge}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+type CreateAnonymousFireRequest struct {
+	CreateFireRequest
+	Challenge string `json:"challenge"`
+	Nonce     string `json:"nonce"`
+}
+
+// Create records an anonymous report once its challenge solution checks
+// out. A challenge is used up by the attempt, even a failed one, so
+// solutions cannot be guessed against the same challenge.
+func (h *AnonymousReportsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateAnonymousFireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if msg := validateFireReport(req.Latitude, req.Longitude, req.Description); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if req.Challenge == "" || req.Nonce == "" {
+		http.Error(w, "Challenge and nonce are required", http.StatusBadRequest)
+		return
+	}
+
+	challenge, err := h.challengesRepo.Consume(r.Context(), req.Challenge)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Challenge is invalid or expired", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to check challenge", http.StatusInternalServerError)
+		return
+	}
+	if !pow.Verify(challenge.Challenge, req.Nonce, challenge.Difficulty) {
+		http.Error(w, "Challenge solution is incorrect", http.StatusForbidden)
+		return
+	}
+
+	fire, msg, err := h.firesHandler.report(r.Context(), nil, req.Latitude, req.Longitude, req.Description)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create fire report", http.StatusInternalServerError)
+		return
+	}
+
+	response := CreateFireResponse{Fire: fire}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// apiKeyPrefix marks Fire Tracker keys so they are easy to spot in
+// configuration files and secret scanners.
+const apiKeyPrefix = "ftk_"
+
+type APIKeysHandler struct {
+	apiKeysRepo *repository.APIKeysRepository
+	usersRepo   *repository.UsersRepository
+}
+
+func NewAPIKeysHandler(apiKeysRepo *repository.APIKeysRepository, usersRepo *repository.UsersRepository) *APIKeysHandler {
+	return &APIKeysHandler{
+		apiKeysRepo: apiKeysRepo,
+		usersRepo:   usersRepo,
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	UserID        *int     `json:"user_id"` // Account the key acts as, defaults to the caller
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 for no expiry
+}
+
+type APIKeyResponse struct {
+	APIKey interface{} `json:"api_key"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []interface{} `json:"api_keys"`
+}
+
+// Create issues a key. The key itself is only in this response; the
+// database keeps a hash.
+func (h *APIKeysHandler) Create(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateAPIKeyRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	userID := adminID
+	if req.UserID != nil {
+		userID = *req.UserID
+	}
+	if _, err := h.usersRepo.GetByID(r.Context(), userID); errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	apiKey, err := h.apiKeysRepo.Create(r.Context(), req.Name, key[:len(apiKeyPrefix)+8], hashToken(key), userID, req.Scopes, expiresAt, adminID)
+	if err != nil {
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+	apiKey.Key = key
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(APIKeyResponse{APIKey: apiKey})
+}
+
+func (h *APIKeysHandler) List(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeysRepo.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch API keys", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListAPIKeysResponse{APIKeys: make([]interface{}, len(keys))}
+	for i, key := range keys {
+		response.APIKeys[i] = key
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIKeysHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.apiKeysRepo.Revoke(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateAPIKeyRequest(req *CreateAPIKeyRequest) string {
+	if req.Name == "" || len(req.Name) > 255 {
+		return "Name must be between 1 and 255 characters"
+	}
+	if len(req.Scopes) == 0 {
+		return "At least one scope is required"
+	}
+	for _, scope := range req.Scopes {
+		valid := false
+		for _, known := range middleware.APIKeyScopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "Unknown scope: " + scope
+		}
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > 3650 {
+		return "Expiry must be between 0 and 3650 days"
+	}
+	return ""
+}
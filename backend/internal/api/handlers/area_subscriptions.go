@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/smsintake"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxAreaSubscriptionsPerUser = 10
+	maxAreaRadiusMeters         = 50000
+	maxChannelsPerSubscription  = 5
+)
+
+var phoneNumberPattern = regexp.MustCompile(`^\+?[0-9]{6,15}$`)
+
+// alertLanguages are the languages alert messages are available in.
+var alertLanguages = map[string]bool{"en": true, "el": true, "tr": true}
+
+type AreaSubscriptionsHandler struct {
+	subscriptionsRepo *repository.AreaSubscriptionsRepository
+	usersRepo         *repository.UsersRepository
+}
+
+func NewAreaSubscriptionsHandler(subscriptionsRepo *repository.AreaSubscriptionsRepository, usersRepo *repository.UsersRepository) *AreaSubscriptionsHandler {
+	return &AreaSubscriptionsHandler{subscriptionsRepo: subscriptionsRepo, usersRepo: usersRepo}
+}
+
+type AreaSubscriptionRequest struct {
+	Name     string                       `json:"name"`
+	Center   *models.Point                `json:"center"`
+	Radius   *float64                     `json:"radius"`
+	Area     *models.Polygon              `json:"area"`
+	Channels []models.NotificationChannel `json:"channels"`
+	Language string                       `json:"language"`
+	Active   *bool                        `json:"active"`
+}
+
+type AreaSubscriptionResponse struct {
+	Subscription interface{} `json:"subscription"`
+}
+
+type ListAreaSubscriptionsResponse struct {
+	Subscriptions []interface{} `json:"subscriptions"`
+}
+
+func (h *AreaSubscriptionsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req AreaSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateAreaSubscriptionRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if !h.checkChannelOwnership(w, r, userID, req.Channels) {
+		return
+	}
+
+	existing, err := h.subscriptionsRepo.GetByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch subscriptions", http.StatusInternalServerError)
+		return
+	}
+	if len(existing) >= maxAreaSubscriptionsPerUser {
+		http.Error(w, "Subscription limit reached", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.subscriptionsRepo.Create(r.Context(), &models.AreaSubscription{
+		UserID:   userID,
+		Name:     req.Name,
+		Center:   req.Center,
+		Radius:   req.Radius,
+		Area:     req.Area,
+		Channels: req.Channels,
+		Language: req.Language,
+		Active:   req.Active == nil || *req.Active,
+	})
+	if err != nil {
+		http.Error(w, "Failed to create subscription", http.StatusInternalServerError)
+		return
+	}
+
+	response := AreaSubscriptionResponse{Subscription: sub}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AreaSubscriptionsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	subs, err := h.subscriptionsRepo.GetByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	subsInterface := make([]interface{}, len(subs))
+	for i, sub := range subs {
+		subsInterface[i] = sub
+	}
+
+	response := ListAreaSubscriptionsResponse{Subscriptions: subsInterface}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AreaSubscriptionsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.subscriptionsRepo.GetByIDForUser(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+
+	response := AreaSubscriptionResponse{Subscription: sub}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AreaSubscriptionsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	var req AreaSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateAreaSubscriptionRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if !h.checkChannelOwnership(w, r, userID, req.Channels) {
+		return
+	}
+
+	sub, err := h.subscriptionsRepo.Update(r.Context(), &models.AreaSubscription{
+		ID:       id,
+		UserID:   userID,
+		Name:     req.Name,
+		Center:   req.Center,
+		Radius:   req.Radius,
+		Area:     req.Area,
+		Channels: req.Channels,
+		Language: req.Language,
+		Active:   req.Active == nil || *req.Active,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update subscription", http.StatusInternalServerError)
+		return
+	}
+
+	response := AreaSubscriptionResponse{Subscription: sub}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AreaSubscriptionsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.subscriptionsRepo.Delete(r.Context(), id, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to delete subscription", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateAreaSubscriptionRequest(req *AreaSubscriptionRequest) string {
+	if req.Name == "" || len(req.Name) > 255 {
+		return "Name is required and must be less than 255 characters"
+	}
+
+	if (req.Center == nil) == (req.Area == nil) {
+		return "Provide either a center and radius or an area"
+	}
+	if req.Center != nil {
+		if req.Center.Latitude < -90 || req.Center.Latitude > 90 {
+			return "Latitude must be between -90 and 90"
+		}
+		if req.Center.Longitude < -180 || req.Center.Longitude > 180 {
+			return "Longitude must be between -180 and 180"
+		}
+		if req.Radius == nil || *req.Radius <= 0 || *req.Radius > maxAreaRadiusMeters {
+			return "Radius must be between 0 and 50000 meters"
+		}
+	} else {
+		if req.Radius != nil {
+			return "Radius is only allowed with a center"
+		}
+		if msg := validatePolygon(req.Area); msg != "" {
+			return msg
+		}
+	}
+
+	if len(req.Channels) == 0 || len(req.Channels) > maxChannelsPerSubscription {
+		return "Between 1 and 5 channels are required"
+	}
+	for _, channel := range req.Channels {
+		if msg := validateNotificationChannel(channel); msg != "" {
+			return msg
+		}
+	}
+
+	if req.Language == "" {
+		req.Language = "en"
+	}
+	if !alertLanguages[req.Language] {
+		return "Language must be 'en', 'el' or 'tr'"
+	}
+	return ""
+}
+
+// checkChannelOwnership writes an error and returns false unless every email
+// and SMS channel goes to the account's own verified address, so alerts
+// cannot be sent to strangers.
+func (h *AreaSubscriptionsHandler) checkChannelOwnership(w http.ResponseWriter, r *http.Request, userID int, channels []models.NotificationChannel) bool {
+	user, err := h.usersRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+		return false
+	}
+	if msg := validateChannelOwnership(user, channels); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// validateChannelOwnership also stores SMS numbers the way the account
+// has them, e.g. "+357..." for "00357...".
+func validateChannelOwnership(user *models.User, channels []models.NotificationChannel) string {
+	for i, channel := range channels {
+		switch channel.Type {
+		case "email":
+			if user.Email == nil || user.EmailVerifiedAt == nil || !strings.EqualFold(channel.Address, *user.Email) {
+				return "Email channel must use your verified email address"
+			}
+		case "sms":
+			if user.Phone == nil || smsintake.NormalizePhone(channel.Address) != *user.Phone {
+				return "SMS channel must use the phone number you texted us from"
+			}
+			channels[i].Address = *user.Phone
+		}
+	}
+	return ""
+}
+
+func validateNotificationChannel(channel models.NotificationChannel) string {
+	switch channel.Type {
+	case "email":
+		if !strings.Contains(channel.Address, "@") || len(channel.Address) > 254 {
+			return "Email channel needs a valid email address"
+		}
+	case "sms":
+		if !phoneNumberPattern.MatchString(channel.Address) {
+			return "SMS channel needs a phone number"
+		}
+	case "telegram":
+		if _, err := strconv.ParseInt(channel.Address, 10, 64); err != nil {
+			return "Telegram channel needs a numeric chat ID"
+		}
+	case "push":
+		if channel.Address != "" {
+			return "Push channel takes no address; register browsers under /api/me/push-subscriptions"
+		}
+	default:
+		return "Channel type must be 'email', 'sms', 'telegram' or 'push'"
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fire-tracker/internal/models"
+	"testing"
+	"time"
+)
+
+func TestValidateChannelOwnership(t *testing.T) {
+	email, phone := "Maria@example.com", "+35799123456"
+	verified := time.Now()
+	owner := &models.User{Email: &email, EmailVerifiedAt: &verified, Phone: &phone}
+	unverified := &models.User{Email: &email}
+
+	tests := []struct {
+		name    string
+		user    *models.User
+		channel models.NotificationChannel
+		valid   bool
+	}{
+		{"own email", owner, models.NotificationChannel{Type: "email", Address: "maria@example.com"}, true},
+		{"someone else's email", owner, models.NotificationChannel{Type: "email", Address: "victim@example.com"}, false},
+		{"unverified email", unverified, models.NotificationChannel{Type: "email", Address: email}, false},
+		{"own phone", owner, models.NotificationChannel{Type: "sms", Address: "0035799123456"}, true},
+		{"someone else's phone", owner, models.NotificationChannel{Type: "sms", Address: "+35799000000"}, false},
+		{"no phone", unverified, models.NotificationChannel{Type: "sms", Address: phone}, false},
+		{"telegram", unverified, models.NotificationChannel{Type: "telegram", Address: "12345"}, true},
+	}
+	for _, tt := range tests {
+		channels := []models.NotificationChannel{tt.channel}
+		if msg := validateChannelOwnership(tt.user, channels); (msg == "") != tt.valid {
+			t.Errorf("%s: got %q, want valid=%v", tt.name, msg, tt.valid)
+		}
+	}
+
+	// SMS numbers are stored the way the account has them
+	channels := []models.NotificationChannel{{Type: "sms", Address: "0035799123456"}}
+	validateChannelOwnership(owner, channels)
+	if channels[0].Address != phone {
+		t.Errorf("stored %q, want %q", channels[0].Address, phone)
+	}
+}
@@ -1,80 +1,276 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/authz"
 	"fire-tracker/internal/config"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/notify"
+	"fire-tracker/internal/password"
 	"fire-tracker/internal/repository"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything longer
+	resetTokenExpiry  = time.Hour
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
 type AuthHandler struct {
 	usersRepo    *repository.UsersRepository
 	sessionsRepo *repository.SessionsRepository
+	resetsRepo   *repository.PasswordResetsRepository
+	hasher       password.Hasher
+	resetSender  notify.Notifier // Delivers reset links to the user's email address
 	config       *config.Config
+
+	// dummyHash is verified when a login names an unknown user, so the
+	// response time does not reveal which usernames exist.
+	dummyHash string
 }
 
-func NewAuthHandler(usersRepo *repository.UsersRepository, sessionsRepo *repository.SessionsRepository, config *config.Config) *AuthHandler {
+func NewAuthHandler(usersRepo *repository.UsersRepository, sessionsRepo *repository.SessionsRepository, resetsRepo *repository.PasswordResetsRepository, hasher password.Hasher, resetSender notify.Notifier, config *config.Config) *AuthHandler {
+	dummyHash, _ := hasher.Hash("dummy password")
 	return &AuthHandler{
 		usersRepo:    usersRepo,
 		sessionsRepo: sessionsRepo,
+		resetsRepo:   resetsRepo,
+		hasher:       hasher,
+		resetSender:  resetSender,
 		config:       config,
+		dummyHash:    dummyHash,
 	}
 }
 
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Name     string `json:"name"`  // Display name, defaults to the username
+	Email    string `json:"email"` // Optional, needed for password resets
+}
+
 type LoginRequest struct {
-	Name string `json:"name"`
-	Role string `json:"role"`
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 type LoginResponse struct {
-	Token string                `json:"token"`
-	User  interface{}           `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresAt    time.Time   `json:"expires_at"` // When the token lapses unless used or refreshed
+	User         interface{} `json:"user"`
 }
 
-func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
-	var req LoginRequest
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type PasswordResetRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	if !usernamePattern.MatchString(req.Username) {
+		http.Error(w, "Username must be 3-32 letters, digits, '.', '_' or '-'", http.StatusBadRequest)
+		return
+	}
+	if msg := validatePassword(req.Password); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
 	if req.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		req.Name = req.Username
+	}
+	if len(req.Name) > 255 {
+		http.Error(w, "Name must be less than 255 characters", http.StatusBadRequest)
+		return
+	}
+	var email *string
+	if req.Email != "" {
+		if !strings.Contains(req.Email, "@") || len(req.Email) > 254 {
+			http.Error(w, "Invalid email address", http.StatusBadRequest)
+			return
+		}
+		email = &req.Email
+	}
+	hash, err := h.hasher.Hash(req.Password)
+	if err != nil {
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
 		return
 	}
 
-	if req.Role != "user" && req.Role != "firefighter" {
-		http.Error(w, "Role must be 'user' or 'firefighter'", http.StatusBadRequest)
+	// Everyone starts as a citizen; firefighters are elevated through an
+	// invite code or an approved role request.
+	user, err := h.usersRepo.Create(r.Context(), req.Username, req.Name, email, authz.Citizen, hash)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		http.Error(w, "Username or email is already taken", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
 		return
 	}
 
-	// Try to find existing user
-	user, err := h.usersRepo.GetByName(r.Context(), req.Name)
-	if err == pgx.ErrNoRows {
-		// Create new user
-		user, err = h.usersRepo.Create(r.Context(), req.Name, req.Role)
-		if err != nil {
-			http.Error(w, "Failed to create user", http.StatusInternalServerError)
-			return
-		}
+	session, err := h.sessionsRepo.Create(r.Context(), user.ID, h.config.SessionExpiry(), h.config.SessionMaxLifetime(), middleware.Client(r))
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	response := LoginResponse{
+		Token:        session.Token,
+		RefreshToken: session.RefreshToken,
+		ExpiresAt:    session.ExpiresAt,
+		User:         user,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Username == "" {
+		http.Error(w, "Username is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersRepo.GetByUsername(r.Context(), req.Username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		password.Verify(h.dummyHash, req.Password)
+		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		return
 	} else if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
+	// Accounts from before passwords, and single sign-on accounts, have no
+	// password. They can only get one through a reset link.
+	if user.PasswordHash == nil {
+		password.Verify(h.dummyHash, req.Password)
+		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+	ok, err := password.Verify(*user.PasswordHash, req.Password)
+	if err != nil || !ok {
+		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+	if h.hasher.NeedsRehash(*user.PasswordHash) {
+		if hash, err := h.hasher.Hash(req.Password); err == nil {
+			h.usersRepo.SetPassword(r.Context(), user.ID, hash)
+		}
+	}
+
+	// Checked after the password so suspension does not reveal which
+	// accounts exist.
+	if user.SuspendedAt != nil {
+		http.Error(w, "Account suspended", http.StatusForbidden)
+		return
+	}
+
 	// Create session
-	session, err := h.sessionsRepo.Create(r.Context(), user.ID, h.config.SessionExpiry())
+	session, err := h.sessionsRepo.Create(r.Context(), user.ID, h.config.SessionExpiry(), h.config.SessionMaxLifetime(), middleware.Client(r))
 	if err != nil {
 		http.Error(w, "Failed to create session", http.StatusInternalServerError)
 		return
 	}
 
 	response := LoginResponse{
-		Token: session.ID,
-		User:  user,
+		Token:        session.Token,
+		RefreshToken: session.RefreshToken,
+		ExpiresAt:    session.ExpiresAt,
+		User:         user,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Refresh trades a refresh token for a new token pair. Each refresh token
+// works once; presenting one again ends the session, since it means the
+// token was copied.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.sessionsRepo.Refresh(r.Context(), req.RefreshToken, h.config.SessionExpiry(), middleware.Client(r))
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		http.Error(w, "Refresh token was already used; the session has been ended", http.StatusUnauthorized)
+		return
+	} else if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to refresh session", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.usersRepo.GetByID(r.Context(), session.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+	if user.SuspendedAt != nil {
+		http.Error(w, "Account suspended", http.StatusForbidden)
+		return
+	}
+
+	response := LoginResponse{
+		Token:        session.Token,
+		RefreshToken: session.RefreshToken,
+		ExpiresAt:    session.ExpiresAt,
+		User:         user,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -82,9 +278,12 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 }
 
 type MeResponse struct {
-	User interface{} `json:"user"`
+	User        interface{}        `json:"user"`
+	Permissions []authz.Permission `json:"permissions"`
 }
 
+// Me returns the caller and what they may do. For API keys the permissions
+// are narrowed to the key's scopes.
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
@@ -98,24 +297,301 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := MeResponse{User: user}
+	response := MeResponse{User: user, Permissions: middleware.EffectivePermissions(r.Context())}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
+	err := h.sessionsRepo.Delete(r.Context(), bearerToken(r))
+	if err != nil {
+		http.Error(w, "Failed to logout", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ListSessionsResponse struct {
+	Sessions []interface{} `json:"sessions"`
+}
+
+// ListSessions shows the devices the caller is signed in on.
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	currentID, _ := middleware.GetSessionID(r.Context())
 
-	token := authHeader[7:] // Remove "Bearer " prefix
-	err := h.sessionsRepo.Delete(r.Context(), token)
+	sessions, err := h.sessionsRepo.ListByUserID(r.Context(), userID)
 	if err != nil {
+		http.Error(w, "Failed to fetch sessions", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListSessionsResponse{Sessions: make([]interface{}, len(sessions))}
+	for i, session := range sessions {
+		session.Current = session.ID == currentID
+		response.Sessions[i] = session
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RevokeSession signs the caller out on one device, e.g. a lost phone.
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	err := h.sessionsRepo.DeleteByID(r.Context(), id, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll ends every session of the caller, including this one.
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.sessionsRepo.DeleteByUserID(r.Context(), userID, ""); err != nil {
 		http.Error(w, "Failed to logout", http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ChangePassword sets a new password after checking the current one, and
+// signs out the user's other sessions. Accounts without a password must use
+// a reset link instead, since there is nothing to check.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validatePassword(req.NewPassword); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if user.PasswordHash == nil {
+		http.Error(w, "This account has no password yet; request a password reset", http.StatusForbidden)
+		return
+	}
+	ok, err = password.Verify(*user.PasswordHash, req.CurrentPassword)
+	if err != nil || !ok {
+		http.Error(w, "Current password is incorrect", http.StatusForbidden)
+		return
+	}
+
+	if err := h.setPassword(r, user.ID, req.NewPassword, bearerToken(r)); err != nil {
+		http.Error(w, "Failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RequestPasswordReset sends a single-use reset link to the account's email
+// address. The response is the same whether or not the account exists.
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var user *models.User
+	var err error
+	switch {
+	case req.Email != "":
+		user, err = h.usersRepo.GetByEmail(r.Context(), req.Email)
+	case req.Username != "":
+		user, err = h.usersRepo.GetByUsername(r.Context(), req.Username)
+	default:
+		http.Error(w, "Username or email is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if user != nil && user.Email != nil {
+		if err := h.sendResetToken(r, user); err != nil {
+			http.Error(w, "Failed to send reset link", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validatePassword(req.NewPassword); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	userID, emailed, err := h.resetsRepo.Consume(r.Context(), hashToken(req.Token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Reset link is invalid or has expired", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setPassword(r, userID, req.NewPassword, ""); err != nil {
+		http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+	// Using an emailed link shows the user reads that address
+	if emailed {
+		if err := h.usersRepo.MarkEmailVerified(r.Context(), userID); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setPassword stores a new password and ends every session of the user
+// except keepSession.
+func (h *AuthHandler) setPassword(r *http.Request, userID int, newPassword, keepSession string) error {
+	hash, err := h.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := h.usersRepo.SetPassword(r.Context(), userID, hash); err != nil {
+		return err
+	}
+	return h.sessionsRepo.DeleteByUserID(r.Context(), userID, keepSession)
+}
+
+type IssuePasswordResetResponse struct {
+	ResetURL  string    `json:"reset_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IssuePasswordReset lets an administrator create a reset link for an
+// account, to hand over in person. It is how accounts without an email
+// address get a password. The link is only returned here.
+func (h *AuthHandler) IssuePasswordReset(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.usersRepo.GetByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	link, expiresAt, err := h.createResetLink(r, user.ID, false)
+	if err != nil {
+		http.Error(w, "Failed to create reset link", http.StatusInternalServerError)
+		return
+	}
+
+	response := IssuePasswordResetResponse{ResetURL: link, ExpiresAt: expiresAt}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// createResetLink stores a new reset token for the user, replacing earlier
+// ones, and returns the link that redeems it.
+func (h *AuthHandler) createResetLink(r *http.Request, userID int, emailed bool) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(raw)
+
+	expiresAt := time.Now().Add(resetTokenExpiry)
+	if err := h.resetsRepo.Create(r.Context(), userID, hashToken(token), expiresAt, emailed); err != nil {
+		return "", time.Time{}, err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimSuffix(h.config.FrontendURL, "/"), url.QueryEscape(token))
+	return link, expiresAt, nil
+}
+
+func (h *AuthHandler) sendResetToken(r *http.Request, user *models.User) error {
+	link, _, err := h.createResetLink(r, user.ID, true)
+	if err != nil {
+		return err
+	}
+	return h.resetSender.Send(r.Context(), notify.Message{
+		To:      *user.Email,
+		Subject: "Reset your Fire Tracker password",
+		Body: fmt.Sprintf("Someone asked to reset the password for %s.\n\nOpen this link within an hour to choose a new password:\n%s\n\nIf this was not you, ignore this message.",
+			user.Username, link),
+		URL: link,
+	})
+}
+
+func validatePassword(pw string) string {
+	if len(pw) < minPasswordLength || len(pw) > maxPasswordLength {
+		return fmt.Sprintf("Password must be between %d and %d characters", minPasswordLength, maxPasswordLength)
+	}
+	return ""
+}
+
+// hashToken is how reset tokens and invite codes are stored, so a database
+// leak does not expose usable secrets.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func bearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
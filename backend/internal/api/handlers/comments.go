@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fire-tracker/internal/api/middleware"
 	"fire-tracker/internal/repository"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 )
 
 type CommentsHandler struct {
@@ -57,7 +59,10 @@ func (h *CommentsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	comment, err := h.commentsRepo.Create(r.Context(), fireID, userID, req.Text)
-	if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Fire not found", http.StatusNotFound)
+		return
+	} else if err != nil {
 		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
 		return
 	}
@@ -95,3 +100,28 @@ func (h *CommentsHandler) List(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// Delete removes a comment, such as abuse or a leaked phone number.
+func (h *CommentsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	fireID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid fire ID", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "commentID"))
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.commentsRepo.Delete(r.Context(), fireID, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type EvacuationZonesHandler struct {
+	zonesRepo *repository.EvacuationZonesRepository
+	firesRepo *repository.FiresRepository
+}
+
+func NewEvacuationZonesHandler(zonesRepo *repository.EvacuationZonesRepository, firesRepo *repository.FiresRepository) *EvacuationZonesHandler {
+	return &EvacuationZonesHandler{
+		zonesRepo: zonesRepo,
+		firesRepo: firesRepo,
+	}
+}
+
+type EvacuationZoneRequest struct {
+	FireID    *int            `json:"fire_id"`
+	Area      *models.Polygon `json:"area"`
+	Level     string          `json:"level"`
+	Message   string          `json:"message"`
+	ExpiresAt *time.Time      `json:"expires_at"`
+}
+
+type EvacuationZoneResponse struct {
+	Zone interface{} `json:"zone"`
+}
+
+type ListEvacuationZonesResponse struct {
+	Zones []interface{} `json:"zones"`
+}
+
+type CheckEvacuationZoneResponse struct {
+	InZone bool          `json:"in_zone"`
+	Level  string        `json:"level,omitempty"` // Most severe level among matching zones
+	Zones  []interface{} `json:"zones"`
+}
+
+func (h *EvacuationZonesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req EvacuationZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := h.validate(r, &req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	zone, err := h.zonesRepo.Create(r.Context(), &models.EvacuationZone{
+		FireID:    req.FireID,
+		CreatedBy: userID,
+		Area:      req.Area,
+		Level:     req.Level,
+		Message:   req.Message,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		http.Error(w, "Failed to create evacuation zone", http.StatusInternalServerError)
+		return
+	}
+
+	response := EvacuationZoneResponse{Zone: zone}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *EvacuationZonesHandler) List(w http.ResponseWriter, r *http.Request) {
+	fireID := 0
+	if fireIDStr := r.URL.Query().Get("fire_id"); fireIDStr != "" {
+		id, err := strconv.Atoi(fireIDStr)
+		if err != nil {
+			http.Error(w, "Invalid fire ID", http.StatusBadRequest)
+			return
+		}
+		fireID = id
+	}
+
+	zones, err := h.zonesRepo.GetActive(r.Context(), fireID)
+	if err != nil {
+		http.Error(w, "Failed to fetch evacuation zones", http.StatusInternalServerError)
+		return
+	}
+
+	zonesInterface := make([]interface{}, len(zones))
+	for i, zone := range zones {
+		zonesInterface[i] = zone
+	}
+
+	response := ListEvacuationZonesResponse{Zones: zonesInterface}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *EvacuationZonesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid evacuation zone ID", http.StatusBadRequest)
+		return
+	}
+
+	zone, err := h.zonesRepo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Evacuation zone not found", http.StatusNotFound)
+		return
+	}
+
+	response := EvacuationZoneResponse{Zone: zone}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *EvacuationZonesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid evacuation zone ID", http.StatusBadRequest)
+		return
+	}
+
+	var req EvacuationZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := h.validate(r, &req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	zone, err := h.zonesRepo.Update(r.Context(), &models.EvacuationZone{
+		ID:        id,
+		FireID:    req.FireID,
+		Area:      req.Area,
+		Level:     req.Level,
+		Message:   req.Message,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Evacuation zone not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update evacuation zone", http.StatusInternalServerError)
+		return
+	}
+
+	response := EvacuationZoneResponse{Zone: zone}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *EvacuationZonesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid evacuation zone ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.zonesRepo.Delete(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Evacuation zone not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to delete evacuation zone", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Check reports whether a point lies inside any active evacuation zone.
+func (h *EvacuationZonesHandler) Check(w http.ResponseWriter, r *http.Request) {
+	latitude, err := strconv.ParseFloat(r.URL.Query().Get("latitude"), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		http.Error(w, "Latitude must be between -90 and 90", http.StatusBadRequest)
+		return
+	}
+	longitude, err := strconv.ParseFloat(r.URL.Query().Get("longitude"), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		http.Error(w, "Longitude must be between -180 and 180", http.StatusBadRequest)
+		return
+	}
+
+	zones, err := h.zonesRepo.GetActiveContaining(r.Context(), latitude, longitude)
+	if err != nil {
+		http.Error(w, "Failed to check evacuation zones", http.StatusInternalServerError)
+		return
+	}
+
+	zonesInterface := make([]interface{}, len(zones))
+	for i, zone := range zones {
+		zonesInterface[i] = zone
+	}
+
+	response := CheckEvacuationZoneResponse{
+		InZone: len(zones) > 0,
+		Zones:  zonesInterface,
+	}
+	if len(zones) > 0 {
+		response.Level = zones[0].Level
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// validate returns a user-facing message describing the first problem with
+// the request, or an empty string if it is valid.
+func (h *EvacuationZonesHandler) validate(r *http.Request, req *EvacuationZoneRequest) string {
+	if req.Level != "prepare" && req.Level != "evacuate" {
+		return "Level must be 'prepare' or 'evacuate'"
+	}
+	if req.Message == "" {
+		return "Message is required"
+	}
+	if len(req.Message) > 2000 {
+		return "Message must be less than 2000 characters"
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return "Expiry must be in the future"
+	}
+	if msg := validatePolygon(req.Area); msg != "" {
+		return msg
+	}
+	if req.FireID != nil {
+		if _, err := h.firesRepo.GetApproved(r.Context(), *req.FireID); err != nil {
+			return "Linked fire not found"
+		}
+	}
+	return ""
+}
+
+func validatePolygon(area *models.Polygon) string {
+	if area == nil || area.Type != "Polygon" {
+		return "Area must be a GeoJSON Polygon"
+	}
+	if len(area.Coordinates) == 0 {
+		return "Area must have an outer ring"
+	}
+	for i, ring := range area.Coordinates {
+		if len(ring) < 4 {
+			return fmt.Sprintf("Ring %d must have at least 4 positions", i)
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Sprintf("Ring %d must be closed", i)
+		}
+		for _, position := range ring {
+			if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+				return "Area coordinates must be valid [longitude, latitude] pairs"
+			}
+		}
+	}
+	return ""
+}
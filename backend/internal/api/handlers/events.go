@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fire-tracker/internal/events"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	sseQueueSize         = 64
+	sseHeartbeatInterval = 15 * time.Second
+)
+
+type EventsHandler struct {
+	broker *events.Broker
+}
+
+func NewEventsHandler(broker *events.Broker) *EventsHandler {
+	return &EventsHandler{broker: broker}
+}
+
+// Stream sends fire and comment events as Server-Sent Events. Event IDs are
+// global, so clients that reconnect with Last-Event-ID to any instance
+// receive the events they missed; if those are no longer buffered a "reset"
+// event tells them to reload.
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	lastID := int64(0)
+	lastIDStr := r.Header.Get("Last-Event-ID")
+	if lastIDStr == "" {
+		lastIDStr = r.URL.Query().Get("last_event_id")
+	}
+	if lastIDStr != "" {
+		id, err := strconv.ParseInt(lastIDStr, 10, 64)
+		if err != nil || id < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastID = id
+	}
+
+	sub, replay, complete := h.broker.Subscribe(lastID, sseQueueSize)
+	defer sub.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Disable the server write timeout for this long-lived response.
+	rc.SetWriteDeadline(time.Time{})
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range replay {
+		if err := writeSSE(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and resumes.
+				return
+			}
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
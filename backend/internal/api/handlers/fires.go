@@ -1,21 +1,44 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/authz"
+	"fire-tracker/internal/models"
 	"fire-tracker/internal/repository"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type FiresHandler struct {
-	firesRepo *repository.FiresRepository
+	firesRepo   *repository.FiresRepository
+	weatherRepo *repository.WeatherRepository
+	usersRepo   *repository.UsersRepository
+	moderation  ModerationPolicy
 }
 
-func NewFiresHandler(firesRepo *repository.FiresRepository) *FiresHandler {
-	return &FiresHandler{firesRepo: firesRepo}
+// ModerationPolicy decides which reports wait in the moderation queue
+// before going public.
+type ModerationPolicy struct {
+	TrustThreshold float64       // Reporters whose trust score is below this are moderated
+	NewUserAge     time.Duration // Accounts younger than this without a confirmed report are moderated
+	Anonymous      bool          // Whether anonymous reports are moderated
+}
+
+func NewFiresHandler(firesRepo *repository.FiresRepository, weatherRepo *repository.WeatherRepository, usersRepo *repository.UsersRepository, moderation ModerationPolicy) *FiresHandler {
+	return &FiresHandler{
+		firesRepo:   firesRepo,
+		weatherRepo: weatherRepo,
+		usersRepo:   usersRepo,
+		moderation:  moderation,
+	}
 }
 
 type CreateFireRequest struct {
@@ -41,22 +64,11 @@ func (h *FiresHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate latitude and longitude
-	if req.Latitude < -90 || req.Latitude > 90 {
-		http.Error(w, "Latitude must be between -90 and 90", http.StatusBadRequest)
+	fire, msg, err := h.report(r.Context(), &userID, req.Latitude, req.Longitude, req.Description)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
-	if req.Longitude < -180 || req.Longitude > 180 {
-		http.Error(w, "Longitude must be between -180 and 180", http.StatusBadRequest)
-		return
-	}
-
-	if req.Description == "" {
-		http.Error(w, "Description is required", http.StatusBadRequest)
-		return
-	}
-
-	fire, err := h.firesRepo.Create(r.Context(), userID, req.Latitude, req.Longitude, req.Description)
 	if err != nil {
 		http.Error(w, "Failed to create fire report", http.StatusInternalServerError)
 		return
@@ -92,7 +104,17 @@ func (h *FiresHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	fires, total, err := h.firesRepo.GetAll(r.Context(), status, limit, offset)
+	var organisationID *int
+	if s := r.URL.Query().Get("organisation_id"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid organisation ID", http.StatusBadRequest)
+			return
+		}
+		organisationID = &id
+	}
+
+	fires, total, err := h.firesRepo.GetAll(r.Context(), status, organisationID, limit, offset)
 	if err != nil {
 		http.Error(w, "Failed to fetch fires", http.StatusInternalServerError)
 		return
@@ -124,12 +146,19 @@ func (h *FiresHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fire, err := h.firesRepo.GetByID(r.Context(), id)
+	fire, err := h.firesRepo.GetApproved(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Fire not found", http.StatusNotFound)
 		return
 	}
 
+	snapshot, err := h.weatherRepo.GetLatestByFireID(r.Context(), id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Failed to fetch weather", http.StatusInternalServerError)
+		return
+	}
+	fire.Weather = snapshot
+
 	response := GetFireResponse{Fire: fire}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -162,7 +191,11 @@ func (h *FiresHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fire, err := h.firesRepo.UpdateStatus(r.Context(), id, req.Status)
+	fire, err := h.firesRepo.UpdateStatus(r.Context(), fireScope(r), id, req.Status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Fire not found, awaiting moderation or outside your organisation", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to update fire status", http.StatusInternalServerError)
 		return
@@ -172,3 +205,143 @@ func (h *FiresHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+type TransferFireRequest struct {
+	OrganisationID *int `json:"organisation_id"`
+}
+
+// Transfer hands a fire over to another organisation, or to the default
+// organisation when organisation_id is null.
+func (h *FiresHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	id, ok := fireIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req TransferFireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	fire, err := h.firesRepo.Transfer(r.Context(), fireScope(r), id, req.OrganisationID)
+	var pgErr *pgconn.PgError
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Fire not found or outside your organisation", http.StatusNotFound)
+		return
+	} else if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		http.Error(w, "Organisation not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to transfer fire", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetFireResponse{Fire: fire})
+}
+
+type MergeFireRequest struct {
+	IntoFireID int `json:"into_fire_id"`
+}
+
+// Merge folds a duplicate report into the fire it describes.
+func (h *FiresHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	id, ok := fireIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req MergeFireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.IntoFireID <= 0 || req.IntoFireID == id {
+		http.Error(w, "into_fire_id must be another fire", http.StatusBadRequest)
+		return
+	}
+
+	fire, err := h.firesRepo.Merge(r.Context(), fireScope(r), id, req.IntoFireID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Fires not found, already merged or outside your organisation", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to merge fires", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetFireResponse{Fire: fire})
+}
+
+// fireScope limits staff to the fires of their organisation. Only
+// administrators manage fires across organisations.
+func fireScope(r *http.Request) repository.FireScope {
+	role, _ := middleware.GetUserRole(r.Context())
+	return repository.FireScope{
+		All:            role == authz.Admin,
+		OrganisationID: middleware.GetOrganisationID(r.Context()),
+	}
+}
+
+// report validates and records a new fire. Every intake channel goes
+// through here so reports are checked and published the same way. A
+// non-empty message means the report was rejected as invalid. A nil
+// reporterID records an anonymous report.
+func (h *FiresHandler) report(ctx context.Context, reporterID *int, latitude, longitude float64, description string) (*models.Fire, string, error) {
+	if msg := validateFireReport(latitude, longitude, description); msg != "" {
+		return nil, msg, nil
+	}
+
+	pending, err := h.needsModeration(ctx, reporterID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fire, err := h.firesRepo.Create(ctx, reporterID, latitude, longitude, description, pending)
+	if err != nil {
+		return nil, "", err
+	}
+	return fire, "", nil
+}
+
+// needsModeration applies the moderation policy to a reporter. Reports
+// from moderators are never held back.
+func (h *FiresHandler) needsModeration(ctx context.Context, reporterID *int) (bool, error) {
+	if reporterID == nil {
+		return h.moderation.Anonymous, nil
+	}
+
+	user, err := h.usersRepo.GetByID(ctx, *reporterID)
+	if err != nil {
+		return false, err
+	}
+	if authz.Can(user.Role, authz.FireModerate) {
+		return false, nil
+	}
+
+	trust, err := h.firesRepo.GetTrust(ctx, []int{user.ID})
+	if err != nil {
+		return false, err
+	}
+	score := trust[user.ID]
+	if score.Score < h.moderation.TrustThreshold {
+		return true, nil
+	}
+	return score.Confirmed == 0 && time.Since(user.CreatedAt) < h.moderation.NewUserAge, nil
+}
+
+func validateFireReport(latitude, longitude float64, description string) string {
+	if latitude < -90 || latitude > 90 {
+		return "Latitude must be between -90 and 90"
+	}
+	if longitude < -180 || longitude > 180 {
+		return "Longitude must be between -180 and 180"
+	}
+
+	if description == "" {
+		return "Description is required"
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fire-tracker/internal/jobs"
+	"net/http"
+)
+
+type JobsHandler struct {
+	runner *jobs.Runner
+}
+
+func NewJobsHandler(runner *jobs.Runner) *JobsHandler {
+	return &JobsHandler{runner: runner}
+}
+
+// Status reports whether this instance is the job leader and each job's
+// run counts, last outcome and next run.
+func (h *JobsHandler) Status(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.runner.Status())
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// ModerationHandler serves the queue of reports held back by the
+// moderation policy, and lets firefighters record whether reports were
+// real, which drives reporter trust.
+type ModerationHandler struct {
+	firesRepo *repository.FiresRepository
+}
+
+func NewModerationHandler(firesRepo *repository.FiresRepository) *ModerationHandler {
+	return &ModerationHandler{firesRepo: firesRepo}
+}
+
+var verdicts = map[string]bool{"confirmed": true, "false": true, "prank": true}
+
+type VerdictRequest struct {
+	Verdict string  `json:"verdict"`
+	Note    *string `json:"note"`
+}
+
+// Queue lists pending reports the caller's organisation may handle, oldest
+// first, with each reporter's trust.
+func (h *ModerationHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pageParams(r)
+
+	fires, total, err := h.firesRepo.ListPendingModeration(r.Context(), fireScope(r), limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch moderation queue", http.StatusInternalServerError)
+		return
+	}
+	if err := attachTrust(r, h.firesRepo, fires); err != nil {
+		http.Error(w, "Failed to fetch reporter trust", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListFiresResponse{Fires: make([]interface{}, len(fires)), Total: total}
+	for i, fire := range fires {
+		response.Fires[i] = fire
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Get shows a report in the caller's scope whatever its moderation status.
+func (h *ModerationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := fireIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	fire, err := h.firesRepo.GetInScope(r.Context(), fireScope(r), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Fire not found or outside your organisation", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to fetch fire", http.StatusInternalServerError)
+		return
+	}
+	if err := attachTrust(r, h.firesRepo, []*models.Fire{fire}); err != nil {
+		http.Error(w, "Failed to fetch reporter trust", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetFireResponse{Fire: fire})
+}
+
+// Approve publishes a pending report.
+func (h *ModerationHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	id, ok := fireIDParam(w, r)
+	if !ok {
+		return
+	}
+	moderatorID, _ := middleware.GetUserID(r.Context())
+
+	fire, err := h.firesRepo.Approve(r.Context(), fireScope(r), id, moderatorID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Fire not found, not awaiting moderation or outside your organisation", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to approve fire", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetFireResponse{Fire: fire})
+}
+
+// Reject refuses a pending report. The verdict defaults to "false".
+func (h *ModerationHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	id, ok := fireIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	req := VerdictRequest{Verdict: "false"}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Verdict != "false" && req.Verdict != "prank" {
+		http.Error(w, "Verdict must be 'false' or 'prank'", http.StatusBadRequest)
+		return
+	}
+	moderatorID, _ := middleware.GetUserID(r.Context())
+
+	fire, err := h.firesRepo.Reject(r.Context(), fireScope(r), id, moderatorID, req.Verdict, trimNote(req.Note))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Fire not found, not awaiting moderation or outside your organisation", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to reject fire", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetFireResponse{Fire: fire})
+}
+
+// SetVerdict records whether any report, moderated or not, was confirmed,
+// false or a prank.
+func (h *ModerationHandler) SetVerdict(w http.ResponseWriter, r *http.Request) {
+	id, ok := fireIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req VerdictRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !verdicts[req.Verdict] {
+		http.Error(w, "Verdict must be 'confirmed', 'false' or 'prank'", http.StatusBadRequest)
+		return
+	}
+	userID, _ := middleware.GetUserID(r.Context())
+
+	fire, err := h.firesRepo.SetVerdict(r.Context(), fireScope(r), id, userID, req.Verdict, trimNote(req.Note))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Fire not found or outside your organisation", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to record verdict", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetFireResponse{Fire: fire})
+}
+
+// attachTrust fills in the trust score of each fire's reporter.
+func attachTrust(r *http.Request, firesRepo *repository.FiresRepository, fires []*models.Fire) error {
+	var userIDs []int
+	for _, fire := range fires {
+		if fire.Reporter != nil {
+			userIDs = append(userIDs, fire.Reporter.ID)
+		}
+	}
+	trust, err := firesRepo.GetTrust(r.Context(), userIDs)
+	if err != nil {
+		return err
+	}
+	for _, fire := range fires {
+		if fire.Reporter != nil {
+			fire.Reporter.Trust = trust[fire.Reporter.ID]
+		}
+	}
+	return nil
+}
+
+func fireIDParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid fire ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func trimNote(note *string) *string {
+	if note == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*note)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
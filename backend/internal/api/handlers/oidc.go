@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/authz"
+	"fire-tracker/internal/config"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/oidc"
+	"fire-tracker/internal/repository"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+const oidcLoginExpiry = 10 * time.Minute
+
+// oidcStateCookie ties a login to the browser that started it, so nobody
+// can finish their own login in someone else's browser.
+const oidcStateCookie = "oidc_state"
+
+var errEmailUnverified = errors.New("email belongs to an account with an unverified email")
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// OIDCHandler signs fire-service staff in through the organisation's
+// identity provider and hands them a normal session token.
+type OIDCHandler struct {
+	client            *oidc.Client // Nil when single sign-on is not configured
+	identitiesRepo    *repository.IdentitiesRepository
+	usersRepo         *repository.UsersRepository
+	sessionsRepo      *repository.SessionsRepository
+	config            *config.Config
+	firefighterGroups map[string]bool
+	logger            *zap.Logger
+}
+
+func NewOIDCHandler(client *oidc.Client, identitiesRepo *repository.IdentitiesRepository, usersRepo *repository.UsersRepository, sessionsRepo *repository.SessionsRepository, config *config.Config, logger *zap.Logger) *OIDCHandler {
+	groups := map[string]bool{}
+	for _, group := range config.OIDCFirefighterGroupList() {
+		groups[group] = true
+	}
+	return &OIDCHandler{
+		client:            client,
+		identitiesRepo:    identitiesRepo,
+		usersRepo:         usersRepo,
+		sessionsRepo:      sessionsRepo,
+		config:            config,
+		firefighterGroups: groups,
+		logger:            logger,
+	}
+}
+
+type OIDCConfigResponse struct {
+	Enabled bool   `json:"enabled"`
+	Name    string `json:"name,omitempty"` // Provider name for the sign-in button
+}
+
+// Config tells the frontend whether to offer single sign-on.
+func (h *OIDCHandler) Config(w http.ResponseWriter, r *http.Request) {
+	response := OIDCConfigResponse{Enabled: h.client != nil}
+	if h.client != nil {
+		response.Name = h.config.OIDCProviderName
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Login starts the authorization code flow and redirects the browser to the
+// provider. return_to is the frontend path to land on afterwards.
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if h.client == nil {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	loginState, authURL, ok := h.start(w, r, r.URL.Query().Get("return_to"), nil)
+	if !ok {
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    loginState.State,
+		Path:     "/api/auth/oidc",
+		MaxAge:   int(oidcLoginExpiry.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.config.OIDCRedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+type StartLinkRequest struct {
+	ReturnTo string `json:"return_to"`
+}
+
+type StartLinkResponse struct {
+	URL string `json:"url"` // Provider page to send the browser to
+}
+
+// StartLink begins linking a provider account to the signed-in user. The
+// link only takes effect once the same user confirms it with ConfirmLink.
+func (h *OIDCHandler) StartLink(w http.ResponseWriter, r *http.Request) {
+	if h.client == nil {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req StartLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_, authURL, ok := h.start(w, r, req.ReturnTo, &userID)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(StartLinkResponse{URL: authURL})
+}
+
+// start records a new login and returns the provider URL for it. It writes
+// the error response itself when it fails.
+func (h *OIDCHandler) start(w http.ResponseWriter, r *http.Request, returnTo string, linkUserID *int) (*models.OIDCLoginState, string, bool) {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.Contains(returnTo, `\`) {
+		returnTo = "/"
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+		return nil, "", false
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+		return nil, "", false
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+		return nil, "", false
+	}
+
+	authURL, err := h.client.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		h.logger.Error("oidc discovery failed", zap.Error(err))
+		http.Error(w, "Identity provider is unavailable", http.StatusBadGateway)
+		return nil, "", false
+	}
+
+	loginState := &models.OIDCLoginState{State: state, Nonce: nonce, CodeVerifier: verifier, ReturnTo: returnTo, LinkUserID: linkUserID}
+	if err := h.identitiesRepo.CreateLoginState(r.Context(), loginState, time.Now().Add(oidcLoginExpiry)); err != nil {
+		http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+		return nil, "", false
+	}
+	return loginState, authURL, true
+}
+
+// Callback finishes the flow. The browser is sent to the frontend with the
+// session tokens in the URL fragment, which is not sent to servers or
+// written to access logs.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	if h.client == nil {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		h.fail(w, r, "The identity provider refused the sign-in: "+providerErr)
+		return
+	}
+
+	state := query.Get("state")
+	cookie, _ := r.Cookie(oidcStateCookie)
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/api/auth/oidc", MaxAge: -1, HttpOnly: true})
+
+	loginState, err := h.identitiesRepo.ConsumeLoginState(r.Context(), state)
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.fail(w, r, "Sign-in expired, please try again")
+		return
+	} else if err != nil {
+		h.fail(w, r, "Sign-in failed")
+		return
+	}
+	// Sign-ins must come back to the browser that started them. Links are
+	// bound to their user instead, when the user confirms them.
+	if loginState.LinkUserID == nil && (cookie == nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1) {
+		h.fail(w, r, "Sign-in expired, please try again")
+		return
+	}
+
+	claims, err := h.client.Exchange(r.Context(), query.Get("code"), loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		h.logger.Warn("oidc code exchange failed", zap.Error(err))
+		h.fail(w, r, "Sign-in failed")
+		return
+	}
+
+	if loginState.LinkUserID != nil {
+		h.holdLink(w, r, loginState, claims)
+		return
+	}
+
+	user, err := h.resolveUser(r, claims)
+	if errors.Is(err, errEmailUnverified) {
+		h.fail(w, r, "An account with your email already exists. Sign in with your password and link single sign-on from your account.")
+		return
+	} else if err != nil {
+		h.logger.Error("oidc user provisioning failed", zap.String("subject", claims.Subject), zap.Error(err))
+		h.fail(w, r, "Sign-in failed")
+		return
+	}
+	if user.SuspendedAt != nil {
+		h.fail(w, r, "Account suspended")
+		return
+	}
+
+	session, err := h.sessionsRepo.Create(r.Context(), user.ID, h.config.SessionExpiry(), h.config.SessionMaxLifetime(), middleware.Client(r))
+	if err != nil {
+		h.fail(w, r, "Failed to create session")
+		return
+	}
+
+	fragment := url.Values{
+		"token":         {session.Token},
+		"refresh_token": {session.RefreshToken},
+		"return_to":     {loginState.ReturnTo},
+	}
+	http.Redirect(w, r, h.frontendURL("/auth/callback")+"#"+fragment.Encode(), http.StatusFound)
+}
+
+// holdLink keeps the provider account of a link started by a signed-in user
+// and sends the browser to the frontend to confirm it with that user's
+// session. The code goes in the URL fragment, like session tokens.
+func (h *OIDCHandler) holdLink(w http.ResponseWriter, r *http.Request, loginState *models.OIDCLoginState, claims *oidc.Claims) {
+	code, err := oidc.NewState()
+	if err != nil {
+		h.fail(w, r, "Linking failed")
+		return
+	}
+	link := &models.OIDCPendingLink{UserID: *loginState.LinkUserID, Issuer: claims.Issuer, Subject: claims.Subject, Groups: claims.Groups}
+	if claims.Email != "" && claims.EmailVerified {
+		link.Email = &claims.Email
+	}
+	if err := h.identitiesRepo.CreatePendingLink(r.Context(), hashToken(code), link, time.Now().Add(oidcLoginExpiry)); err != nil {
+		h.fail(w, r, "Linking failed")
+		return
+	}
+
+	fragment := url.Values{
+		"link_code": {code},
+		"return_to": {loginState.ReturnTo},
+	}
+	http.Redirect(w, r, h.frontendURL("/auth/callback")+"#"+fragment.Encode(), http.StatusFound)
+}
+
+type ConfirmLinkRequest struct {
+	Code string `json:"code"`
+}
+
+type ConfirmLinkResponse struct {
+	Identity interface{} `json:"identity"`
+}
+
+// ConfirmLink links a held provider account to the caller, who must be the
+// user that started linking it.
+func (h *OIDCHandler) ConfirmLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ConfirmLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.identitiesRepo.ConsumePendingLink(r.Context(), hashToken(req.Code), userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Link expired or started by another account", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	identity, err := h.identitiesRepo.Link(r.Context(), userID, link.Issuer, link.Subject, link.Email, link.Groups)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		http.Error(w, "This provider account is already linked to a user", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to link account", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ConfirmLinkResponse{Identity: identity})
+}
+
+// resolveUser finds the user for a provider account, linking to a user
+// whose email both sides have verified or creating a user on first sign-in,
+// and brings their role in line with their provider groups.
+func (h *OIDCHandler) resolveUser(r *http.Request, claims *oidc.Claims) (*models.User, error) {
+	ctx := r.Context()
+	var email *string
+	if claims.Email != "" && claims.EmailVerified {
+		email = &claims.Email
+	}
+
+	var user *models.User
+	identity, err := h.identitiesRepo.GetByIssuerSubject(ctx, claims.Issuer, claims.Subject)
+	switch {
+	case err == nil:
+		user, err = h.usersRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	case email != nil:
+		user, err = h.usersRepo.GetByEmail(ctx, *email)
+		if err == nil {
+			// Anyone can register with someone else's address, so only
+			// an address its owner has used stands for the account.
+			if user.EmailVerifiedAt == nil {
+				return nil, errEmailUnverified
+			}
+			identity, err = h.identitiesRepo.Link(ctx, user.ID, claims.Issuer, claims.Subject, email, claims.Groups)
+			if err != nil {
+				return nil, err
+			}
+			break
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		fallthrough
+	default:
+		user, identity, err = h.createUser(r, claims, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Provider groups grant the firefighter role, and take it away again if
+	// they were what granted it. Roles from invites, approvals or
+	// administrators are left alone.
+	grantedRole := identity.GrantedRole
+	var newRole string
+	switch {
+	case user.Role == authz.Citizen && h.inFirefighterGroup(claims.Groups):
+		newRole, grantedRole = authz.Firefighter, true
+	case user.Role == authz.Firefighter && grantedRole && !h.inFirefighterGroup(claims.Groups):
+		newRole, grantedRole = authz.Citizen, false
+	case user.Role != authz.Firefighter:
+		grantedRole = false
+	}
+	if newRole != "" {
+		user, err = h.usersRepo.Update(ctx, user.ID, repository.UserUpdate{Role: &newRole})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.identitiesRepo.RecordLogin(ctx, identity.ID, email, claims.Groups, grantedRole); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createUser provisions a user for a first-time provider account, picking
+// the first free username derived from its claims.
+func (h *OIDCHandler) createUser(r *http.Request, claims *oidc.Claims, email *string) (*models.User, *models.UserIdentity, error) {
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+
+	var err error
+	for _, username := range usernameCandidates(claims) {
+		if name == "" {
+			name = username
+		}
+		var user *models.User
+		var identity *models.UserIdentity
+		user, identity, err = h.identitiesRepo.CreateUser(r.Context(), username, name, email, claims.Issuer, claims.Subject, claims.Groups)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			continue
+		}
+		return user, identity, err
+	}
+	return nil, nil, fmt.Errorf("no free username: %w", err)
+}
+
+func (h *OIDCHandler) inFirefighterGroup(groups []string) bool {
+	for _, group := range groups {
+		if h.firefighterGroups[group] {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *OIDCHandler) fail(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, h.frontendURL("/login")+"?"+url.Values{"sso_error": {msg}}.Encode(), http.StatusFound)
+}
+
+func (h *OIDCHandler) frontendURL(path string) string {
+	return strings.TrimSuffix(h.config.FrontendURL, "/") + path
+}
+
+// usernameCandidates derives usernames from the provider's preferred
+// username or email, with numbered variants, ending with one made from the
+// subject that is practically always free.
+func usernameCandidates(claims *oidc.Claims) []string {
+	var bases []string
+	for _, source := range []string{claims.PreferredUsername, strings.SplitN(claims.Email, "@", 2)[0]} {
+		base := usernameInvalidChars.ReplaceAllString(strings.ToLower(source), "")
+		if len(base) > 29 {
+			base = base[:29]
+		}
+		if len(base) >= 3 {
+			bases = append(bases, base)
+		}
+	}
+
+	var candidates []string
+	for _, base := range bases {
+		candidates = append(candidates, base)
+	}
+	if len(bases) > 0 {
+		for i := 2; i <= 9; i++ {
+			candidates = append(candidates, fmt.Sprintf("%s-%d", bases[0], i))
+		}
+	}
+	sum := sha256.Sum256([]byte(claims.Issuer + " " + claims.Subject))
+	return append(candidates, "sso-"+hex.EncodeToString(sum[:6]))
+}
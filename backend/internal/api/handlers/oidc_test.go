@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/authz"
+	"fire-tracker/internal/config"
+	"fire-tracker/internal/oidc"
+	"fire-tracker/internal/oidc/oidctest"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/testdb"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const testFrontendURL = "http://frontend.test"
+
+type oidcTest struct {
+	handler    *OIDCHandler
+	provider   *oidctest.Provider
+	users      *repository.UsersRepository
+	identities *repository.IdentitiesRepository
+	sessions   *repository.SessionsRepository
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	db := testdb.Open(t)
+	provider := oidctest.NewProvider("fire-tracker", "")
+	t.Cleanup(provider.Close)
+
+	cfg := &config.Config{
+		SessionExpiryHours:    1,
+		SessionMaxDays:        1,
+		OIDCIssuerURL:         provider.Issuer(),
+		OIDCClientID:          "fire-tracker",
+		OIDCRedirectURL:       "http://api.test/api/auth/oidc/callback",
+		OIDCGroupsClaim:       "groups",
+		OIDCFirefighterGroups: "firefighters",
+		FrontendURL:           testFrontendURL,
+	}
+	client := oidc.NewClient(oidc.Config{
+		Issuer:      cfg.OIDCIssuerURL,
+		ClientID:    cfg.OIDCClientID,
+		RedirectURL: cfg.OIDCRedirectURL,
+		Scopes:      []string{"openid", "profile", "email"},
+		GroupsClaim: cfg.OIDCGroupsClaim,
+	}, 5*time.Second)
+
+	test := &oidcTest{
+		provider:   provider,
+		users:      repository.NewUsersRepository(db),
+		identities: repository.NewIdentitiesRepository(db),
+		sessions:   repository.NewSessionsRepository(db),
+	}
+	test.handler = NewOIDCHandler(client, test.identities, test.users, test.sessions, cfg, zap.NewNop())
+	return test
+}
+
+// authorize follows the provider's redirect back to the callback.
+func (o *oidcTest) authorize(t *testing.T, authURL string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: got status %d", resp.StatusCode)
+	}
+	return resp.Header.Get("Location")
+}
+
+// callback runs the callback and returns where it sends the browser.
+func (o *oidcTest) callback(t *testing.T, callbackURL string, cookies []*http.Cookie) *url.URL {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, callbackURL, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	o.handler.Callback(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("callback: got status %d", rec.Code)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+// login signs in through the provider as its current user, sending the
+// state cookie back unless withCookie is false.
+func (o *oidcTest) login(t *testing.T, withCookie bool) *url.URL {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	o.handler.Login(rec, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login?return_to=/fires", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login: got status %d", rec.Code)
+	}
+
+	var cookies []*http.Cookie
+	if withCookie {
+		cookies = rec.Result().Cookies()
+	}
+	return o.callback(t, o.authorize(t, rec.Header().Get("Location")), cookies)
+}
+
+// sessionUser returns the user the callback signed in.
+func (o *oidcTest) sessionUser(t *testing.T, location *url.URL) int {
+	t.Helper()
+	if !strings.HasPrefix(location.String(), testFrontendURL+"/auth/callback#") {
+		t.Fatalf("expected a redirect to the frontend callback, got %s", location)
+	}
+	fragment, err := url.ParseQuery(location.Fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := o.sessions.GetByToken(context.Background(), fragment.Get("token"))
+	if err != nil {
+		t.Fatalf("session from callback: %v", err)
+	}
+	return session.UserID
+}
+
+func expectLoginError(t *testing.T, location *url.URL) {
+	t.Helper()
+	if location.Path != "/login" || location.Query().Get("sso_error") == "" {
+		t.Fatalf("expected a sign-in error, got %s", location)
+	}
+}
+
+func TestOIDCLoginCreatesSession(t *testing.T) {
+	o := newOIDCTest(t)
+
+	location := o.login(t, true)
+	fragment, _ := url.ParseQuery(location.Fragment)
+	if fragment.Get("return_to") != "/fires" || fragment.Get("refresh_token") == "" {
+		t.Errorf("unexpected fragment %q", location.Fragment)
+	}
+
+	user, err := o.users.GetByID(context.Background(), o.sessionUser(t, location))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "firefighter" || user.Email == nil || *user.Email != "firefighter@example.com" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("email vouched for by the provider should count as verified")
+	}
+
+	// Signing in again finds the same user
+	if again := o.sessionUser(t, o.login(t, true)); again != user.ID {
+		t.Errorf("second sign-in got user %d, want %d", again, user.ID)
+	}
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	o := newOIDCTest(t)
+
+	expectLoginError(t, o.login(t, false))
+}
+
+func TestOIDCGroupsMapToRole(t *testing.T) {
+	o := newOIDCTest(t)
+	ctx := context.Background()
+	member := oidctest.User{Subject: "ff-2", Email: "ff2@example.com", EmailVerified: true, Name: "FF Two", Username: "fftwo", Groups: []string{"firefighters"}}
+
+	o.provider.SetUser(member)
+	userID := o.sessionUser(t, o.login(t, true))
+	if user, _ := o.users.GetByID(ctx, userID); user.Role != authz.Firefighter {
+		t.Fatalf("group member got role %q, want firefighter", user.Role)
+	}
+
+	// Leaving the group takes away the role it granted
+	member.Groups = nil
+	o.provider.SetUser(member)
+	o.sessionUser(t, o.login(t, true))
+	if user, _ := o.users.GetByID(ctx, userID); user.Role != authz.Citizen {
+		t.Fatalf("former member got role %q, want citizen", user.Role)
+	}
+
+	// Roles given another way are left alone
+	commander := authz.Commander
+	if _, err := o.users.Update(ctx, userID, repository.UserUpdate{Role: &commander}); err != nil {
+		t.Fatal(err)
+	}
+	o.sessionUser(t, o.login(t, true))
+	if user, _ := o.users.GetByID(ctx, userID); user.Role != authz.Commander {
+		t.Fatalf("commander got role %q after signing in", user.Role)
+	}
+}
+
+func TestOIDCLinksVerifiedEmail(t *testing.T) {
+	o := newOIDCTest(t)
+	ctx := context.Background()
+	email := "alice@example.com"
+	alice, err := o.users.Create(ctx, "alice", "Alice", &email, authz.Citizen, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.users.MarkEmailVerified(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	o.provider.SetUser(oidctest.User{Subject: "alice-sso", Email: email, EmailVerified: true, Name: "Alice", Username: "alice.sso"})
+	if got := o.sessionUser(t, o.login(t, true)); got != alice.ID {
+		t.Fatalf("signed in as user %d, want linked user %d", got, alice.ID)
+	}
+}
+
+func TestOIDCRefusesUnverifiedLocalEmail(t *testing.T) {
+	o := newOIDCTest(t)
+	ctx := context.Background()
+	email := "mallory-target@example.com"
+	if _, err := o.users.Create(ctx, "target", "Target", &email, authz.Citizen, "hash"); err != nil {
+		t.Fatal(err)
+	}
+
+	o.provider.SetUser(oidctest.User{Subject: "someone", Email: email, EmailVerified: true, Name: "Someone", Username: "someone"})
+	expectLoginError(t, o.login(t, true))
+
+	if _, err := o.identities.GetByIssuerSubject(ctx, o.provider.Issuer(), "someone"); err == nil {
+		t.Error("provider account was linked to an account with an unverified email")
+	}
+}
+
+func TestOIDCLinkRequiresStartingUser(t *testing.T) {
+	o := newOIDCTest(t)
+	ctx := context.Background()
+	bob, err := o.users.Create(ctx, "bob", "Bob", nil, authz.Citizen, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eve, err := o.users.Create(ctx, "eve", "Eve", nil, authz.Citizen, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.provider.SetUser(oidctest.User{Subject: "bob-sso", Email: "bob@example.com", EmailVerified: true, Name: "Bob", Username: "bob.sso"})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/link", strings.NewReader(`{"return_to":"/sessions"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, bob.ID))
+	rec := httptest.NewRecorder()
+	o.handler.StartLink(rec, req)
+	var started StartLinkResponse
+	if err := json.NewDecoder(rec.Body).Decode(&started); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("start link: status %d, %v", rec.Code, err)
+	}
+
+	location := o.callback(t, o.authorize(t, started.URL), nil)
+	fragment, _ := url.ParseQuery(location.Fragment)
+	code := fragment.Get("link_code")
+	if code == "" {
+		t.Fatalf("expected a link code, got %s", location)
+	}
+
+	confirm := func(userID int) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/link/confirm", strings.NewReader(`{"code":"`+code+`"}`))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+		rec := httptest.NewRecorder()
+		o.handler.ConfirmLink(rec, req)
+		return rec.Code
+	}
+	if status := confirm(eve.ID); status != http.StatusNotFound {
+		t.Fatalf("another user confirming got status %d, want 404", status)
+	}
+	if status := confirm(bob.ID); status != http.StatusCreated {
+		t.Fatalf("confirming got status %d, want 201", status)
+	}
+
+	if got := o.sessionUser(t, o.login(t, true)); got != bob.ID {
+		t.Fatalf("signed in as user %d, want linked user %d", got, bob.ID)
+	}
+}
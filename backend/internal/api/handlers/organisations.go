@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// OrganisationsHandler manages the agencies sharing the deployment.
+// Organisations are public so anyone can see who is responsible where.
+type OrganisationsHandler struct {
+	organisationsRepo *repository.OrganisationsRepository
+}
+
+func NewOrganisationsHandler(organisationsRepo *repository.OrganisationsRepository) *OrganisationsHandler {
+	return &OrganisationsHandler{organisationsRepo: organisationsRepo}
+}
+
+type OrganisationRequest struct {
+	Name         string          `json:"name"`
+	Jurisdiction *models.Polygon `json:"jurisdiction"`
+}
+
+type OrganisationResponse struct {
+	Organisation interface{} `json:"organisation"`
+}
+
+type ListOrganisationsResponse struct {
+	Organisations []interface{} `json:"organisations"`
+}
+
+func (h *OrganisationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.organisationsRepo.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch organisations", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListOrganisationsResponse{Organisations: make([]interface{}, len(orgs))}
+	for i, org := range orgs {
+		response.Organisations[i] = org
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *OrganisationsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := organisationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.organisationsRepo.GetByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Organisation not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to fetch organisation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(OrganisationResponse{Organisation: org})
+}
+
+func (h *OrganisationsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req OrganisationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if msg := validateOrganisationRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	org, err := h.organisationsRepo.Create(r.Context(), req.Name, req.Jurisdiction)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		http.Error(w, "Organisation name is already taken", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to create organisation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(OrganisationResponse{Organisation: org})
+}
+
+// Update replaces an organisation's name and jurisdiction. Fires assigned
+// by jurisdiction follow the new one.
+func (h *OrganisationsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := organisationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req OrganisationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if msg := validateOrganisationRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	org, err := h.organisationsRepo.Update(r.Context(), id, req.Name, req.Jurisdiction)
+	var pgErr *pgconn.PgError
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Organisation not found", http.StatusNotFound)
+		return
+	} else if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		http.Error(w, "Organisation name is already taken", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update organisation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(OrganisationResponse{Organisation: org})
+}
+
+// Delete removes an organisation once it has no staff. Its fires go to the
+// organisation now responsible for them.
+func (h *OrganisationsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := organisationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.organisationsRepo.Delete(r.Context(), id)
+	var pgErr *pgconn.PgError
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Organisation not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, repository.ErrDefaultOrganisation) {
+		http.Error(w, "The default organisation cannot be deleted", http.StatusConflict)
+		return
+	} else if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		http.Error(w, "Organisation still has members; move them to another organisation first", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to delete organisation", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateOrganisationRequest(req *OrganisationRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 255 {
+		return "Name must be between 1 and 255 characters"
+	}
+	if req.Jurisdiction != nil {
+		if msg := validatePolygon(req.Jurisdiction); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func organisationIDParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid organisation ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
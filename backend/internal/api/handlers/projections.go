@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/spread"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxProjectionHours = 72
+
+var defaultProjectionHours = []float64{1, 3, 6, 12}
+
+type ProjectionsHandler struct {
+	firesRepo   *repository.FiresRepository
+	weatherRepo *repository.WeatherRepository
+	model       *spread.EllipticalModel
+}
+
+func NewProjectionsHandler(firesRepo *repository.FiresRepository, weatherRepo *repository.WeatherRepository, model *spread.EllipticalModel) *ProjectionsHandler {
+	return &ProjectionsHandler{
+		firesRepo:   firesRepo,
+		weatherRepo: weatherRepo,
+		model:       model,
+	}
+}
+
+type ProjectionResponse struct {
+	FireID     int                       `json:"fire_id"`
+	Wind       spread.Wind               `json:"wind"`
+	WindSource string                    `json:"wind_source"` // "weather", "query" or "none"
+	Projection *spread.FeatureCollection `json:"projection"`
+}
+
+func (h *ProjectionsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid fire ID", http.StatusBadRequest)
+		return
+	}
+
+	hours, err := parseProjectionHours(r.URL.Query().Get("hours"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fire, err := h.firesRepo.GetApproved(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Fire not found", http.StatusNotFound)
+		return
+	}
+
+	wind := spread.Wind{}
+	windSource := "none"
+
+	snapshot, err := h.weatherRepo.GetLatestByFireID(r.Context(), id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Failed to fetch weather", http.StatusInternalServerError)
+		return
+	}
+	if snapshot != nil {
+		wind = spread.Wind{Speed: snapshot.WindSpeed, Direction: snapshot.WindDirection}
+		windSource = "weather"
+	}
+
+	// Explicit wind parameters override recorded conditions so commanders can
+	// try out forecast scenarios.
+	if speedStr := r.URL.Query().Get("wind_speed"); speedStr != "" {
+		speed, err := strconv.ParseFloat(speedStr, 64)
+		if err != nil || !isFinite(speed) || speed < 0 {
+			http.Error(w, "wind_speed must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+		wind.Speed = speed
+		windSource = "query"
+	}
+	if directionStr := r.URL.Query().Get("wind_direction"); directionStr != "" {
+		direction, err := strconv.ParseFloat(directionStr, 64)
+		if err != nil || !isFinite(direction) || direction < 0 || direction > 360 {
+			http.Error(w, "wind_direction must be between 0 and 360", http.StatusBadRequest)
+			return
+		}
+		wind.Direction = direction
+		windSource = "query"
+	}
+
+	projections := make([]*spread.Projection, 0, len(hours))
+	for _, horizon := range hours {
+		projections = append(projections, h.model.Project(fire.Latitude, fire.Longitude, wind, horizon))
+	}
+
+	response := ProjectionResponse{
+		FireID:     fire.ID,
+		Wind:       wind,
+		WindSource: windSource,
+		Projection: spread.ToGeoJSON(projections),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseProjectionHours parses a comma-separated list of horizons such as
+// "1,3,6". An empty value returns the default horizons.
+func parseProjectionHours(value string) ([]float64, error) {
+	if value == "" {
+		return defaultProjectionHours, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > 10 {
+		return nil, errors.New("At most 10 horizons may be requested")
+	}
+
+	hours := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		h, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || !isFinite(h) || h <= 0 || h > maxProjectionHours {
+			return nil, errors.New("Hours must be numbers between 0 and 72")
+		}
+		hours = append(hours, h)
+	}
+	sort.Float64s(hours)
+	return hours, nil
+}
+
+// isFinite rejects NaN and infinities, which ParseFloat accepts and which
+// slip through range checks.
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import "testing"
+
+func TestParseProjectionHours(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"", true},
+		{"1,3,6", true},
+		{"72", true},
+		{"0", false},
+		{"73", false},
+		{"NaN", false},
+		{"1,nan", false},
+		{"Inf", false},
+		{"-Inf", false},
+	}
+	for _, tt := range tests {
+		if _, err := parseProjectionHours(tt.value); (err == nil) != tt.valid {
+			t.Errorf("%q: got %v, want valid=%v", tt.value, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxPushSubscriptionsPerUser = 10
+
+type PushSubscriptionsHandler struct {
+	pushRepo       *repository.PushSubscriptionsRepository
+	vapidPublicKey string   // Empty when push is not configured
+	pushHosts      []string // Domains endpoints may point at, with their subdomains
+}
+
+func NewPushSubscriptionsHandler(pushRepo *repository.PushSubscriptionsRepository, vapidPublicKey string, pushHosts []string) *PushSubscriptionsHandler {
+	return &PushSubscriptionsHandler{
+		pushRepo:       pushRepo,
+		vapidPublicKey: vapidPublicKey,
+		pushHosts:      pushHosts,
+	}
+}
+
+// PushSubscriptionRequest matches the browser's PushSubscription.toJSON().
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+type VAPIDPublicKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
+
+type PushSubscriptionResponse struct {
+	Subscription interface{} `json:"subscription"`
+}
+
+type ListPushSubscriptionsResponse struct {
+	Subscriptions []interface{} `json:"subscriptions"`
+}
+
+func (h *PushSubscriptionsHandler) PublicKey(w http.ResponseWriter, r *http.Request) {
+	if h.vapidPublicKey == "" {
+		http.Error(w, "Push notifications are not configured", http.StatusNotFound)
+		return
+	}
+
+	response := VAPIDPublicKeyResponse{PublicKey: h.vapidPublicKey}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PushSubscriptionsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if h.vapidPublicKey == "" {
+		http.Error(w, "Push notifications are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req PushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validatePushSubscriptionRequest(&req, h.pushHosts); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.pushRepo.GetByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch push subscriptions", http.StatusInternalServerError)
+		return
+	}
+	if len(existing) >= maxPushSubscriptionsPerUser {
+		known := false
+		for _, sub := range existing {
+			if sub.Endpoint == req.Endpoint {
+				known = true
+				break
+			}
+		}
+		if !known {
+			http.Error(w, "Push subscription limit reached", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var userAgent *string
+	if ua := r.UserAgent(); ua != "" {
+		userAgent = &ua
+	}
+
+	sub, err := h.pushRepo.Save(r.Context(), &models.PushSubscription{
+		UserID:    userID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: userAgent,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "This browser is registered to another account", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to save push subscription", http.StatusInternalServerError)
+		return
+	}
+
+	response := PushSubscriptionResponse{Subscription: sub}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PushSubscriptionsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	subs, err := h.pushRepo.GetByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch push subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	subsInterface := make([]interface{}, len(subs))
+	for i, sub := range subs {
+		subsInterface[i] = sub
+	}
+
+	response := ListPushSubscriptionsResponse{Subscriptions: subsInterface}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PushSubscriptionsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.pushRepo.Delete(r.Context(), id, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Push subscription not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to delete push subscription", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validatePushSubscriptionRequest(req *PushSubscriptionRequest, pushHosts []string) string {
+	u, err := url.Parse(req.Endpoint)
+	if err != nil || u.Host == "" || len(req.Endpoint) > 2048 {
+		return "Endpoint must be an absolute URL"
+	}
+	// The server posts to the endpoint, so it must belong to a known push
+	// service rather than anything reachable from inside the network.
+	host := strings.ToLower(u.Hostname())
+	if !pushHostAllowed(host, pushHosts) {
+		return "Endpoint is not on a known push service"
+	}
+	// Browsers only hand out https endpoints; plain http is allowed for a
+	// local push service during development, once it is listed.
+	if u.Scheme != "https" && !(u.Scheme == "http" && (host == "localhost" || host == "127.0.0.1")) {
+		return "Endpoint must be an https URL"
+	}
+
+	if key, err := base64.RawURLEncoding.DecodeString(req.Keys.P256dh); err != nil || len(key) != 65 || key[0] != 0x04 {
+		return "keys.p256dh must be an uncompressed P-256 public key"
+	}
+	if secret, err := base64.RawURLEncoding.DecodeString(req.Keys.Auth); err != nil || len(secret) != 16 {
+		return "keys.auth must be a 16-byte secret"
+	}
+	return ""
+}
+
+func pushHostAllowed(host string, pushHosts []string) bool {
+	for _, allowed := range pushHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
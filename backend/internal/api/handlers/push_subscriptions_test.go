@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestValidatePushSubscriptionEndpoint(t *testing.T) {
+	hosts := []string{"fcm.googleapis.com", "push.services.mozilla.com", "127.0.0.1"}
+	key := make([]byte, 65)
+	key[0] = 0x04
+
+	tests := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc", true},
+		{"https://updates.push.services.mozilla.com/wpush/v2/abc", true},
+		{"http://127.0.0.1:4000/push/1", true},
+		{"http://fcm.googleapis.com/fcm/send/abc", false},
+		{"https://fcm.googleapis.com.attacker.test/abc", false},
+		{"https://notfcm.googleapis.com/abc", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://localhost/push", false},
+		{"https://internal.service/push", false},
+	}
+	for _, tt := range tests {
+		req := &PushSubscriptionRequest{Endpoint: tt.endpoint}
+		req.Keys.P256dh = base64.RawURLEncoding.EncodeToString(key)
+		req.Keys.Auth = base64.RawURLEncoding.EncodeToString(make([]byte, 16))
+
+		if msg := validatePushSubscriptionRequest(req, hosts); (msg == "") != tt.valid {
+			t.Errorf("%s: got %q, want valid=%v", tt.endpoint, msg, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/authz"
+	"fire-tracker/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// elevatedRoles are the roles users can ask for. Everyone registers as a
+// citizen.
+var elevatedRoles = map[string]bool{"firefighter": true}
+
+type RoleRequestsHandler struct {
+	roleRequestsRepo *repository.RoleRequestsRepository
+	usersRepo        *repository.UsersRepository
+}
+
+func NewRoleRequestsHandler(roleRequestsRepo *repository.RoleRequestsRepository, usersRepo *repository.UsersRepository) *RoleRequestsHandler {
+	return &RoleRequestsHandler{
+		roleRequestsRepo: roleRequestsRepo,
+		usersRepo:        usersRepo,
+	}
+}
+
+type CreateRoleRequestRequest struct {
+	Role       string  `json:"role"`
+	InviteCode string  `json:"invite_code"` // Grants the role immediately when valid
+	Message    *string `json:"message"`     // Shown to the reviewer
+}
+
+type ReviewRoleRequestRequest struct {
+	Note *string `json:"note"`
+}
+
+type CreateInviteRequest struct {
+	Role           string  `json:"role"`
+	Note           *string `json:"note"`
+	MaxUses        int     `json:"max_uses"`
+	ExpiresInHours int     `json:"expires_in_hours"` // 0 for no expiry
+}
+
+type RoleRequestResponse struct {
+	Request interface{} `json:"request"`
+}
+
+type ListRoleRequestsResponse struct {
+	Requests []interface{} `json:"requests"`
+}
+
+type InviteResponse struct {
+	Invite interface{} `json:"invite"`
+}
+
+type ListInvitesResponse struct {
+	Invites []interface{} `json:"invites"`
+}
+
+// Create asks for an elevated role. A valid invite code grants it straight
+// away; otherwise the request waits for review.
+func (h *RoleRequestsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateRoleRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = "firefighter"
+	}
+	if !elevatedRoles[req.Role] {
+		http.Error(w, "Role must be 'firefighter'", http.StatusBadRequest)
+		return
+	}
+	if req.Message != nil && len(*req.Message) > 1000 {
+		http.Error(w, "Message must be less than 1000 characters", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if user.Role == req.Role {
+		http.Error(w, "You already have this role", http.StatusConflict)
+		return
+	}
+	if user.Role != authz.Citizen {
+		http.Error(w, "Your role is managed by an administrator", http.StatusConflict)
+		return
+	}
+
+	if req.InviteCode != "" {
+		request, err := h.roleRequestsRepo.Redeem(r.Context(), userID, hashToken(normalizeInviteCode(req.InviteCode)), req.Message)
+		if errors.Is(err, repository.ErrInvalidInvite) {
+			http.Error(w, "Invite code is invalid or has expired", http.StatusBadRequest)
+			return
+		} else if errors.Is(err, repository.ErrNotCitizen) {
+			http.Error(w, "Your role is managed by an administrator", http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to redeem invite", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(RoleRequestResponse{Request: request})
+		return
+	}
+
+	request, err := h.roleRequestsRepo.Create(r.Context(), userID, req.Role, req.Message)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		http.Error(w, "You already have a pending request", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to create request", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RoleRequestResponse{Request: request})
+}
+
+// Get returns the caller's most recent request.
+func (h *RoleRequestsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	request, err := h.roleRequestsRepo.GetLatestByUserID(r.Context(), userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "No role request", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RoleRequestResponse{Request: request})
+}
+
+// List returns requests for review, pending ones by default.
+func (h *RoleRequestsHandler) List(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = "pending"
+	case "all":
+		status = ""
+	case "pending", "approved", "denied":
+	default:
+		http.Error(w, "Status must be 'pending', 'approved', 'denied' or 'all'", http.StatusBadRequest)
+		return
+	}
+
+	requests, err := h.roleRequestsRepo.List(r.Context(), status)
+	if err != nil {
+		http.Error(w, "Failed to fetch requests", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListRoleRequestsResponse{Requests: make([]interface{}, len(requests))}
+	for i, request := range requests {
+		response.Requests[i] = request
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *RoleRequestsHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, true)
+}
+
+func (h *RoleRequestsHandler) Deny(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, false)
+}
+
+func (h *RoleRequestsHandler) review(w http.ResponseWriter, r *http.Request, approve bool) {
+	reviewerID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional; it only carries a note for the requester.
+	var req ReviewRoleRequestRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	request, err := h.roleRequestsRepo.Review(r.Context(), id, reviewerID, approve, req.Note)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Pending request not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, repository.ErrNotCitizen) {
+		http.Error(w, "The user's role has changed since they asked; deny the request instead", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to review request", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RoleRequestResponse{Request: request})
+}
+
+// CreateInvite issues a new invite code. The code is only shown in this
+// response; the database keeps a hash.
+func (h *RoleRequestsHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = "firefighter"
+	}
+	if !elevatedRoles[req.Role] {
+		http.Error(w, "Role must be 'firefighter'", http.StatusBadRequest)
+		return
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+	if req.MaxUses < 1 || req.MaxUses > 1000 {
+		http.Error(w, "Max uses must be between 1 and 1000", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresInHours < 0 {
+		http.Error(w, "Expiry must not be negative", http.StatusBadRequest)
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+
+	invite, err := h.roleRequestsRepo.CreateInvite(r.Context(), hashToken(normalizeInviteCode(code)), req.Role, req.Note, req.MaxUses, expiresAt, userID)
+	if err != nil {
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+	invite.Code = code
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(InviteResponse{Invite: invite})
+}
+
+func (h *RoleRequestsHandler) ListInvites(w http.ResponseWriter, r *http.Request) {
+	invites, err := h.roleRequestsRepo.ListInvites(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch invites", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListInvitesResponse{Invites: make([]interface{}, len(invites))}
+	for i, invite := range invites {
+		response.Invites[i] = invite
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *RoleRequestsHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid invite ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.roleRequestsRepo.RevokeInvite(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to revoke invite", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// generateInviteCode returns 80 random bits as four groups of base32,
+// e.g. "K7QX-2MPA-9ZTR-B4WD", which is easy to read out over a radio.
+func generateInviteCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := base32.StdEncoding.EncodeToString(raw)
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+// normalizeInviteCode makes codes match however they were typed.
+func normalizeInviteCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(code)))
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/notify"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/smsintake"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxInboundSMSLength = 1600
+	smsDescriptionLimit = 1000
+	defaultSMSReport    = "Reported by SMS"
+)
+
+// SMSHandler accepts text messages forwarded by an SMS gateway. Messages
+// with coordinates or a known village name report a new fire; other
+// messages are added as comments to the sender's recent fire or to the fire
+// named with "#<id>". Reports held for moderation take no comments until
+// they are approved, so their senders are told they are awaiting review.
+type SMSHandler struct {
+	firesHandler *FiresHandler
+	usersRepo    *repository.UsersRepository
+	commentsRepo *repository.CommentsRepository
+	placesRepo   *repository.PlacesRepository
+	replier      *notify.SMSNotifier // Nil when replies are only returned in the response
+	token        string
+	followUp     time.Duration
+}
+
+func NewSMSHandler(firesHandler *FiresHandler, usersRepo *repository.UsersRepository, commentsRepo *repository.CommentsRepository, placesRepo *repository.PlacesRepository, replier *notify.SMSNotifier, token string, followUp time.Duration) *SMSHandler {
+	return &SMSHandler{
+		firesHandler: firesHandler,
+		usersRepo:    usersRepo,
+		commentsRepo: commentsRepo,
+		placesRepo:   placesRepo,
+		replier:      replier,
+		token:        token,
+		followUp:     followUp,
+	}
+}
+
+// InboundSMSRequest is the JSON form of an inbound message. Form-encoded
+// requests with "From" and "Body" fields are accepted as well.
+type InboundSMSRequest struct {
+	From string `json:"from"`
+	Text string `json:"text"`
+}
+
+type InboundSMSResponse struct {
+	Reply     string `json:"reply"`
+	FireID    *int   `json:"fire_id,omitempty"`
+	CommentID *int   `json:"comment_id,omitempty"`
+	Replied   bool   `json:"replied"` // Whether the reply was sent through the SMS gateway
+}
+
+func (h *SMSHandler) Inbound(w http.ResponseWriter, r *http.Request) {
+	if h.token == "" {
+		http.Error(w, "SMS intake is not configured", http.StatusNotFound)
+		return
+	}
+	if !h.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req, err := decodeInboundSMS(r)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	phone := smsintake.NormalizePhone(req.From)
+	if !phoneNumberPattern.MatchString(phone) {
+		http.Error(w, "Sender must be a phone number", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" || len(req.Text) > maxInboundSMSLength {
+		http.Error(w, "Message text is required and must be less than 1600 characters", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersRepo.GetOrCreateByPhone(r.Context(), phone, "SMS "+smsintake.MaskPhone(phone))
+	if err != nil {
+		http.Error(w, "Failed to identify sender", http.StatusInternalServerError)
+		return
+	}
+	if user.SuspendedAt != nil {
+		http.Error(w, "Account suspended", http.StatusForbidden)
+		return
+	}
+
+	response, err := h.handle(r.Context(), user.ID, req.Text)
+	if err != nil {
+		http.Error(w, "Failed to process message", http.StatusInternalServerError)
+		return
+	}
+
+	if h.replier != nil {
+		err := h.replier.Send(r.Context(), notify.Message{To: phone, Body: response.Reply})
+		response.Replied = err == nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *SMSHandler) handle(ctx context.Context, userID int, text string) (*InboundSMSResponse, error) {
+	msg := smsintake.Parse(text)
+
+	if msg.FireID != nil {
+		if msg.Text == "" {
+			return &InboundSMSResponse{Reply: fmt.Sprintf("Add your update after #%d.", *msg.FireID)}, nil
+		}
+		fire, err := h.firesHandler.firesRepo.GetByID(ctx, *msg.FireID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &InboundSMSResponse{Reply: fmt.Sprintf("Fire #%d was not found.", *msg.FireID)}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		// Only the sender learns about their own held report
+		ownPending := fire.ModerationStatus == "pending" && fire.ReporterID != nil && *fire.ReporterID == userID
+		if ownPending {
+			return awaitingReview(fire.ID), nil
+		}
+		if fire.ModerationStatus != "approved" {
+			return &InboundSMSResponse{Reply: fmt.Sprintf("Fire #%d was not found.", *msg.FireID)}, nil
+		}
+		return h.addComment(ctx, fire.ID, userID, msg.Text)
+	}
+
+	latitude, longitude, place, err := h.locate(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if latitude != nil {
+		description := msg.Text
+		if description == "" {
+			description = defaultSMSReport
+		}
+		if runes := []rune(description); len(runes) > smsDescriptionLimit {
+			description = string(runes[:smsDescriptionLimit])
+		}
+
+		fire, invalid, err := h.firesHandler.report(ctx, &userID, *latitude, *longitude, description)
+		if invalid != "" {
+			return &InboundSMSResponse{Reply: invalid + "."}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fire.ModerationStatus == "pending" {
+			return awaitingReview(fire.ID), nil
+		}
+
+		reply := fmt.Sprintf("Fire #%d reported", fire.ID)
+		if place != "" {
+			reply += " near " + place
+		}
+		reply += fmt.Sprintf(". Text more details to add them, or start a message with #%d.", fire.ID)
+		return &InboundSMSResponse{Reply: reply, FireID: &fire.ID}, nil
+	}
+
+	// Without a location the message is a follow-up to the sender's recent
+	// report, if there is one.
+	fire, err := h.firesHandler.firesRepo.GetLatestOpenByReporter(ctx, userID, time.Now().Add(-h.followUp))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &InboundSMSResponse{Reply: "Where is the fire? Send coordinates (e.g. 34.935, 32.872) or the village name."}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if fire.ModerationStatus == "pending" {
+		return awaitingReview(fire.ID), nil
+	}
+	return h.addComment(ctx, fire.ID, userID, msg.Text)
+}
+
+// awaitingReview answers messages about a report held for moderation.
+func awaitingReview(fireID int) *InboundSMSResponse {
+	return &InboundSMSResponse{
+		Reply:  fmt.Sprintf("Your report #%d is awaiting review. Send updates once it is published.", fireID),
+		FireID: &fireID,
+	}
+}
+
+// locate returns the coordinates in the message or, failing that, those of
+// a village it names.
+func (h *SMSHandler) locate(ctx context.Context, msg smsintake.Message) (*float64, *float64, string, error) {
+	if msg.Latitude != nil {
+		return msg.Latitude, msg.Longitude, "", nil
+	}
+	if msg.Text == "" {
+		return nil, nil, "", nil
+	}
+
+	place, err := h.placesRepo.FindInText(ctx, msg.Text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, "", nil
+	} else if err != nil {
+		return nil, nil, "", err
+	}
+	return &place.Latitude, &place.Longitude, place.Name, nil
+}
+
+func (h *SMSHandler) addComment(ctx context.Context, fireID, userID int, text string) (*InboundSMSResponse, error) {
+	comment, err := h.commentsRepo.Create(ctx, fireID, userID, text)
+	if err != nil {
+		return nil, err
+	}
+	return &InboundSMSResponse{
+		Reply:     fmt.Sprintf("Added to fire #%d. Thank you.", fireID),
+		FireID:    &fireID,
+		CommentID: &comment.ID,
+	}, nil
+}
+
+// authorized checks the shared gateway token in X-Gateway-Token. It is not
+// taken from the URL, which ends up in access logs.
+func (h *SMSHandler) authorized(r *http.Request) bool {
+	token := r.Header.Get("X-Gateway-Token")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func decodeInboundSMS(r *http.Request) (*InboundSMSRequest, error) {
+	req := &InboundSMSRequest{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	req.From = r.PostForm.Get("From")
+	if req.From == "" {
+		req.From = r.PostForm.Get("from")
+	}
+	req.Text = r.PostForm.Get("Body")
+	if req.Text == "" {
+		req.Text = r.PostForm.Get("text")
+	}
+	return req, nil
+}
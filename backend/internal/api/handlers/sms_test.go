@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/testdb"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMSInboundTokenOnlyFromHeader(t *testing.T) {
+	h := &SMSHandler{token: "gateway-secret"}
+
+	tests := []struct {
+		name   string
+		url    string
+		header string
+		want   int
+	}{
+		{"no token", "/api/sms/inbound", "", http.StatusUnauthorized},
+		{"wrong header", "/api/sms/inbound", "guess", http.StatusUnauthorized},
+		{"query parameter", "/api/sms/inbound?token=gateway-secret", "", http.StatusUnauthorized},
+		// Authorized, then rejected for the missing body
+		{"header", "/api/sms/inbound", "gateway-secret", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Gateway-Token", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.Inbound(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// sendSMS posts text from a phone number and decodes the reply.
+func sendSMS(t *testing.T, h *SMSHandler, from, text string) InboundSMSResponse {
+	t.Helper()
+	body, _ := json.Marshal(InboundSMSRequest{From: from, Text: text})
+	req := httptest.NewRequest(http.MethodPost, "/api/sms/inbound", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gateway-Token", "gateway-secret")
+	rec := httptest.NewRecorder()
+	h.Inbound(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%q: got status %d: %s", text, rec.Code, rec.Body.String())
+	}
+	var resp InboundSMSResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestSMSFollowUpToPendingReport(t *testing.T) {
+	db := testdb.Open(t)
+	usersRepo := repository.NewUsersRepository(db)
+	// New accounts are held for moderation
+	firesHandler := NewFiresHandler(repository.NewFiresRepository(db), nil, usersRepo, ModerationPolicy{NewUserAge: time.Hour})
+	h := NewSMSHandler(firesHandler, usersRepo, repository.NewCommentsRepository(db), repository.NewPlacesRepository(db), nil, "gateway-secret", time.Hour)
+
+	report := sendSMS(t, h, "+35799000001", "Smoke 34.935, 32.872")
+	if report.FireID == nil || !strings.Contains(report.Reply, "awaiting review") {
+		t.Fatalf("report reply %+v, want it awaiting review", report)
+	}
+	fireID := *report.FireID
+
+	for _, text := range []string{"It is spreading", fmt.Sprintf("#%d It is spreading", fireID)} {
+		resp := sendSMS(t, h, "+35799000001", text)
+		if resp.CommentID != nil || !strings.Contains(resp.Reply, "awaiting review") {
+			t.Errorf("%q: reply %+v, want it awaiting review without a comment", text, resp)
+		}
+	}
+
+	// Other senders cannot tell a held report exists
+	resp := sendSMS(t, h, "+35799000002", fmt.Sprintf("#%d It is spreading", fireID))
+	if resp.CommentID != nil || !strings.Contains(resp.Reply, "not found") {
+		t.Errorf("other sender: reply %+v, want not found", resp)
+	}
+}
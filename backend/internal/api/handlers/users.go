@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/authz"
+	"fire-tracker/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// UsersHandler is the administrators' view of accounts.
+type UsersHandler struct {
+	usersRepo    *repository.UsersRepository
+	sessionsRepo *repository.SessionsRepository
+	firesRepo    *repository.FiresRepository
+	commentsRepo *repository.CommentsRepository
+}
+
+func NewUsersHandler(usersRepo *repository.UsersRepository, sessionsRepo *repository.SessionsRepository, firesRepo *repository.FiresRepository, commentsRepo *repository.CommentsRepository) *UsersHandler {
+	return &UsersHandler{
+		usersRepo:    usersRepo,
+		sessionsRepo: sessionsRepo,
+		firesRepo:    firesRepo,
+		commentsRepo: commentsRepo,
+	}
+}
+
+type UpdateUserRequest struct {
+	Username *string `json:"username"`
+	Name     *string `json:"name"`
+	Role     *string `json:"role"`
+}
+
+type SuspendUserRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type UserResponse struct {
+	User interface{} `json:"user"`
+}
+
+type ListUsersResponse struct {
+	Users []interface{} `json:"users"`
+	Total int           `json:"total"`
+}
+
+type ListCommentsPageResponse struct {
+	Comments []interface{} `json:"comments"`
+	Total    int           `json:"total"`
+}
+
+// List searches accounts by username, name or email (q), role,
+// organisation and suspension.
+func (h *UsersHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := repository.UserFilter{
+		Query: query.Get("q"),
+		Role:  query.Get("role"),
+	}
+	if filter.Role != "" && !authz.ValidRole(filter.Role) {
+		http.Error(w, "Role must be 'citizen', 'firefighter', 'dispatcher', 'commander' or 'admin'", http.StatusBadRequest)
+		return
+	}
+	if s := query.Get("organisation_id"); s != "" {
+		organisationID, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid organisation ID", http.StatusBadRequest)
+			return
+		}
+		filter.OrganisationID = &organisationID
+	}
+	if s := query.Get("suspended"); s != "" {
+		suspended, err := strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, "Suspended must be 'true' or 'false'", http.StatusBadRequest)
+			return
+		}
+		filter.Suspended = &suspended
+	}
+	limit, offset := pageParams(r)
+
+	users, total, err := h.usersRepo.List(r.Context(), filter, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+		return
+	}
+
+	userIDs := make([]int, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	trust, err := h.firesRepo.GetTrust(r.Context(), userIDs)
+	if err != nil {
+		http.Error(w, "Failed to fetch trust scores", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListUsersResponse{Users: make([]interface{}, len(users)), Total: total}
+	for i, user := range users {
+		user.Trust = trust[user.ID]
+		response.Users[i] = user
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *UsersHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.usersRepo.GetByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	trust, err := h.firesRepo.GetTrust(r.Context(), []int{user.ID})
+	if err != nil {
+		http.Error(w, "Failed to fetch trust score", http.StatusInternalServerError)
+		return
+	}
+	user.Trust = trust[user.ID]
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UserResponse{User: user})
+}
+
+// Update renames a user or changes their role. Administrators cannot change
+// their own role, so there is always at least one left.
+func (h *UsersHandler) Update(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := middleware.GetUserID(r.Context())
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Username != nil && !usernamePattern.MatchString(*req.Username) {
+		http.Error(w, "Username must be 3-32 letters, digits, '.', '_' or '-'", http.StatusBadRequest)
+		return
+	}
+	if req.Name != nil && (*req.Name == "" || len(*req.Name) > 255) {
+		http.Error(w, "Name must be between 1 and 255 characters", http.StatusBadRequest)
+		return
+	}
+	if req.Role != nil {
+		if !authz.ValidRole(*req.Role) {
+			http.Error(w, "Role must be 'citizen', 'firefighter', 'dispatcher', 'commander' or 'admin'", http.StatusBadRequest)
+			return
+		}
+		if id == adminID {
+			http.Error(w, "You cannot change your own role", http.StatusForbidden)
+			return
+		}
+	}
+
+	user, err := h.usersRepo.Update(r.Context(), id, repository.UserUpdate{
+		Username: req.Username,
+		Name:     req.Name,
+		Role:     req.Role,
+	})
+	var pgErr *pgconn.PgError
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		http.Error(w, "Username is already taken", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UserResponse{User: user})
+}
+
+type SetOrganisationRequest struct {
+	OrganisationID *int `json:"organisation_id"`
+}
+
+// SetOrganisation makes a user a member of an organisation, or of none when
+// organisation_id is null.
+func (h *UsersHandler) SetOrganisation(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetOrganisationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersRepo.SetOrganisation(r.Context(), id, req.OrganisationID)
+	var pgErr *pgconn.PgError
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		http.Error(w, "Organisation not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update membership", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UserResponse{User: user})
+}
+
+// Suspend blocks an account and signs it out everywhere.
+func (h *UsersHandler) Suspend(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := middleware.GetUserID(r.Context())
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	if id == adminID {
+		http.Error(w, "You cannot suspend yourself", http.StatusForbidden)
+		return
+	}
+
+	var req SuspendUserRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Reason != nil && len(*req.Reason) > 1000 {
+		http.Error(w, "Reason must be less than 1000 characters", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.usersRepo.Suspend(r.Context(), id, adminID, req.Reason)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to suspend user", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.sessionsRepo.DeleteByUserID(r.Context(), id, ""); err != nil {
+		http.Error(w, "Failed to end sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UserResponse{User: user})
+}
+
+func (h *UsersHandler) Unsuspend(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.usersRepo.Unsuspend(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to unsuspend user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UserResponse{User: user})
+}
+
+// ListFires returns the fires a user reported.
+func (h *UsersHandler) ListFires(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := pageParams(r)
+
+	fires, total, err := h.firesRepo.GetByReporter(r.Context(), id, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch fires", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListFiresResponse{Fires: make([]interface{}, len(fires)), Total: total}
+	for i, fire := range fires {
+		response.Fires[i] = fire
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListComments returns the comments a user wrote.
+func (h *UsersHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := pageParams(r)
+
+	comments, total, err := h.commentsRepo.GetByUserID(r.Context(), id, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch comments", http.StatusInternalServerError)
+		return
+	}
+
+	response := ListCommentsPageResponse{Comments: make([]interface{}, len(comments)), Total: total}
+	for i, comment := range comments {
+		response.Comments[i] = comment
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func userIDParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// pageParams reads limit (default 50, at most 200) and offset from the
+// query string.
+func pageParams(r *http.Request) (int, int) {
+	limit, offset := 50, 0
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+	return limit, offset
+}
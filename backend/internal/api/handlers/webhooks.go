@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/webhooks"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type WebhooksHandler struct {
+	webhooksRepo *repository.WebhooksRepository
+	dispatcher   *webhooks.Dispatcher
+}
+
+func NewWebhooksHandler(webhooksRepo *repository.WebhooksRepository, dispatcher *webhooks.Dispatcher) *WebhooksHandler {
+	return &WebhooksHandler{
+		webhooksRepo: webhooksRepo,
+		dispatcher:   dispatcher,
+	}
+}
+
+type WebhookRequest struct {
+	URL        string          `json:"url"`
+	Secret     string          `json:"secret"` // Generated when empty; ignored on update
+	EventTypes []string        `json:"event_types"`
+	Region     *models.Polygon `json:"region"`
+	Active     *bool           `json:"active"`
+}
+
+type WebhookResponse struct {
+	Webhook interface{} `json:"webhook"`
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []interface{} `json:"webhooks"`
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []interface{} `json:"deliveries"`
+}
+
+type WebhookDeliveryResponse struct {
+	Delivery interface{} `json:"delivery"`
+}
+
+func (h *WebhooksHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateWebhookRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			http.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+			return
+		}
+		secret = hex.EncodeToString(buf)
+	} else if len(secret) < 16 {
+		http.Error(w, "Secret must be at least 16 characters", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.webhooksRepo.CreateSubscription(r.Context(), &models.WebhookSubscription{
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+		Region:     req.Region,
+		Active:     req.Active == nil || *req.Active,
+		CreatedBy:  userID,
+	})
+	if err != nil {
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+
+	// The secret is only shown once, at creation.
+	response := WebhookResponse{Webhook: sub}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WebhooksHandler) List(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhooksRepo.ListSubscriptions(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	subsInterface := make([]interface{}, len(subs))
+	for i, sub := range subs {
+		sub.Secret = ""
+		subsInterface[i] = sub
+	}
+
+	response := ListWebhooksResponse{Webhooks: subsInterface}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WebhooksHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.loadSubscription(w, r)
+	if !ok {
+		return
+	}
+	sub.Secret = ""
+
+	response := WebhookResponse{Webhook: sub}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WebhooksHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateWebhookRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.webhooksRepo.UpdateSubscription(r.Context(), &models.WebhookSubscription{
+		ID:         id,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Region:     req.Region,
+		Active:     req.Active == nil || *req.Active,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+	sub.Secret = ""
+
+	response := WebhookResponse{Webhook: sub}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WebhooksHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.webhooksRepo.DeleteSubscription(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhooksHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.loadSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	offset := 0
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	deliveries, err := h.webhooksRepo.ListDeliveries(r.Context(), sub.ID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	deliveriesInterface := make([]interface{}, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveriesInterface[i] = delivery
+	}
+
+	response := ListWebhookDeliveriesResponse{Deliveries: deliveriesInterface}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Ping sends a signed test delivery right away and returns its outcome.
+func (h *WebhooksHandler) Ping(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.loadSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.dispatcher.Ping(r.Context(), sub)
+	if err != nil {
+		http.Error(w, "Failed to send ping", http.StatusInternalServerError)
+		return
+	}
+
+	response := WebhookDeliveryResponse{Delivery: delivery}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WebhooksHandler) loadSubscription(w http.ResponseWriter, r *http.Request) (*models.WebhookSubscription, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	sub, err := h.webhooksRepo.GetSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+	return sub, true
+}
+
+func validateWebhookRequest(req *WebhookRequest) string {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+
+	if len(req.EventTypes) == 0 {
+		return "At least one event type is required"
+	}
+	for _, eventType := range req.EventTypes {
+		known := false
+		for _, t := range webhooks.EventTypes {
+			if eventType == t {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return "Unknown event type: " + eventType
+		}
+	}
+
+	if req.Region != nil {
+		if msg := validatePolygon(req.Region); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
@@ -2,30 +2,83 @@ package middleware
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fire-tracker/internal/authz"
 	"fire-tracker/internal/repository"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "user_id"
 const UserRoleKey contextKey = "user_role"
+const APIKeyScopesKey contextKey = "api_key_scopes"
+const SessionIDKey contextKey = "session_id"
+const OrganisationIDKey contextKey = "organisation_id"
+
+// APIKeyHeader carries API keys, keeping them apart from session tokens in
+// the Authorization header.
+const APIKeyHeader = "X-API-Key"
+
+// API key scopes. A key only reaches routes guarded by RequireScope with
+// one of its scopes, and only if its user's role has the permission the
+// route requires too.
+const (
+	ScopeFiresRead     = "fires:read"
+	ScopeFiresWrite    = "fires:write"
+	ScopeFiresStatus   = "fires:status"
+	ScopeCommentsWrite = "comments:write"
+	ScopeZonesWrite    = "zones:write"
+	ScopeWebhooks      = "webhooks:manage"
+	ScopeAdmin         = "admin"
+)
+
+var APIKeyScopes = []string{ScopeFiresRead, ScopeFiresWrite, ScopeFiresStatus, ScopeCommentsWrite, ScopeZonesWrite, ScopeWebhooks, ScopeAdmin}
+
+// scopePermissions lists the permissions whose routes each scope reaches.
+// It must follow the router's RequireScope and RequirePermission pairs.
+var scopePermissions = map[string][]authz.Permission{
+	ScopeFiresWrite:    {authz.FireReport},
+	ScopeFiresStatus:   {authz.FireStatusUpdate, authz.FireModerate, authz.FireTransfer, authz.FireMerge},
+	ScopeCommentsWrite: {authz.CommentCreate, authz.CommentDelete},
+	ScopeZonesWrite:    {authz.ZoneManage},
+	ScopeWebhooks:      {authz.WebhookManage},
+	ScopeAdmin:         {authz.UserManage, authz.OrganisationManage, authz.JobView},
+}
+
+// lastUsedResolution limits how often a busy session's or key's last use
+// is written.
+const lastUsedResolution = time.Minute
 
 type AuthMiddleware struct {
 	sessionsRepo *repository.SessionsRepository
 	usersRepo    *repository.UsersRepository
+	apiKeysRepo  *repository.APIKeysRepository
+	sessionIdle  time.Duration // How far each use pushes a session's expiry out
 }
 
-func NewAuthMiddleware(sessionsRepo *repository.SessionsRepository, usersRepo *repository.UsersRepository) *AuthMiddleware {
+func NewAuthMiddleware(sessionsRepo *repository.SessionsRepository, usersRepo *repository.UsersRepository, apiKeysRepo *repository.APIKeysRepository, sessionIdle time.Duration) *AuthMiddleware {
 	return &AuthMiddleware{
 		sessionsRepo: sessionsRepo,
 		usersRepo:    usersRepo,
+		apiKeysRepo:  apiKeysRepo,
+		sessionIdle:  sessionIdle,
 	}
 }
 
+// Authenticate accepts a session token in the Authorization header or an
+// API key in the X-API-Key header.
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			m.authenticateAPIKey(w, r, next, key)
+			return
+		}
+
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -38,42 +91,202 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		token := parts[1]
-		session, err := m.sessionsRepo.GetByID(r.Context(), token)
-		if err != nil {
-			http.Error(w, "Invalid or expired session", http.StatusUnauthorized)
+		m.authenticateToken(w, r, next, parts[1])
+	})
+}
+
+// AuthenticateStream is Authenticate for streaming endpoints such as
+// WebSockets, where browsers cannot set headers. The bearer token may also be
+// passed as the access_token query parameter.
+func (m *AuthMiddleware) AuthenticateStream(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get(APIKeyHeader) != "" {
+			m.Authenticate(next).ServeHTTP(w, r)
 			return
 		}
 
-		user, err := m.usersRepo.GetByID(r.Context(), session.UserID)
-		if err != nil {
-			http.Error(w, "User not found", http.StatusUnauthorized)
+		token := r.URL.Query().Get("access_token")
+		if token == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), UserIDKey, user.ID)
-		ctx = context.WithValue(ctx, UserRoleKey, user.Role)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		m.authenticateToken(w, r, next, token)
 	})
 }
 
-func (m *AuthMiddleware) RequireFirefighter(next http.Handler) http.Handler {
+func (m *AuthMiddleware) authenticateToken(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	session, err := m.sessionsRepo.GetByToken(r.Context(), token)
+	if err != nil {
+		http.Error(w, "Invalid or expired session", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := m.usersRepo.GetByID(r.Context(), session.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+	if user.SuspendedAt != nil {
+		http.Error(w, "Account suspended", http.StatusForbidden)
+		return
+	}
+
+	if time.Since(session.LastSeenAt) > lastUsedResolution {
+		m.sessionsRepo.Touch(r.Context(), session.ID, m.sessionIdle, Client(r))
+	}
+
+	ctx := context.WithValue(r.Context(), UserIDKey, user.ID)
+	ctx = context.WithValue(ctx, UserRoleKey, user.Role)
+	ctx = context.WithValue(ctx, OrganisationIDKey, user.OrganisationID)
+	ctx = context.WithValue(ctx, SessionIDKey, session.ID)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (m *AuthMiddleware) authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	sum := sha256.Sum256([]byte(key))
+	apiKey, err := m.apiKeysRepo.GetActiveByHash(r.Context(), hex.EncodeToString(sum[:]))
+	if err != nil {
+		http.Error(w, "Invalid or expired API key", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := m.usersRepo.GetByID(r.Context(), apiKey.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+	if user.SuspendedAt != nil {
+		http.Error(w, "Account suspended", http.StatusForbidden)
+		return
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > lastUsedResolution {
+		m.apiKeysRepo.TouchLastUsed(r.Context(), apiKey.ID)
+	}
+
+	ctx := context.WithValue(r.Context(), UserIDKey, user.ID)
+	ctx = context.WithValue(ctx, UserRoleKey, user.Role)
+	ctx = context.WithValue(ctx, OrganisationIDKey, user.OrganisationID)
+	ctx = context.WithValue(ctx, APIKeyScopesKey, apiKey.Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireScope limits API keys to routes matching one of their scopes.
+// Session-authenticated requests are not affected.
+func (m *AuthMiddleware) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, isKey := r.Context().Value(APIKeyScopesKey).([]string)
+			if isKey && !containsScope(scopes, scope) {
+				http.Error(w, "Forbidden: API key lacks the "+scope+" scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession keeps API keys away from account routes such as password
+// changes.
+func (m *AuthMiddleware) RequireSession(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		role, ok := r.Context().Value(UserRoleKey).(string)
-		if !ok || role != "firefighter" {
-			http.Error(w, "Forbidden: Firefighter role required", http.StatusForbidden)
+		if _, isKey := r.Context().Value(APIKeyScopesKey).([]string); isKey {
+			http.Error(w, "Forbidden: Not available to API keys", http.StatusForbidden)
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// RequirePermission lets through users whose role grants permission.
+func (m *AuthMiddleware) RequirePermission(permission authz.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value(UserRoleKey).(string)
+			if !authz.Can(role, permission) {
+				http.Error(w, "Forbidden: "+string(permission)+" permission required", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func GetUserID(ctx context.Context) (int, bool) {
 	userID, ok := ctx.Value(UserIDKey).(int)
 	return userID, ok
 }
 
+// GetSessionID returns the caller's session, which is missing for API
+// keys.
+func GetSessionID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(SessionIDKey).(string)
+	return id, ok
+}
+
+// Client describes the device a request comes from. It relies on the
+// RealIP middleware having set RemoteAddr.
+func Client(r *http.Request) repository.SessionClient {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	userAgent := r.UserAgent()
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	return repository.SessionClient{IP: ip, UserAgent: userAgent}
+}
+
 func GetUserRole(ctx context.Context) (string, bool) {
 	role, ok := ctx.Value(UserRoleKey).(string)
 	return role, ok
 }
+
+// GetOrganisationID returns the caller's organisation, or nil if they
+// belong to none.
+func GetOrganisationID(ctx context.Context) *int {
+	id, _ := ctx.Value(OrganisationIDKey).(*int)
+	return id
+}
+
+// EffectivePermissions lists what the caller may do: their role's
+// permissions, narrowed to its scopes when they use an API key.
+func EffectivePermissions(ctx context.Context) []authz.Permission {
+	role, _ := GetUserRole(ctx)
+	permissions := authz.PermissionsFor(role)
+	scopes, isKey := ctx.Value(APIKeyScopesKey).([]string)
+	if !isKey {
+		return permissions
+	}
+
+	effective := []authz.Permission{}
+	for _, permission := range permissions {
+		for _, scope := range scopes {
+			if containsPermission(scopePermissions[scope], permission) {
+				effective = append(effective, permission)
+				break
+			}
+		}
+	}
+	return effective
+}
+
+func containsPermission(permissions []authz.Permission, permission authz.Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
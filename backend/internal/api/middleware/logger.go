@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Logger is chi's request logger with the values of secret query
+// parameters, such as the access_token streaming endpoints accept, replaced
+// so credentials never reach the access log.
+func Logger(secretParams ...string) func(http.Handler) http.Handler {
+	return chimiddleware.RequestLogger(&redactingFormatter{
+		next:   &chimiddleware.DefaultLogFormatter{Logger: log.New(os.Stdout, "", log.LstdFlags), NoColor: true},
+		params: secretParams,
+	})
+}
+
+type redactingFormatter struct {
+	next   chimiddleware.LogFormatter
+	params []string
+}
+
+// NewLogEntry hands the formatter a copy of r with the secret parameters
+// redacted; the handlers still see the original.
+func (f *redactingFormatter) NewLogEntry(r *http.Request) chimiddleware.LogEntry {
+	query := r.URL.Query()
+	redacted := false
+	for _, param := range f.params {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return f.next.NewLogEntry(r)
+	}
+
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	logged := *r
+	logged.URL = &u
+	logged.RequestURI = u.RequestURI()
+	return f.next.NewLogEntry(&logged)
+}
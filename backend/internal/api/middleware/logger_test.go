@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestLoggerRedactsSecretParams(t *testing.T) {
+	var buf bytes.Buffer
+	logger := chimiddleware.RequestLogger(&redactingFormatter{
+		next:   &chimiddleware.DefaultLogFormatter{Logger: log.New(&buf, "", 0), NoColor: true},
+		params: []string{"access_token"},
+	})
+
+	var seen string
+	handler := logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.Query().Get("access_token")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/ws?access_token=secret-token&since=5", nil))
+
+	if seen != "secret-token" {
+		t.Errorf("handler saw token %q, want the original", seen)
+	}
+	line := buf.String()
+	if strings.Contains(line, "secret-token") {
+		t.Errorf("token reached the log: %s", line)
+	}
+	if !strings.Contains(line, "access_token=REDACTED") || !strings.Contains(line, "since=5") {
+		t.Errorf("unexpected log line: %s", line)
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"fire-tracker/internal/ratelimit"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// RateLimiter applies token-bucket limits per client IP and per user.
+// Requests from allow-listed networks or users are never limited.
+type RateLimiter struct {
+	store      ratelimit.Store // Nil disables limiting
+	allowNets  []*net.IPNet
+	allowUsers map[int]bool
+	logger     *zap.Logger
+}
+
+func NewRateLimiter(store ratelimit.Store, allowNets []*net.IPNet, allowUsers []int, logger *zap.Logger) *RateLimiter {
+	users := make(map[int]bool, len(allowUsers))
+	for _, id := range allowUsers {
+		users[id] = true
+	}
+	return &RateLimiter{
+		store:      store,
+		allowNets:  allowNets,
+		allowUsers: users,
+		logger:     logger,
+	}
+}
+
+// Limit returns middleware enforcing perIP and perUser under name, which
+// keeps buckets of different routes apart. The per-user limit only applies
+// after Authenticate; a zero Limit is skipped. Store errors let requests
+// through, so an outage of the store does not take the routes down too.
+func (l *RateLimiter) Limit(name string, perIP, perUser ratelimit.Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l.store == nil || (!perIP.Enabled() && !perUser.Enabled()) {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, hasUser := GetUserID(r.Context())
+			if hasUser && l.allowUsers[userID] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ip := Client(r).IP
+			if containsIP(l.allowNets, ip) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if perIP.Enabled() && !l.take(w, r, name+":ip:"+ip, perIP) {
+				return
+			}
+			if hasUser && perUser.Enabled() && !l.take(w, r, name+":user:"+strconv.Itoa(userID), perUser) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LimitKey returns middleware enforcing limit per value of key, such as the
+// account a login is for, under name. Requests key gives no value for, and
+// requests from allow-listed networks, pass.
+func (l *RateLimiter) LimitKey(name string, limit ratelimit.Limit, key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l.store == nil || !limit.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := key(r)
+			if value == "" || containsIP(l.allowNets, Client(r).IP) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !l.take(w, r, name+":"+value, limit) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// take writes a 429 response and returns false when key is out of tokens.
+func (l *RateLimiter) take(w http.ResponseWriter, r *http.Request, key string, limit ratelimit.Limit) bool {
+	allowed, retryAfter, err := l.store.Take(r.Context(), key, limit)
+	if err != nil {
+		l.logger.Error("rate limit check failed", zap.String("key", key), zap.Error(err))
+		return true
+	}
+	if allowed {
+		return true
+	}
+
+	l.logger.Info("rate limit exceeded", zap.String("key", key), zap.Stringer("limit", limit), zap.Duration("retry_after", retryAfter))
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(retryAfter.Seconds())))))
+	http.Error(w, "Too many requests", http.StatusTooManyRequests)
+	return false
+}
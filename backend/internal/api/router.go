@@ -1,11 +1,34 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/alerts"
 	"fire-tracker/internal/api/handlers"
 	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/authz"
 	"fire-tracker/internal/config"
+	"fire-tracker/internal/eventbus"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/jobs"
+	"fire-tracker/internal/notify"
+	"fire-tracker/internal/oidc"
+	"fire-tracker/internal/outbox"
+	"fire-tracker/internal/password"
+	"fire-tracker/internal/ratelimit"
+	"fire-tracker/internal/realtime"
 	"fire-tracker/internal/repository"
+	"fire-tracker/internal/spread"
+	"fire-tracker/internal/weather"
+	"fire-tracker/internal/webhooks"
+	"fire-tracker/internal/webpush"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
@@ -14,18 +37,24 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewRouter(db *pgxpool.Pool, cfg *config.Config, logger *zap.Logger) http.Handler {
+var allowedOrigins = []string{"http://localhost:3000", "http://localhost:3001"}
+
+// NewRouter builds the API and starts its background workers, which run
+// until ctx is cancelled. Cancel it when shutting the server down so they
+// stop before db is closed.
+func NewRouter(ctx context.Context, db *pgxpool.Pool, cfg *config.Config, logger *zap.Logger) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
-	r.Use(chimiddleware.Logger)
+	r.Use(middleware.RealIP(parseNetworks(cfg.TrustedProxies, "trusted proxy", logger)))
+	r.Use(middleware.Logger("access_token"))
 	r.Use(chimiddleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:3001"},
+		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Last-Event-ID"},
+		ExposedHeaders:   []string{"Retry-After"},
 		AllowCredentials: true,
 	}))
 
@@ -34,14 +63,155 @@ func NewRouter(db *pgxpool.Pool, cfg *config.Config, logger *zap.Logger) http.Ha
 	sessionsRepo := repository.NewSessionsRepository(db)
 	firesRepo := repository.NewFiresRepository(db)
 	commentsRepo := repository.NewCommentsRepository(db)
+	weatherRepo := repository.NewWeatherRepository(db)
+	zonesRepo := repository.NewEvacuationZonesRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	webhooksRepo := repository.NewWebhooksRepository(db)
+	areaSubscriptionsRepo := repository.NewAreaSubscriptionsRepository(db)
+	notificationsRepo := repository.NewNotificationsRepository(db)
+	pushRepo := repository.NewPushSubscriptionsRepository(db)
+	placesRepo := repository.NewPlacesRepository(db)
+	passwordResetsRepo := repository.NewPasswordResetsRepository(db)
+	roleRequestsRepo := repository.NewRoleRequestsRepository(db)
+	identitiesRepo := repository.NewIdentitiesRepository(db)
+	apiKeysRepo := repository.NewAPIKeysRepository(db)
+	rateLimitsRepo := repository.NewRateLimitsRepository(db)
+	reportChallengesRepo := repository.NewReportChallengesRepository(db)
+	organisationsRepo := repository.NewOrganisationsRepository(db)
+
+	// Services
+	broker := events.NewBroker(cfg.EventReplayBufferSize)
+	listener := eventbus.NewListener(cfg.DatabaseURL, broker, firesRepo, commentsRepo, logger)
+	go listener.Run(ctx)
+	relay := outbox.NewRelay(outboxRepo, outbox.Config{
+		PollInterval: cfg.OutboxPollInterval(),
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+		BaseBackoff:  5 * time.Second,
+		MaxBackoff:   30 * time.Minute,
+		Lease:        time.Minute,
+	}, logger)
+	if weatherRecorder := newWeatherRecorder(cfg, weatherRepo); weatherRecorder != nil {
+		relay.Register(weatherRecorder)
+	}
+	relay.Register(webhooks.NewConsumer(webhooksRepo, firesRepo))
+	relay.Register(alerts.NewConsumer(areaSubscriptionsRepo, notificationsRepo, pushRepo))
+	go relay.Run(ctx)
+
+	webhookDispatcher := webhooks.NewDispatcher(webhooksRepo, webhooks.NewSender(cfg.WebhookTimeout()), webhooks.DispatcherConfig{
+		PollInterval: cfg.OutboxPollInterval(),
+		BatchSize:    cfg.OutboxBatchSize,
+		Concurrency:  8,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		Lease:        cfg.WebhookTimeout() + time.Minute,
+	}, logger)
+	go webhookDispatcher.Run(ctx)
+
+	notifyWorker := notify.NewWorker(notificationsRepo, firesRepo, notify.NewTemplates(cfg.FrontendURL), notify.WorkerConfig{
+		PollInterval: cfg.OutboxPollInterval(),
+		BatchSize:    cfg.OutboxBatchSize,
+		Concurrency:  8,
+		MaxAttempts:  cfg.NotifyMaxAttempts,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   time.Hour,
+		Lease:        cfg.NotifyTimeout() + time.Minute,
+	}, logger)
+	for _, notifier := range newNotifiers(cfg) {
+		notifyWorker.Register(notifier)
+	}
+	emailNotifier := newEmailNotifier(cfg)
+	if emailNotifier != nil {
+		notifyWorker.Register(emailNotifier)
+	}
+	smsNotifier := newSMSNotifier(cfg)
+	if smsNotifier != nil {
+		notifyWorker.Register(smsNotifier)
+	}
+	vapidKeys := newVAPIDKeys(cfg, logger)
+	if vapidKeys != nil {
+		notifyWorker.Register(notify.NewPushNotifier(pushRepo, webpush.NewSender(vapidKeys, cfg.PushTTL(), cfg.NotifyTimeout())))
+	}
+	go notifyWorker.Run(ctx)
+
+	jobRunner := jobs.NewRunner(repository.NewLeaderLock(db, "fire-tracker:jobs"), jobs.Config{
+		LeaderCheckInterval: cfg.JobLeaderCheckInterval(),
+	}, logger)
+	jobRunner.Register(jobs.NewSessionCleanup(sessionsRepo, newJobSchedule(cfg.SessionCleanupSchedule, time.Hour, logger), logger))
+	jobRunner.Register(jobs.NewReportChallengeCleanup(reportChallengesRepo, logger))
+	if cfg.OutboxRetentionDays > 0 {
+		jobRunner.Register(jobs.NewOutboxCleanup(outboxRepo, cfg.OutboxRetention(), logger))
+	}
+
+	authRateLimit := newRateLimit(cfg.RateLimitAuthIP, logger)
+	loginRateLimit := newRateLimit(cfg.RateLimitAuthUsername, logger)
+	firesIPRateLimit := newRateLimit(cfg.RateLimitFiresIP, logger)
+	firesUserRateLimit := newRateLimit(cfg.RateLimitFiresUser, logger)
+	commentsIPRateLimit := newRateLimit(cfg.RateLimitCommentsIP, logger)
+	commentsUserRateLimit := newRateLimit(cfg.RateLimitCommentsUser, logger)
+	anonymousRateLimit := newRateLimit(cfg.RateLimitAnonymousIP, logger)
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimitStore {
+	case "off":
+	case "postgres":
+		rateLimitStore = ratelimit.NewPostgresStore(rateLimitsRepo)
+		// A bucket must not be deleted before it would have refilled
+		idle := 24 * time.Hour
+		for _, limit := range []ratelimit.Limit{authRateLimit, loginRateLimit, firesIPRateLimit, firesUserRateLimit, commentsIPRateLimit, commentsUserRateLimit, anonymousRateLimit} {
+			idle = max(idle, limit.Per)
+		}
+		jobRunner.Register(jobs.NewRateLimitCleanup(rateLimitsRepo, idle, logger))
+	default:
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	go jobRunner.Run(ctx)
+
+	passwordHasher, err := password.NewHasher(cfg.PasswordHasher)
+	if err != nil {
+		logger.Error("falling back to argon2id password hashing", zap.Error(err))
+		passwordHasher = password.DefaultArgon2id
+	}
+	var resetSender notify.Notifier = notify.NewLogNotifier("email", logger)
+	if emailNotifier != nil {
+		resetSender = emailNotifier
+	}
 
 	// Handlers
-	authHandler := handlers.NewAuthHandler(usersRepo, sessionsRepo, cfg)
-	firesHandler := handlers.NewFiresHandler(firesRepo)
+	authHandler := handlers.NewAuthHandler(usersRepo, sessionsRepo, passwordResetsRepo, passwordHasher, resetSender, cfg)
+	oidcHandler := handlers.NewOIDCHandler(newOIDCClient(cfg), identitiesRepo, usersRepo, sessionsRepo, cfg, logger)
+	firesHandler := handlers.NewFiresHandler(firesRepo, weatherRepo, usersRepo, handlers.ModerationPolicy{
+		TrustThreshold: cfg.ModerationTrustThreshold,
+		NewUserAge:     cfg.ModerationNewUserAge(),
+		Anonymous:      cfg.ModerationAnonymous,
+	})
+	moderationHandler := handlers.NewModerationHandler(firesRepo)
+	anonymousReportsHandler := handlers.NewAnonymousReportsHandler(firesHandler, reportChallengesRepo, cfg.ReportPoWDifficulty, cfg.ReportChallengeTTL())
 	commentsHandler := handlers.NewCommentsHandler(commentsRepo)
+	zonesHandler := handlers.NewEvacuationZonesHandler(zonesRepo, firesRepo)
+	projectionsHandler := handlers.NewProjectionsHandler(firesRepo, weatherRepo, spread.NewEllipticalModel(cfg.SpreadBaseRate, cfg.SpreadWindFactor))
+	eventsHandler := handlers.NewEventsHandler(broker)
+	webhooksHandler := handlers.NewWebhooksHandler(webhooksRepo, webhookDispatcher)
+	areaSubscriptionsHandler := handlers.NewAreaSubscriptionsHandler(areaSubscriptionsRepo, usersRepo)
+	var vapidPublicKey string
+	if vapidKeys != nil {
+		vapidPublicKey = vapidKeys.PublicKey()
+	}
+	pushSubscriptionsHandler := handlers.NewPushSubscriptionsHandler(pushRepo, vapidPublicKey, cfg.PushHostList())
+	roleRequestsHandler := handlers.NewRoleRequestsHandler(roleRequestsRepo, usersRepo)
+	usersHandler := handlers.NewUsersHandler(usersRepo, sessionsRepo, firesRepo, commentsRepo)
+	apiKeysHandler := handlers.NewAPIKeysHandler(apiKeysRepo, usersRepo)
+	jobsHandler := handlers.NewJobsHandler(jobRunner)
+	organisationsHandler := handlers.NewOrganisationsHandler(organisationsRepo)
+	smsHandler := handlers.NewSMSHandler(firesHandler, usersRepo, commentsRepo, placesRepo, smsNotifier, cfg.SMSInboundToken, cfg.SMSFollowUp())
+
+	// Realtime
+	hub := realtime.NewHub(broker, firesRepo, allowedOrigins, logger)
+	go hub.Run(ctx)
 
 	// Middleware
-	authMiddleware := middleware.NewAuthMiddleware(sessionsRepo, usersRepo)
+	authMiddleware := middleware.NewAuthMiddleware(sessionsRepo, usersRepo, apiKeysRepo, cfg.SessionExpiry())
+	rateLimiter := newRateLimiter(cfg, rateLimitStore, logger)
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -52,33 +222,328 @@ func NewRouter(db *pgxpool.Pool, cfg *config.Config, logger *zap.Logger) http.Ha
 	// API routes
 	r.Route("/api", func(r chi.Router) {
 		// Auth routes
-		r.Post("/auth/login", authHandler.Login)
+		r.Group(func(r chi.Router) {
+			r.Use(rateLimiter.Limit("auth", authRateLimit, ratelimit.Limit{}))
+			r.Post("/auth/register", authHandler.Register)
+			r.With(rateLimiter.LimitKey("login", loginRateLimit, loginUsername)).Post("/auth/login", authHandler.Login)
+			r.Post("/auth/password-reset", authHandler.RequestPasswordReset)
+			r.Post("/auth/password-reset/confirm", authHandler.ConfirmPasswordReset)
+		})
+		r.Post("/auth/refresh", authHandler.Refresh)
+		r.Get("/auth/oidc", oidcHandler.Config)
+		r.Get("/auth/oidc/login", oidcHandler.Login)
+		r.Get("/auth/oidc/callback", oidcHandler.Callback)
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.Authenticate)
 			r.Get("/auth/me", authHandler.Me)
-			r.Post("/auth/logout", authHandler.Logout)
+
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.RequireSession)
+				r.Post("/auth/logout", authHandler.Logout)
+				r.Post("/auth/logout-all", authHandler.LogoutAll)
+				r.Get("/auth/sessions", authHandler.ListSessions)
+				r.Delete("/auth/sessions/{id}", authHandler.RevokeSession)
+				r.Post("/auth/password", authHandler.ChangePassword)
+				r.Post("/auth/oidc/link", oidcHandler.StartLink)
+				r.Post("/auth/oidc/link/confirm", oidcHandler.ConfirmLink)
+			})
+		})
+
+		// Personal routes
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireSession)
+			r.Get("/me/subscriptions", areaSubscriptionsHandler.List)
+			r.Post("/me/subscriptions", areaSubscriptionsHandler.Create)
+			r.Get("/me/subscriptions/{id}", areaSubscriptionsHandler.Get)
+			r.Put("/me/subscriptions/{id}", areaSubscriptionsHandler.Update)
+			r.Delete("/me/subscriptions/{id}", areaSubscriptionsHandler.Delete)
+			r.Get("/me/push-subscriptions", pushSubscriptionsHandler.List)
+			r.Post("/me/push-subscriptions", pushSubscriptionsHandler.Create)
+			r.Delete("/me/push-subscriptions/{id}", pushSubscriptionsHandler.Delete)
+			r.Get("/me/role-request", roleRequestsHandler.Get)
+			r.Post("/me/role-request", roleRequestsHandler.Create)
 		})
 
+		// Admin routes
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(middleware.ScopeAdmin))
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.RequirePermission(authz.UserManage))
+				r.Get("/admin/users", usersHandler.List)
+				r.Get("/admin/users/{id}", usersHandler.Get)
+				r.Patch("/admin/users/{id}", usersHandler.Update)
+				r.Post("/admin/users/{id}/suspend", usersHandler.Suspend)
+				r.Post("/admin/users/{id}/unsuspend", usersHandler.Unsuspend)
+				r.Get("/admin/users/{id}/fires", usersHandler.ListFires)
+				r.Get("/admin/users/{id}/comments", usersHandler.ListComments)
+				r.Put("/admin/users/{id}/organisation", usersHandler.SetOrganisation)
+				r.Post("/admin/users/{id}/password-reset", authHandler.IssuePasswordReset)
+				r.Get("/admin/role-requests", roleRequestsHandler.List)
+				r.Post("/admin/role-requests/{id}/approve", roleRequestsHandler.Approve)
+				r.Post("/admin/role-requests/{id}/deny", roleRequestsHandler.Deny)
+				r.Get("/admin/invites", roleRequestsHandler.ListInvites)
+				r.Post("/admin/invites", roleRequestsHandler.CreateInvite)
+				r.Delete("/admin/invites/{id}", roleRequestsHandler.RevokeInvite)
+			})
+			r.With(authMiddleware.RequirePermission(authz.JobView)).Get("/admin/jobs", jobsHandler.Status)
+
+			// Keys cannot be used to mint more keys
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.RequireSession)
+				r.Use(authMiddleware.RequirePermission(authz.APIKeyManage))
+				r.Get("/admin/api-keys", apiKeysHandler.List)
+				r.Post("/admin/api-keys", apiKeysHandler.Create)
+				r.Delete("/admin/api-keys/{id}", apiKeysHandler.Revoke)
+			})
+		})
+
+		// Organisations
+		r.Get("/organisations", organisationsHandler.List)
+		r.Get("/organisations/{id}", organisationsHandler.Get)
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(middleware.ScopeAdmin))
+			r.Use(authMiddleware.RequirePermission(authz.OrganisationManage))
+			r.Post("/organisations", organisationsHandler.Create)
+			r.Put("/organisations/{id}", organisationsHandler.Update)
+			r.Delete("/organisations/{id}", organisationsHandler.Delete)
+		})
+
+		// Web Push
+		r.Get("/push/vapid-public-key", pushSubscriptionsHandler.PublicKey)
+
+		// Event stream
+		r.Get("/events", eventsHandler.Stream)
+		r.With(authMiddleware.AuthenticateStream, authMiddleware.RequireScope(middleware.ScopeFiresRead)).Get("/ws", hub.ServeHTTP)
+
 		// Fires routes
 		r.Get("/fires", firesHandler.List)
 		r.Get("/fires/{id}", firesHandler.Get)
+		r.Get("/fires/{id}/projection", projectionsHandler.Get)
+		r.Group(func(r chi.Router) {
+			r.Use(rateLimiter.Limit("anonymous", anonymousRateLimit, ratelimit.Limit{}))
+			r.Post("/fires/anonymous/challenge", anonymousReportsHandler.Challenge)
+			r.Post("/fires/anonymous", anonymousReportsHandler.Create)
+		})
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.Authenticate)
-			r.Post("/fires", firesHandler.Create)
+			r.With(
+				authMiddleware.RequireScope(middleware.ScopeFiresWrite),
+				authMiddleware.RequirePermission(authz.FireReport),
+				rateLimiter.Limit("fires", firesIPRateLimit, firesUserRateLimit),
+			).Post("/fires", firesHandler.Create)
 
 			r.Group(func(r chi.Router) {
-				r.Use(authMiddleware.RequireFirefighter)
-				r.Patch("/fires/{id}/status", firesHandler.UpdateStatus)
+				r.Use(authMiddleware.RequireScope(middleware.ScopeFiresStatus))
+				r.With(authMiddleware.RequirePermission(authz.FireStatusUpdate)).Patch("/fires/{id}/status", firesHandler.UpdateStatus)
+				r.With(authMiddleware.RequirePermission(authz.FireTransfer)).Put("/fires/{id}/organisation", firesHandler.Transfer)
+				r.With(authMiddleware.RequirePermission(authz.FireMerge)).Post("/fires/{id}/merge", firesHandler.Merge)
+				r.Group(func(r chi.Router) {
+					r.Use(authMiddleware.RequirePermission(authz.FireModerate))
+					r.Post("/fires/{id}/verdict", moderationHandler.SetVerdict)
+					r.Get("/moderation/fires", moderationHandler.Queue)
+					r.Get("/moderation/fires/{id}", moderationHandler.Get)
+					r.Post("/moderation/fires/{id}/approve", moderationHandler.Approve)
+					r.Post("/moderation/fires/{id}/reject", moderationHandler.Reject)
+				})
 			})
 		})
 
+		// Inbound SMS reports (gateway token)
+		r.Post("/sms/inbound", smsHandler.Inbound)
+
+		// Evacuation zones routes
+		r.Get("/evacuation-zones", zonesHandler.List)
+		r.Get("/evacuation-zones/check", zonesHandler.Check)
+		r.Get("/evacuation-zones/{id}", zonesHandler.Get)
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(middleware.ScopeZonesWrite))
+			r.Use(authMiddleware.RequirePermission(authz.ZoneManage))
+			r.Post("/evacuation-zones", zonesHandler.Create)
+			r.Put("/evacuation-zones/{id}", zonesHandler.Update)
+			r.Delete("/evacuation-zones/{id}", zonesHandler.Delete)
+		})
+
+		// Webhook management routes
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(middleware.ScopeWebhooks))
+			r.Use(authMiddleware.RequirePermission(authz.WebhookManage))
+			r.Get("/webhooks", webhooksHandler.List)
+			r.Post("/webhooks", webhooksHandler.Create)
+			r.Get("/webhooks/{id}", webhooksHandler.Get)
+			r.Put("/webhooks/{id}", webhooksHandler.Update)
+			r.Delete("/webhooks/{id}", webhooksHandler.Delete)
+			r.Get("/webhooks/{id}/deliveries", webhooksHandler.ListDeliveries)
+			r.Post("/webhooks/{id}/ping", webhooksHandler.Ping)
+		})
+
 		// Comments routes
 		r.Get("/fires/{id}/comments", commentsHandler.List)
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.Authenticate)
-			r.Post("/fires/{id}/comments", commentsHandler.Create)
+			r.Use(authMiddleware.RequireScope(middleware.ScopeCommentsWrite))
+			r.With(
+				authMiddleware.RequirePermission(authz.CommentCreate),
+				rateLimiter.Limit("comments", commentsIPRateLimit, commentsUserRateLimit),
+			).Post("/fires/{id}/comments", commentsHandler.Create)
+			r.With(authMiddleware.RequirePermission(authz.CommentDelete)).Delete("/fires/{id}/comments/{commentID}", commentsHandler.Delete)
 		})
 	})
 
 	return r
 }
+
+func newWeatherRecorder(cfg *config.Config, weatherRepo *repository.WeatherRepository) *weather.Recorder {
+	var provider weather.Provider
+	switch cfg.WeatherProvider {
+	case "http":
+		provider = weather.NewHTTPProvider(cfg.WeatherAPIURL, cfg.WeatherTimeout())
+	case "file":
+		provider = weather.NewFileProvider(cfg.WeatherFile)
+	default:
+		return nil
+	}
+	return weather.NewRecorder(provider, weatherRepo)
+}
+
+// newJobSchedule falls back to running every fallback when spec is invalid,
+// so a typo in the environment does not stop housekeeping altogether.
+func newJobSchedule(spec string, fallback time.Duration, logger *zap.Logger) jobs.Schedule {
+	schedule, err := jobs.ParseSchedule(spec)
+	if err != nil {
+		logger.Error("invalid job schedule, using default", zap.String("schedule", spec), zap.Duration("default", fallback), zap.Error(err))
+		return jobs.Every(fallback)
+	}
+	return schedule
+}
+
+// newRateLimit disables the limit when spec is invalid, and says so.
+func newRateLimit(spec string, logger *zap.Logger) ratelimit.Limit {
+	limit, err := ratelimit.ParseLimit(spec)
+	if err != nil {
+		logger.Error("invalid rate limit, disabling it", zap.Error(err))
+	}
+	return limit
+}
+
+// loginUsername keys login attempts by the account they are for. It reads
+// the body and puts it back for the handler.
+func loginUsername(r *http.Request) string {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<16))
+	if err != nil {
+		return ""
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req handlers.LoginRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(req.Username))
+}
+
+// parseNetworks reads comma-separated IPs and CIDRs, logging and skipping
+// invalid entries.
+func parseNetworks(spec, what string, logger *zap.Logger) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			logger.Error("invalid "+what+" entry", zap.String("entry", entry), zap.Error(err))
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func newRateLimiter(cfg *config.Config, store ratelimit.Store, logger *zap.Logger) *middleware.RateLimiter {
+	allowNets := parseNetworks(cfg.RateLimitAllowIPs, "rate limit allow-list", logger)
+
+	var allowUsers []int
+	for _, entry := range strings.Split(cfg.RateLimitAllowUserIDs, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, err := strconv.Atoi(entry)
+		if err != nil {
+			logger.Error("invalid rate limit allow-list user ID", zap.String("entry", entry))
+			continue
+		}
+		allowUsers = append(allowUsers, id)
+	}
+
+	return middleware.NewRateLimiter(store, allowNets, allowUsers, logger)
+}
+
+func newNotifiers(cfg *config.Config) []notify.Notifier {
+	var notifiers []notify.Notifier
+	if cfg.TelegramBotToken != "" {
+		notifiers = append(notifiers, notify.NewTelegramNotifier(cfg.TelegramAPIURL, cfg.TelegramBotToken, cfg.NotifyTimeout()))
+	}
+	return notifiers
+}
+
+// newEmailNotifier returns nil when no SMTP relay is configured. It is kept
+// apart from the other notifiers because password resets are sent through
+// it.
+func newEmailNotifier(cfg *config.Config) *notify.EmailNotifier {
+	if cfg.SMTPHost == "" {
+		return nil
+	}
+	return notify.NewEmailNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, cfg.NotifyTimeout())
+}
+
+// newSMSNotifier returns nil when no SMS gateway is configured. It is kept
+// apart from the other notifiers because SMS intake replies through it.
+func newSMSNotifier(cfg *config.Config) *notify.SMSNotifier {
+	if cfg.SMSGatewayURL == "" {
+		return nil
+	}
+	return notify.NewSMSNotifier(cfg.SMSGatewayURL, cfg.SMSGatewayAPIKey, cfg.SMSSender, cfg.NotifyTimeout())
+}
+
+// newOIDCClient returns nil when single sign-on is not configured.
+func newOIDCClient(cfg *config.Config) *oidc.Client {
+	if cfg.OIDCIssuerURL == "" {
+		return nil
+	}
+	return oidc.NewClient(oidc.Config{
+		Issuer:       cfg.OIDCIssuerURL,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       strings.Fields(cfg.OIDCScopes),
+		GroupsClaim:  cfg.OIDCGroupsClaim,
+	}, 10*time.Second)
+}
+
+// newVAPIDKeys parses the configured Web Push keys. Push is disabled when
+// they are missing or invalid.
+func newVAPIDKeys(cfg *config.Config, logger *zap.Logger) *webpush.VAPIDKeys {
+	if cfg.VAPIDPrivateKey == "" {
+		return nil
+	}
+	keys, err := webpush.ParseVAPIDKeys(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+	if err != nil {
+		logger.Error("web push disabled", zap.Error(err))
+		return nil
+	}
+	return keys
+}
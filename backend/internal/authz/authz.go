@@ -0,0 +1,66 @@
+// Package authz maps roles to the permissions they grant. Routes and
+// handlers check permissions rather than role names, so a role can be
+// added or reshaped here without touching them.
+package authz
+
+// Permission names one thing a user may do.
+type Permission string
+
+const (
+	FireReport         Permission = "fire.report"
+	FireStatusUpdate   Permission = "fire.status.update"
+	FireModerate       Permission = "fire.moderate" // Verdicts and the moderation queue; moderators' own reports skip it
+	FireTransfer       Permission = "fire.transfer" // Handing a fire over to another organisation
+	FireMerge          Permission = "fire.merge"    // Folding a duplicate report into the fire it describes
+	ZoneManage         Permission = "zone.manage"
+	CommentCreate      Permission = "comment.create"
+	CommentDelete      Permission = "comment.delete"
+	UserManage         Permission = "user.manage" // Accounts, role requests, invites and memberships
+	OrganisationManage Permission = "organisation.manage"
+	WebhookManage      Permission = "webhook.manage"
+	APIKeyManage       Permission = "apikey.manage"
+	JobView            Permission = "job.view"
+)
+
+// Roles, from least to most privileged.
+const (
+	Citizen     = "citizen"
+	Firefighter = "firefighter"
+	Dispatcher  = "dispatcher"
+	Commander   = "commander"
+	Admin       = "admin"
+)
+
+var Roles = []string{Citizen, Firefighter, Dispatcher, Commander, Admin}
+
+var rolePermissions = map[string][]Permission{
+	Citizen:     {FireReport, CommentCreate},
+	Firefighter: {FireReport, CommentCreate, FireStatusUpdate, FireModerate, ZoneManage},
+	Dispatcher:  {FireReport, CommentCreate, FireStatusUpdate, FireModerate, CommentDelete, FireMerge},
+	Commander:   {FireReport, CommentCreate, FireStatusUpdate, FireModerate, ZoneManage, CommentDelete, FireMerge, FireTransfer},
+	Admin: {
+		FireReport, CommentCreate, FireStatusUpdate, FireModerate, ZoneManage, CommentDelete, FireMerge, FireTransfer,
+		UserManage, OrganisationManage, WebhookManage, APIKeyManage, JobView,
+	},
+}
+
+// ValidRole reports whether role is one of Roles.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// PermissionsFor lists what role may do. Unknown roles may do nothing.
+func PermissionsFor(role string) []Permission {
+	return append([]Permission{}, rolePermissions[role]...)
+}
+
+// Can reports whether role grants permission.
+func Can(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+package config
+
+import "testing"
+
+func TestGetEnvPositiveInt(t *testing.T) {
+	for value, want := range map[string]int{"": 15, "30": 30, "0": 15, "-5": 15, "soon": 15} {
+		t.Setenv("JOB_LEADER_CHECK_SECONDS", value)
+		if got := getEnvPositiveInt("JOB_LEADER_CHECK_SECONDS", 15); got != want {
+			t.Errorf("%q: got %d, want %d", value, got, want)
+		}
+	}
+}
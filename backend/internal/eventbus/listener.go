@@ -0,0 +1,194 @@
+// Package eventbus receives change notifications published by any backend
+// instance through Postgres LISTEN/NOTIFY and re-dispatches them to the
+// local events broker.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/repository"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+
+	// A notification that is missing for longer than gapTimeout is treated
+	// as lost. Notifications from concurrent transactions can arrive out of
+	// order, so a jump in sequence numbers is not immediately a gap.
+	gapTimeout       = 5 * time.Second
+	gapCheckInterval = time.Second
+	maxTrackedGap    = 1000
+	loadTimeout      = 5 * time.Second
+)
+
+// Listener holds a dedicated connection (outside the pool) listening on
+// events.Channel.
+type Listener struct {
+	databaseURL  string
+	broker       *events.Broker
+	firesRepo    *repository.FiresRepository
+	commentsRepo *repository.CommentsRepository
+	logger       *zap.Logger
+
+	connected bool
+	lastSeq   int64
+	missing   map[int64]time.Time
+}
+
+func NewListener(databaseURL string, broker *events.Broker, firesRepo *repository.FiresRepository, commentsRepo *repository.CommentsRepository, logger *zap.Logger) *Listener {
+	return &Listener{
+		databaseURL:  databaseURL,
+		broker:       broker,
+		firesRepo:    firesRepo,
+		commentsRepo: commentsRepo,
+		logger:       logger,
+		missing:      make(map[int64]time.Time),
+	}
+}
+
+// Run listens until ctx is cancelled, reconnecting with exponential backoff.
+func (l *Listener) Run(ctx context.Context) {
+	delay := minReconnectDelay
+	for {
+		err := l.listen(ctx, func() { delay = minReconnectDelay })
+		if ctx.Err() != nil {
+			return
+		}
+
+		l.logger.Warn("event listener disconnected", zap.Error(err), zap.Duration("retry_in", delay))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, onConnected func()) error {
+	conn, err := pgx.Connect(ctx, l.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{events.Channel}.Sanitize()); err != nil {
+		return err
+	}
+	onConnected()
+
+	if l.connected {
+		// Anything published while we were disconnected is lost.
+		l.logger.Info("event listener reconnected, resetting subscribers")
+		l.resync()
+	}
+	l.connected = true
+
+	// Gaps are checked on schedule whether or not notifications keep
+	// arriving, so a steady stream cannot hide a lost one.
+	nextGapCheck := time.Now().Add(gapCheckInterval)
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, time.Until(nextGapCheck))
+		notification, err := conn.WaitForNotification(waitCtx)
+		cancel()
+
+		if err == nil {
+			l.handle(ctx, notification.Payload)
+		} else if ctx.Err() != nil || !pgconn.Timeout(err) {
+			return err
+		}
+
+		if !time.Now().Before(nextGapCheck) {
+			l.checkGaps()
+			nextGapCheck = time.Now().Add(gapCheckInterval)
+		}
+	}
+}
+
+func (l *Listener) handle(ctx context.Context, payload string) {
+	var n events.Notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		l.logger.Error("invalid event notification", zap.String("payload", payload), zap.Error(err))
+		return
+	}
+
+	l.track(n.Seq)
+
+	data, err := l.load(ctx, &n)
+	if err != nil {
+		l.logger.Warn("failed to load event entity",
+			zap.String("type", n.Type), zap.Int64("seq", n.Seq), zap.Error(err))
+		return
+	}
+
+	l.broker.Publish(events.Event{
+		ID:   n.Seq,
+		Type: n.Type,
+		Data: data,
+		Time: time.Now().UTC(),
+	})
+}
+
+func (l *Listener) load(ctx context.Context, n *events.Notification) (interface{}, error) {
+	loadCtx, cancel := context.WithTimeout(ctx, loadTimeout)
+	defer cancel()
+
+	if n.Type == events.CommentDeleted {
+		return &events.DeletedComment{ID: n.CommentID, FireID: n.FireID}, nil
+	}
+	if n.CommentID != 0 {
+		return l.commentsRepo.GetByID(loadCtx, n.CommentID)
+	}
+	return l.firesRepo.GetByID(loadCtx, n.FireID)
+}
+
+// track records seq and any sequence numbers skipped before it.
+func (l *Listener) track(seq int64) {
+	if _, ok := l.missing[seq]; ok {
+		delete(l.missing, seq)
+		return
+	}
+
+	if l.lastSeq != 0 && seq-l.lastSeq > maxTrackedGap {
+		l.logger.Warn("event sequence jumped, resetting subscribers",
+			zap.Int64("last_seq", l.lastSeq), zap.Int64("seq", seq))
+		l.resync()
+	}
+
+	if l.lastSeq != 0 {
+		now := time.Now()
+		for missing := l.lastSeq + 1; missing < seq; missing++ {
+			l.missing[missing] = now
+		}
+	}
+	if seq > l.lastSeq {
+		l.lastSeq = seq
+	}
+}
+
+func (l *Listener) checkGaps() {
+	for seq, since := range l.missing {
+		if time.Since(since) > gapTimeout {
+			l.logger.Warn("event notifications lost, resetting subscribers", zap.Int64("seq", seq))
+			l.resync()
+			return
+		}
+	}
+}
+
+func (l *Listener) resync() {
+	l.missing = make(map[int64]time.Time)
+	l.lastSeq = 0
+	l.broker.Reset()
+}
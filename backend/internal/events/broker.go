@@ -0,0 +1,115 @@
+package events
+
+import (
+	"sync"
+)
+
+// Broker fans events out to in-process subscribers and keeps a bounded
+// buffer of recent events so reconnecting clients can resume.
+type Broker struct {
+	mu          sync.Mutex
+	lastID      int64
+	buffer      []Event
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives events on C. C is closed when the subscriber falls
+// too far behind or unsubscribes; clients are expected to resume from the
+// last event they saw.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	broker *Broker
+}
+
+func NewBroker(bufferSize int) *Broker {
+	return &Broker{
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish delivers the event to every subscriber and buffers it for replay.
+func (b *Broker) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.ID > b.lastID {
+		b.lastID = event.ID
+	}
+
+	b.buffer = append(b.buffer, event)
+	if len(b.buffer) > b.bufferSize {
+		b.buffer = b.buffer[len(b.buffer)-b.bufferSize:]
+	}
+
+	b.fanOut(event)
+}
+
+// Reset discards the replay buffer and sends a Reset event to every
+// subscriber. Used when the event source may have lost events.
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buffer = nil
+	b.fanOut(Event{ID: b.lastID, Type: Reset})
+}
+
+// Subscribe registers a subscriber and returns the buffered events after
+// lastID. complete is false when events after lastID are no longer buffered
+// and the client must reload its state.
+func (b *Broker) Subscribe(lastID int64, queueSize int) (sub *Subscription, replay []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, queueSize)
+	sub = &Subscription{C: ch, ch: ch, broker: b}
+	b.subscribers[sub] = struct{}{}
+
+	complete = true
+	if lastID > 0 {
+		switch {
+		case lastID > b.lastID:
+			// The ID comes from before a restart or from a replica ahead of us.
+			complete = false
+		case len(b.buffer) == 0:
+			complete = lastID == b.lastID
+		case b.buffer[0].ID > lastID+1:
+			complete = false
+		}
+
+		for _, event := range b.buffer {
+			if event.ID > lastID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	return sub, replay, complete
+}
+
+func (s *Subscription) Unsubscribe() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+func (b *Broker) fanOut(event Event) {
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			// Slow subscriber: drop it rather than block publishers.
+			b.remove(sub)
+		}
+	}
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,46 @@
+// Package events distributes domain change notifications to in-process
+// subscribers such as streaming API clients.
+package events
+
+import "time"
+
+const (
+	FireCreated       = "fire.created"
+	FireStatusChanged = "fire.status_changed"
+	FireMerged        = "fire.merged"
+	FireTransferred   = "fire.transferred"
+	CommentAdded      = "comment.added"
+	CommentDeleted    = "comment.deleted"
+
+	// Reset tells subscribers that events may have been missed and they
+	// should reload their state.
+	Reset = "reset"
+)
+
+// DeletedComment is the data of CommentDeleted events, since the comment
+// itself is gone.
+type DeletedComment struct {
+	ID     int `json:"id"`
+	FireID int `json:"fire_id"`
+}
+
+// Channel is the Postgres NOTIFY channel carrying Notification payloads.
+const Channel = "fire_events"
+
+// Event IDs are the global sequence numbers assigned in Postgres, so they
+// are comparable across backend instances.
+type Event struct {
+	ID   int64       `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	Time time.Time   `json:"time"`
+}
+
+// Notification is the NOTIFY payload. It only carries identifiers because
+// payloads are limited to 8000 bytes; listeners load the entity themselves.
+type Notification struct {
+	Seq       int64  `json:"seq"`
+	Type      string `json:"type"`
+	FireID    int    `json:"fire_id"`
+	CommentID int    `json:"comment_id,omitempty"`
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"fire-tracker/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NewOutboxCleanup returns a job that deletes outbox entries every consumer
+// is done with once they are older than retention, so the outbox does not
+// grow with every write.
+func NewOutboxCleanup(outboxRepo *repository.OutboxRepository, retention time.Duration, logger *zap.Logger) Job {
+	return Job{
+		Name:     "outbox_cleanup",
+		Schedule: Every(time.Hour),
+		Jitter:   time.Minute,
+		Timeout:  5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			deleted, err := outboxRepo.DeleteProcessed(ctx, retention)
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				logger.Info("deleted processed outbox entries", zap.Int64("count", deleted))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/testdb"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestOutboxCleanupKeepsUnfinishedEntries(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	outboxRepo := repository.NewOutboxRepository(db)
+	firesRepo := repository.NewFiresRepository(db)
+
+	// Each report records one outbox entry
+	for i := 0; i < 3; i++ {
+		if _, err := firesRepo.Create(ctx, nil, 38.0, 23.7, "Smoke", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := outboxRepo.Dispatch(ctx, []string{"a", "b"}, 10); err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err := outboxRepo.ClaimDue(ctx, []string{"a", "b"}, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byEntry := map[int64][]int64{}
+	var entries []int64
+	for _, d := range deliveries {
+		if byEntry[d.Entry.ID] == nil {
+			entries = append(entries, d.Entry.ID)
+		}
+		byEntry[d.Entry.ID] = append(byEntry[d.Entry.ID], d.ID)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("claimed deliveries for %d entries, want 3", len(entries))
+	}
+	// The first entry is done for both consumers, the second only for one
+	// and the third for neither
+	for _, id := range append(byEntry[entries[0]], byEntry[entries[1]][0]) {
+		if err := outboxRepo.MarkDelivered(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(ctx, `UPDATE outbox SET dispatched_at = NOW() - INTERVAL '8 days'`); err != nil {
+		t.Fatal(err)
+	}
+
+	job := NewOutboxCleanup(outboxRepo, 7*24*time.Hour, zap.NewNop())
+	if err := job.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var remaining int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM outbox`).Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 2 {
+		t.Errorf("%d entries left, want the 2 with deliveries outstanding", remaining)
+	}
+}
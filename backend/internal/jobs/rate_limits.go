@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"fire-tracker/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NewRateLimitCleanup returns a job that deletes rate limit buckets idle
+// for longer than idle, which must be at least the slowest limit's refill
+// period.
+func NewRateLimitCleanup(rateLimitsRepo *repository.RateLimitsRepository, idle time.Duration, logger *zap.Logger) Job {
+	return Job{
+		Name:     "rate_limit_cleanup",
+		Schedule: Every(time.Hour),
+		Jitter:   time.Minute,
+		Timeout:  5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			deleted, err := rateLimitsRepo.DeleteIdle(ctx, idle)
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				logger.Info("deleted idle rate limit buckets", zap.Int64("count", deleted))
+			}
+			return nil
+		},
+	}
+}
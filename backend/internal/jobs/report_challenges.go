@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"fire-tracker/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NewReportChallengeCleanup returns a job that deletes proof-of-work
+// challenges that expired unsolved.
+func NewReportChallengeCleanup(challengesRepo *repository.ReportChallengesRepository, logger *zap.Logger) Job {
+	return Job{
+		Name:     "report_challenge_cleanup",
+		Schedule: Every(15 * time.Minute),
+		Jitter:   time.Minute,
+		Timeout:  5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			deleted, err := challengesRepo.DeleteExpired(ctx)
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				logger.Info("deleted expired report challenges", zap.Int64("count", deleted))
+			}
+			return nil
+		},
+	}
+}
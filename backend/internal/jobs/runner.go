@@ -0,0 +1,256 @@
+// Package jobs runs periodic housekeeping tasks. Only one backend instance
+// runs jobs at a time: the runner that holds a Postgres advisory lock is the
+// leader, and the others wait to take over if it goes away.
+package jobs
+
+import (
+	"context"
+	"fire-tracker/internal/repository"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job is a named task run on a schedule.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Jitter delays each run by a random amount up to this long, so jobs
+	// with the same schedule do not all hit the database at once.
+	Jitter  time.Duration
+	Timeout time.Duration // Zero means no timeout
+	Run     func(ctx context.Context) error
+}
+
+// JobStats describes a job's runs on this instance. Followers keep the
+// stats from their last term as leader.
+type JobStats struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Running        bool       `json:"running"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastSuccessAt  *time.Time `json:"last_success_at,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+}
+
+// Status is a snapshot of the runner.
+type Status struct {
+	Leader bool       `json:"leader"`
+	Jobs   []JobStats `json:"jobs"`
+}
+
+// DefaultLeaderCheckInterval is used when Config.LeaderCheckInterval is not
+// positive.
+const DefaultLeaderCheckInterval = 15 * time.Second
+
+type Config struct {
+	LeaderCheckInterval time.Duration // How often the leader checks its lock and followers try to take it
+}
+
+type Runner struct {
+	lock   *repository.LeaderLock
+	config Config
+	logger *zap.Logger
+	jobs   []Job
+
+	mu     sync.Mutex
+	leader bool
+	stats  map[string]*JobStats
+}
+
+func NewRunner(lock *repository.LeaderLock, config Config, logger *zap.Logger) *Runner {
+	if config.LeaderCheckInterval <= 0 {
+		config.LeaderCheckInterval = DefaultLeaderCheckInterval
+	}
+	return &Runner{
+		lock:   lock,
+		config: config,
+		logger: logger,
+		stats:  make(map[string]*JobStats),
+	}
+}
+
+// Register adds a job. Jobs with a name already registered are ignored.
+// Must be called before Run.
+func (r *Runner) Register(job Job) {
+	if _, ok := r.stats[job.Name]; ok {
+		return
+	}
+	r.jobs = append(r.jobs, job)
+	r.stats[job.Name] = &JobStats{Name: job.Name, Schedule: job.Schedule.String()}
+}
+
+// Run competes for leadership and, while leader, runs jobs on their
+// schedules until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) {
+	if len(r.jobs) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.config.LeaderCheckInterval)
+	defer ticker.Stop()
+
+	var (
+		lead context.CancelFunc
+		wg   sync.WaitGroup
+	)
+	stepDown := func() {
+		lead()
+		wg.Wait()
+		lead = nil
+		r.setLeader(false)
+	}
+
+	for {
+		if lead == nil {
+			acquired, err := r.lock.TryAcquire(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.logger.Error("failed to acquire job leader lock", zap.Error(err))
+			}
+			if acquired {
+				r.logger.Info("became job leader", zap.Int("jobs", len(r.jobs)))
+				r.setLeader(true)
+
+				var leadCtx context.Context
+				leadCtx, lead = context.WithCancel(ctx)
+				for _, job := range r.jobs {
+					wg.Add(1)
+					go func(job Job) {
+						defer wg.Done()
+						r.schedule(leadCtx, job)
+					}(job)
+				}
+			}
+		} else if err := r.lock.Check(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("lost job leader lock, stopping jobs", zap.Error(err))
+			stepDown()
+		}
+
+		select {
+		case <-ctx.Done():
+			if lead != nil {
+				stepDown()
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := r.lock.Release(releaseCtx); err != nil {
+					r.logger.Warn("failed to release job leader lock", zap.Error(err))
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the current leadership and per-job stats.
+func (r *Runner) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := Status{Leader: r.leader, Jobs: make([]JobStats, 0, len(r.jobs))}
+	for _, job := range r.jobs {
+		status.Jobs = append(status.Jobs, *r.stats[job.Name])
+	}
+	return status
+}
+
+func (r *Runner) setLeader(leader bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.leader = leader
+	if !leader {
+		for _, stats := range r.stats {
+			stats.NextRunAt = nil
+		}
+	}
+}
+
+func (r *Runner) schedule(ctx context.Context, job Job) {
+	for {
+		next := job.Schedule.Next(time.Now())
+		if next.IsZero() {
+			r.logger.Error("job schedule never fires", zap.String("job", job.Name), zap.String("schedule", job.Schedule.String()))
+			return
+		}
+		if job.Jitter > 0 {
+			next = next.Add(rand.N(job.Jitter))
+		}
+		r.update(job.Name, func(stats *JobStats) {
+			stats.NextRunAt = &next
+		})
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		r.execute(ctx, job)
+	}
+}
+
+func (r *Runner) execute(ctx context.Context, job Job) {
+	logger := r.logger.With(zap.String("job", job.Name))
+	started := time.Now()
+	r.update(job.Name, func(stats *JobStats) {
+		stats.Running = true
+		stats.LastStartedAt = &started
+		stats.NextRunAt = nil
+	})
+
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	if job.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, job.Timeout)
+	}
+	err := runJob(runCtx, job)
+	cancel()
+
+	duration := time.Since(started)
+	r.update(job.Name, func(stats *JobStats) {
+		stats.Running = false
+		stats.Runs++
+		stats.LastDurationMs = duration.Milliseconds()
+		if err != nil {
+			stats.Failures++
+			message := err.Error()
+			stats.LastError = &message
+			return
+		}
+		finished := started.Add(duration)
+		stats.LastSuccessAt = &finished
+		stats.LastError = nil
+	})
+
+	if err != nil {
+		logger.Error("job failed", zap.Duration("duration", duration), zap.Error(err))
+		return
+	}
+	logger.Info("job finished", zap.Duration("duration", duration))
+}
+
+// runJob turns a panicking job into a failed run rather than taking the
+// runner down with it.
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (r *Runner) update(name string, fn func(stats *JobStats)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r.stats[name])
+}
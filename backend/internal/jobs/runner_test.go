@@ -0,0 +1,14 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRunnerDefaultsLeaderCheckInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if r := NewRunner(nil, Config{LeaderCheckInterval: interval}, nil); r.config.LeaderCheckInterval != DefaultLeaderCheckInterval {
+			t.Errorf("interval %v became %v, want the default", interval, r.config.LeaderCheckInterval)
+		}
+	}
+}
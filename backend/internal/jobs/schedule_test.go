@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleRejects(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"@every",
+		"@every 0s",
+		"@every -1m",
+		"@every soon",
+		"@monthly",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2024, time.January, 10, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 90s", from.Add(90 * time.Second)},
+		{"@hourly", time.Date(2024, time.January, 10, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{"* * * * *", time.Date(2024, time.January, 10, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.January, 10, 10, 15, 0, 0, time.UTC)},
+		{"5,50 * * * *", time.Date(2024, time.January, 10, 10, 50, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2024, time.January, 10, 11, 0, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, time.January, 11, 3, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matching is enough
+		{"0 0 13 * 5", time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextIsStrictlyAfter(t *testing.T) {
+	schedule, err := ParseSchedule("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	if got, want := schedule.Next(on), on.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Next on a matching minute = %v, want %v", got, want)
+	}
+	// Other zones are converted to UTC
+	athens := time.FixedZone("EET", 2*60*60)
+	if got, want := schedule.Next(on.In(athens)), on.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Next from EET = %v, want %v", got, want)
+	}
+}
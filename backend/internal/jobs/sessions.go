@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"fire-tracker/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NewSessionCleanup returns a job that deletes sessions past their absolute
+// expiry, which nothing else removes.
+func NewSessionCleanup(sessionsRepo *repository.SessionsRepository, schedule Schedule, logger *zap.Logger) Job {
+	return Job{
+		Name:     "session_cleanup",
+		Schedule: schedule,
+		Jitter:   time.Minute,
+		Timeout:  5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			deleted, err := sessionsRepo.DeleteExpired(ctx)
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				logger.Info("deleted expired sessions", zap.Int64("count", deleted))
+			}
+			return nil
+		},
+	}
+}
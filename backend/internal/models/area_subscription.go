@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type AreaSubscription struct {
+	ID        int                   `json:"id"`
+	UserID    int                   `json:"user_id"`
+	Name      string                `json:"name"`
+	Center    *Point                `json:"center,omitempty"`
+	Radius    *float64              `json:"radius,omitempty"` // Meters around Center
+	Area      *Polygon              `json:"area,omitempty"`
+	Channels  []NotificationChannel `json:"channels"`
+	Language  string                `json:"language"` // Language of alert messages, e.g. "en", "el", "tr"
+	Active    bool                  `json:"active"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+type NotificationChannel struct {
+	Type    string `json:"type"`    // "email", "sms", "telegram" or "push"
+	Address string `json:"address"` // Email address, phone number or chat ID; empty for push
+}
+
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type EvacuationZone struct {
+	ID        int        `json:"id"`
+	FireID    *int       `json:"fire_id"`
+	CreatedBy int        `json:"created_by"`
+	Area      *Polygon   `json:"area"`
+	Level     string     `json:"level"` // "prepare" or "evacuate"
+	Message   string     `json:"message"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Polygon is a GeoJSON polygon geometry. Coordinates are [longitude, latitude].
+type Polygon struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
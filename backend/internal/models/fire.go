@@ -3,15 +3,26 @@ package models
 import "time"
 
 type Fire struct {
-	ID          int       `json:"id"`
-	ReporterID  int       `json:"reporter_id"`
-	Reporter    *User     `json:"reporter,omitempty"`
-	Latitude    float64   `json:"latitude"`
-	Longitude   float64   `json:"longitude"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"` // "reported", "seen", "closed"
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int     `json:"id"`
+	ReporterID  *int    `json:"reporter_id"` // Nil for anonymous reports
+	Reporter    *User   `json:"reporter,omitempty"`
+	Anonymous   bool    `json:"anonymous"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Description string  `json:"description"`
+	Status      string  `json:"status"` // "reported", "seen", "closed"
+
+	OrganisationID *int `json:"organisation_id"`          // Responsible organisation; nil if outside every jurisdiction
+	MergedIntoID   *int `json:"merged_into_id,omitempty"` // Fire this duplicate report was folded into
+
+	ModerationStatus string     `json:"moderation_status"` // "pending", "approved", "rejected"
+	Verdict          *string    `json:"verdict,omitempty"` // "confirmed", "false", "prank"
+	VerdictAt        *time.Time `json:"verdict_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Weather *WeatherSnapshot `json:"weather,omitempty"` // Latest conditions, only set on single-fire reads
 }
 
 type FireWithDistance struct {
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Notification struct {
+	ID             int64      `json:"id"`
+	UserID         int        `json:"user_id"`
+	SubscriptionID *int       `json:"subscription_id"`
+	FireID         int        `json:"fire_id"`
+	EventType      string     `json:"event_type"`
+	FireStatus     string     `json:"fire_status"`
+	Channel        string     `json:"channel"`
+	Address        string     `json:"address"`
+	Language       string     `json:"language"`
+	Status         string     `json:"status"` // "pending", "sent", "failed"
+	Attempts       int        `json:"attempts"`
+	LastError      *string    `json:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	SentAt         *time.Time `json:"sent_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
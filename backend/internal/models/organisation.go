@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Organisation is an agency sharing the deployment. Its staff manage the
+// fires it is responsible for.
+type Organisation struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Jurisdiction *Polygon  `json:"jurisdiction"` // New fires inside it are assigned to the organisation; nil means none
+	IsDefault    bool      `json:"default"`      // Looks after fires outside every jurisdiction and staff without an organisation
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type OutboxEntry struct {
+	ID        int64           `json:"id"`
+	EventType string          `json:"event_type"`
+	FireID    int             `json:"fire_id"`
+	CommentID int             `json:"comment_id,omitempty"`
+	Payload   json.RawMessage `json:"payload"` // Entity as it was when the event was recorded
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type OutboxDelivery struct {
+	ID       int64        `json:"id"`
+	Consumer string       `json:"consumer"`
+	Attempts int          `json:"attempts"`
+	Entry    *OutboxEntry `json:"entry"`
+}
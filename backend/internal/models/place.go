@@ -0,0 +1,10 @@
+package models
+
+// Place is a named location from the gazetteer used to resolve village
+// names in text reports.
+type Place struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
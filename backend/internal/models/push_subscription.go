@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PushSubscription is a browser Web Push endpoint with the keys used to
+// encrypt messages for it.
+type PushSubscription struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Endpoint  string    `json:"endpoint"`
+	P256dh    string    `json:"p256dh"` // Base64url browser public key
+	Auth      string    `json:"auth"`   // Base64url authentication secret
+	UserAgent *string   `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
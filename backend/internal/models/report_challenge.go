@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ReportChallenge is a proof-of-work puzzle an anonymous reporter must
+// solve before submitting a fire.
+type ReportChallenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type RoleRequest struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	User       *User      `json:"user,omitempty"` // Set in review listings
+	Role       string     `json:"role"`
+	Status     string     `json:"status"` // "pending", "approved", "denied"
+	Message    *string    `json:"message"`
+	InviteID   *int       `json:"invite_id,omitempty"` // Set when approved by an invite code
+	ReviewedBy *int       `json:"reviewed_by"`
+	ReviewNote *string    `json:"review_note"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type RoleInvite struct {
+	ID        int        `json:"id"`
+	Code      string     `json:"code,omitempty"` // Only returned when the invite is created
+	Role      string     `json:"role"`
+	Note      *string    `json:"note"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedBy *int       `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -4,14 +4,48 @@ import "time"
 
 type User struct {
 	ID        int       `json:"id"`
+	Username  string    `json:"username,omitempty"`
 	Name      string    `json:"name"`
-	Role      string    `json:"role"` // "user" or "firefighter"
+	Email     *string   `json:"email,omitempty"` // Only loaded for the account owner
+	Role      string    `json:"role"`            // One of authz.Roles
 	CreatedAt time.Time `json:"created_at"`
+
+	OrganisationID *int `json:"organisation_id,omitempty"` // Staff of an organisation only manage its fires
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // Nil until the owner is known to read the address
+	Phone           *string    `json:"phone,omitempty"`             // Number the account texted in from, so known to be the owner's
+
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	SuspendedReason *string    `json:"suspended_reason,omitempty"`
+
+	Trust *TrustScore `json:"trust,omitempty"` // Only set in firefighter views
+
+	PasswordHash *string `json:"-"` // Nil for accounts created before passwords
+}
+
+// TrustScore summarises how a user's reports turned out. Score runs from
+// 0 to 1 and starts at 0.5 for users with no judged reports.
+type TrustScore struct {
+	Score     float64 `json:"score"`
+	Reports   int     `json:"reports"`
+	Confirmed int     `json:"confirmed"`
+	False     int     `json:"false"`
+	Pranks    int     `json:"pranks"`
 }
 
 type Session struct {
-	ID        string    `json:"id"`
-	UserID    int       `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ID                string    `json:"id"`
+	UserID            int       `json:"user_id"`
+	CreatedAt         time.Time `json:"created_at"`
+	ExpiresAt         time.Time `json:"expires_at"`          // Moves forward while the session is used
+	AbsoluteExpiresAt time.Time `json:"absolute_expires_at"` // Hard limit, refresh tokens stop working here
+	LastSeenAt        time.Time `json:"last_seen_at"`
+	IP                *string   `json:"ip"`
+	UserAgent         *string   `json:"user_agent"`
+	Current           bool      `json:"current"` // Set in listings for the caller's own session
+
+	// Only set when the session is created or refreshed; the database keeps
+	// hashes.
+	Token        string `json:"-"`
+	RefreshToken string `json:"-"`
 }
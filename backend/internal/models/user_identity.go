@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type UserIdentity struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	Issuer      string    `json:"issuer"`
+	Subject     string    `json:"subject"`
+	Email       *string   `json:"email"`
+	Groups      []string  `json:"groups"`
+	GrantedRole bool      `json:"granted_role"` // The user's role came from provider groups
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// OIDCLoginState is what the callback needs to finish a login started by
+// this server.
+type OIDCLoginState struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	ReturnTo     string
+	LinkUserID   *int // Set when a signed-in user is linking a provider account
+}
+
+// OIDCPendingLink is a provider account waiting for the user who started
+// linking it to confirm.
+type OIDCPendingLink struct {
+	UserID  int
+	Issuer  string
+	Subject string
+	Email   *string
+	Groups  []string
+}
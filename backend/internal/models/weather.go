@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type WeatherSnapshot struct {
+	ID            int       `json:"id"`
+	FireID        int       `json:"fire_id"`
+	WindSpeed     float64   `json:"wind_speed"`     // m/s
+	WindDirection float64   `json:"wind_direction"` // Degrees the wind is blowing from
+	Temperature   float64   `json:"temperature"`    // Celsius
+	Humidity      float64   `json:"humidity"`       // Relative humidity, percent
+	Source        string    `json:"source"`
+	ObservedAt    time.Time `json:"observed_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,50 @@
+// Package notify delivers queued notifications over outbound channels such
+// as email, SMS and Telegram.
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is a rendered notification ready to send. Subject and URL are
+// only used by channels that support them.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	URL     string // Page the message refers to
+}
+
+// Notifier sends messages over a single channel type.
+type Notifier interface {
+	// Channel is the notification channel type this notifier handles, e.g.
+	// "email".
+	Channel() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// PermanentError marks a send failure that retrying cannot fix, such as an
+// unsubscribed recipient.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so the worker fails the notification without
+// retrying it.
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
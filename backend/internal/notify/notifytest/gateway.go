@@ -0,0 +1,80 @@
+package notifytest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Request is a request received by Gateway.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Gateway is a stub HTTP endpoint standing in for an SMS gateway or bot
+// API. It records every request and answers with StatusCode, 200 by
+// default.
+type Gateway struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	requests   []Request
+	statusCode int
+}
+
+// NewGateway starts a stub gateway on a random local port.
+func NewGateway() *Gateway {
+	g := &Gateway{statusCode: http.StatusOK}
+	g.server = httptest.NewServer(http.HandlerFunc(g.serveHTTP))
+	return g
+}
+
+// URL returns the base URL of the gateway.
+func (g *Gateway) URL() string {
+	return g.server.URL
+}
+
+// SetStatusCode changes the status returned for subsequent requests, e.g.
+// to exercise retries.
+func (g *Gateway) SetStatusCode(code int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.statusCode = code
+}
+
+// Requests returns the requests received so far.
+func (g *Gateway) Requests() []Request {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Request(nil), g.requests...)
+}
+
+func (g *Gateway) Close() {
+	g.server.Close()
+}
+
+func (g *Gateway) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	g.mu.Lock()
+	g.requests = append(g.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	code := g.statusCode
+	g.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if code >= 200 && code < 300 {
+		w.Write([]byte(`{"ok":true}`))
+	} else {
+		w.Write([]byte(`{"ok":false}`))
+	}
+}
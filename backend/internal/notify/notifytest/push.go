@@ -0,0 +1,133 @@
+package notifytest
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/webpush"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// PushMessage is a message received and decrypted by PushService.
+type PushMessage struct {
+	Endpoint      string
+	Authorization string // VAPID header as sent
+	Payload       []byte
+}
+
+type pushEndpoint struct {
+	key        *ecdh.PrivateKey
+	authSecret []byte
+}
+
+// PushService is a stub Web Push service. It issues subscriptions with
+// their own browser keys, so messages sent to them can be decrypted and
+// inspected. Unknown or removed endpoints answer 410 Gone.
+type PushService struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	endpoints map[string]*pushEndpoint
+	messages  []PushMessage
+	next      int
+}
+
+// NewPushService starts a stub push service on a random local port.
+func NewPushService() *PushService {
+	p := &PushService{endpoints: make(map[string]*pushEndpoint)}
+	p.server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	return p
+}
+
+// NewSubscription creates an endpoint as a browser would and returns the
+// subscription to store for it.
+func (p *PushService) NewSubscription(userID int) (*models.PushSubscription, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	authSecret := make([]byte, 16)
+	if _, err := rand.Read(authSecret); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.next++
+	endpoint := fmt.Sprintf("%s/push/%d", p.server.URL, p.next)
+	p.endpoints[endpoint] = &pushEndpoint{key: key, authSecret: authSecret}
+	p.mu.Unlock()
+
+	return &models.PushSubscription{
+		UserID:   userID,
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(authSecret),
+	}, nil
+}
+
+// Unsubscribe removes an endpoint, as when a user revokes permission.
+func (p *PushService) Unsubscribe(endpoint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.endpoints, endpoint)
+}
+
+// Messages returns the decrypted messages received so far.
+func (p *PushService) Messages() []PushMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PushMessage(nil), p.messages...)
+}
+
+func (p *PushService) Close() {
+	p.server.Close()
+}
+
+func (p *PushService) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := p.server.URL + r.URL.Path
+
+	p.mu.Lock()
+	sub, ok := p.endpoints[endpoint]
+	p.mu.Unlock()
+	if !ok {
+		http.Error(w, "Subscription not found", http.StatusGone)
+		return
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "vapid t=") {
+		http.Error(w, "Missing VAPID authorization", http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("Content-Encoding") != "aes128gcm" {
+		http.Error(w, "Unsupported content encoding", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	payload, err := webpush.Decrypt(body, sub.key, sub.authSecret)
+	if err != nil {
+		http.Error(w, "Failed to decrypt payload", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	p.messages = append(p.messages, PushMessage{
+		Endpoint:      endpoint,
+		Authorization: authorization,
+		Payload:       payload,
+	})
+	p.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+}
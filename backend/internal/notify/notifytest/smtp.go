@@ -0,0 +1,146 @@
+// Package notifytest provides local stand-ins for the services notifiers
+// talk to, for development and tests.
+package notifytest
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Email is a message accepted by SMTPServer.
+type Email struct {
+	From string
+	To   []string
+	Data string // Raw message including headers
+}
+
+// SMTPServer is a minimal in-memory SMTP server. It accepts any sender,
+// recipient and credentials and keeps every message it receives.
+type SMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Email
+	wg       sync.WaitGroup
+}
+
+// NewSMTPServer starts a server on a random local port.
+func NewSMTPServer() (*SMTPServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &SMTPServer{listener: listener}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on.
+func (s *SMTPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Messages returns the messages received so far.
+func (s *SMTPServer) Messages() []Email {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Email(nil), s.messages...)
+}
+
+func (s *SMTPServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *SMTPServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *SMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(line string) {
+		w.WriteString(line + "\r\n")
+		w.Flush()
+	}
+
+	reply("220 localhost notifytest SMTP")
+	var current Email
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "HELO":
+			reply("250 localhost")
+		case "AUTH":
+			reply("235 Authentication successful")
+		case "MAIL":
+			current = Email{From: extractAddress(line)}
+			reply("250 OK")
+		case "RCPT":
+			current.To = append(current.To, extractAddress(line))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" || dataLine == ".\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			current.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			current = Email{}
+			reply("250 OK")
+		case "RSET":
+			current = Email{}
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func extractAddress(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.LastIndex(line, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/webpush"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// pushPayload is the JSON the service worker receives in the push event.
+type pushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+}
+
+// errPushPayloadTooLarge is returned when a message does not fit even with
+// its body left out.
+var errPushPayloadTooLarge = errors.New("push payload too large")
+
+// PushNotifier sends Web Push messages. The recipient address is the push
+// subscription endpoint; keys are looked up when sending, and subscriptions
+// the push service reports as gone are deleted.
+type PushNotifier struct {
+	pushRepo *repository.PushSubscriptionsRepository
+	sender   *webpush.Sender
+}
+
+func NewPushNotifier(pushRepo *repository.PushSubscriptionsRepository, sender *webpush.Sender) *PushNotifier {
+	return &PushNotifier{pushRepo: pushRepo, sender: sender}
+}
+
+func (n *PushNotifier) Channel() string {
+	return "push"
+}
+
+func (n *PushNotifier) Send(ctx context.Context, msg Message) error {
+	sub, err := n.pushRepo.GetByEndpoint(ctx, msg.To)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Permanent(webpush.ErrSubscriptionGone)
+	} else if err != nil {
+		return err
+	}
+
+	payload, err := encodePushPayload(msg)
+	if err != nil {
+		return err
+	}
+
+	err = n.sender.Send(ctx, sub, payload)
+	if errors.Is(err, webpush.ErrSubscriptionGone) {
+		if err := n.pushRepo.DeleteByEndpoint(ctx, sub.Endpoint); err != nil {
+			return err
+		}
+		return Permanent(err)
+	}
+	return err
+}
+
+// encodePushPayload marshals msg, shortening the body with an ellipsis
+// until it fits the single record webpush.Encrypt produces.
+func encodePushPayload(msg Message) ([]byte, error) {
+	body := []rune(msg.Body)
+	truncated := false
+	for {
+		text := string(body)
+		if truncated {
+			text += "…"
+		}
+		payload, err := json.Marshal(pushPayload{Title: msg.Subject, Body: text, URL: msg.URL})
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) <= webpush.MaxPayloadSize {
+			return payload, nil
+		}
+		if len(body) == 0 {
+			return nil, Permanent(errPushPayloadTooLarge)
+		}
+
+		// Every rune takes at least a byte, so dropping one per excess byte
+		// always makes progress
+		drop := len(payload) - webpush.MaxPayloadSize
+		if !truncated {
+			drop += len("…")
+		}
+		body = body[:max(len(body)-drop, 0)]
+		truncated = true
+	}
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fire-tracker/internal/webpush"
+	"strings"
+	"testing"
+)
+
+func TestEncodePushPayloadFitsOneRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"short", "Fire reported near Kakopetria"},
+		{"long", strings.Repeat("Smoke rising. ", 1000)},
+		// Escaped in JSON, so the bytes outgrow the runes
+		{"escaped", strings.Repeat("<&>", 2000)},
+		{"multibyte", strings.Repeat("Πυρκαγιά ", 1000)},
+	}
+	for _, tt := range tests {
+		msg := Message{Subject: "Fire alert", Body: tt.body, URL: "https://fires.example/fires/1"}
+		payload, err := encodePushPayload(msg)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(payload) > webpush.MaxPayloadSize {
+			t.Errorf("%s: payload is %d bytes, want at most %d", tt.name, len(payload), webpush.MaxPayloadSize)
+		}
+
+		var decoded pushPayload
+		if err := json.Unmarshal(payload, &decoded); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if truncated := decoded.Body != tt.body; truncated && !strings.HasSuffix(decoded.Body, "…") {
+			t.Errorf("%s: truncated body does not end with an ellipsis", tt.name)
+		}
+	}
+
+	_, err := encodePushPayload(Message{Subject: strings.Repeat("x", webpush.MaxPayloadSize), Body: "Smoke"})
+	if !errors.Is(err, errPushPayloadTooLarge) || !isPermanent(err) {
+		t.Errorf("oversize subject: got %v, want a permanent errPushPayloadTooLarge", err)
+	}
+}
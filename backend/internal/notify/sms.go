@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const maxErrorBodyBytes = 512
+
+// SMSNotifier posts messages to a generic HTTP SMS gateway as
+// {"to", "from", "text"} JSON, authenticated with a bearer API key.
+type SMSNotifier struct {
+	url    string
+	apiKey string
+	sender string
+	client *http.Client
+}
+
+func NewSMSNotifier(url, apiKey, sender string, timeout time.Duration) *SMSNotifier {
+	return &SMSNotifier{
+		url:    url,
+		apiKey: apiKey,
+		sender: sender,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (n *SMSNotifier) Channel() string {
+	return "sms"
+}
+
+func (n *SMSNotifier) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]string{
+		"to":   msg.To,
+		"from": n.sender,
+		"text": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+n.apiKey)
+	}
+
+	return doRequest(n.client, req)
+}
+
+// doRequest sends req and returns an error unless the response status is
+// 2xx.
+func doRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return fmt.Errorf("gateway returned status %d: %s", resp.StatusCode, snippet)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
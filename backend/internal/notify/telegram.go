@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TelegramNotifier sends messages through a Telegram-style bot API. The
+// recipient address is the chat ID.
+type TelegramNotifier struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+func NewTelegramNotifier(apiURL, token string, timeout time.Duration) *TelegramNotifier {
+	return &TelegramNotifier{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (n *TelegramNotifier) Channel() string {
+	return "telegram"
+}
+
+func (n *TelegramNotifier) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  msg.To,
+		"text":                     msg.Body,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.apiURL+"/bot"+n.token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doRequest(n.client, req)
+}
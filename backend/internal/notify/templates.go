@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// DefaultLanguage is used when a notification's language has no templates.
+const DefaultLanguage = "en"
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+type templateData struct {
+	Fire   *models.Fire
+	Status string // Localised fire status
+	Link   string
+}
+
+var templateSources = map[string]map[string][2]string{
+	"en": {
+		events.FireCreated: {
+			"Fire reported near your watch area",
+			"A fire was reported near {{.Fire.Latitude | coord}}, {{.Fire.Longitude | coord}}.\n\n{{.Fire.Description}}\n\nDetails: {{.Link}}",
+		},
+		events.FireStatusChanged: {
+			"Fire #{{.Fire.ID}} is now {{.Status}}",
+			"The fire near {{.Fire.Latitude | coord}}, {{.Fire.Longitude | coord}} is now {{.Status}}.\n\nDetails: {{.Link}}",
+		},
+	},
+	"el": {
+		events.FireCreated: {
+			"Αναφορά πυρκαγιάς κοντά στην περιοχή σας",
+			"Αναφέρθηκε πυρκαγιά κοντά στο {{.Fire.Latitude | coord}}, {{.Fire.Longitude | coord}}.\n\n{{.Fire.Description}}\n\nΛεπτομέρειες: {{.Link}}",
+		},
+		events.FireStatusChanged: {
+			"Πυρκαγιά #{{.Fire.ID}}: {{.Status}}",
+			"Η πυρκαγιά κοντά στο {{.Fire.Latitude | coord}}, {{.Fire.Longitude | coord}} είναι πλέον σε κατάσταση: {{.Status}}.\n\nΛεπτομέρειες: {{.Link}}",
+		},
+	},
+	"tr": {
+		events.FireCreated: {
+			"İzleme alanınızın yakınında yangın bildirildi",
+			"{{.Fire.Latitude | coord}}, {{.Fire.Longitude | coord}} yakınında bir yangın bildirildi.\n\n{{.Fire.Description}}\n\nAyrıntılar: {{.Link}}",
+		},
+		events.FireStatusChanged: {
+			"Yangın #{{.Fire.ID}}: {{.Status}}",
+			"{{.Fire.Latitude | coord}}, {{.Fire.Longitude | coord}} yakınındaki yangının durumu: {{.Status}}.\n\nAyrıntılar: {{.Link}}",
+		},
+	},
+}
+
+var statusLabels = map[string]map[string]string{
+	"en": {"reported": "reported", "seen": "seen by firefighters", "closed": "closed"},
+	"el": {"reported": "αναφέρθηκε", "seen": "εντοπίστηκε από πυροσβέστες", "closed": "έκλεισε"},
+	"tr": {"reported": "bildirildi", "seen": "itfaiye tarafından görüldü", "closed": "kapatıldı"},
+}
+
+var templateFuncs = template.FuncMap{
+	"coord": func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) },
+}
+
+// Templates renders notification messages per event type and language.
+type Templates struct {
+	frontendURL string
+	templates   map[string]map[string]messageTemplate
+}
+
+// NewTemplates parses the built-in message templates. Links to fires are
+// built from frontendURL.
+func NewTemplates(frontendURL string) *Templates {
+	t := &Templates{
+		frontendURL: strings.TrimSuffix(frontendURL, "/"),
+		templates:   make(map[string]map[string]messageTemplate),
+	}
+	for language, byEvent := range templateSources {
+		t.templates[language] = make(map[string]messageTemplate)
+		for eventType, source := range byEvent {
+			name := language + "/" + eventType
+			t.templates[language][eventType] = messageTemplate{
+				subject: template.Must(template.New(name + "/subject").Funcs(templateFuncs).Parse(source[0])),
+				body:    template.Must(template.New(name + "/body").Funcs(templateFuncs).Parse(source[1])),
+			}
+		}
+	}
+	return t
+}
+
+// Render builds the message for a notification about fire, falling back to
+// DefaultLanguage when the notification's language is not available.
+func (t *Templates) Render(n *models.Notification, fire *models.Fire) (Message, error) {
+	language := n.Language
+	if _, ok := t.templates[language]; !ok {
+		language = DefaultLanguage
+	}
+	tmpl, ok := t.templates[language][n.EventType]
+	if !ok {
+		return Message{}, fmt.Errorf("no template for event type %q", n.EventType)
+	}
+
+	// Render the status the notification was queued for, not the current
+	// one, so a delayed retry still describes the change it announces.
+	status := n.FireStatus
+	if label, ok := statusLabels[language][status]; ok {
+		status = label
+	}
+	data := templateData{
+		Fire:   fire,
+		Status: status,
+		Link:   fmt.Sprintf("%s/fires/%d", t.frontendURL, fire.ID),
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+	return Message{To: n.Address, Subject: subject.String(), Body: body.String(), URL: data.Link}, nil
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestTemplatesRender(t *testing.T) {
+	templates := NewTemplates("https://fires.example.com/")
+	fire := &models.Fire{ID: 42, Latitude: 38.123456, Longitude: 23.5, Description: "Smoke over the ridge", Status: "closed"}
+
+	tests := []struct {
+		name         string
+		notification models.Notification
+		subject      string
+		body         []string
+	}{
+		{
+			name:         "created",
+			notification: models.Notification{EventType: events.FireCreated, Language: "en", Address: "+300000000"},
+			subject:      "Fire reported near your watch area",
+			body:         []string{"38.1235, 23.5000", "Smoke over the ridge", "https://fires.example.com/fires/42"},
+		},
+		{
+			name:         "status in greek",
+			notification: models.Notification{EventType: events.FireStatusChanged, FireStatus: "seen", Language: "el"},
+			subject:      "Πυρκαγιά #42: εντοπίστηκε από πυροσβέστες",
+			body:         []string{"εντοπίστηκε από πυροσβέστες"},
+		},
+		{
+			name:         "status in turkish",
+			notification: models.Notification{EventType: events.FireStatusChanged, FireStatus: "closed", Language: "tr"},
+			subject:      "Yangın #42: kapatıldı",
+		},
+		{
+			// The queued status is announced, not the fire's current one
+			name:         "queued status",
+			notification: models.Notification{EventType: events.FireStatusChanged, FireStatus: "seen", Language: "en"},
+			subject:      "Fire #42 is now seen by firefighters",
+		},
+		{
+			name:         "unknown language falls back",
+			notification: models.Notification{EventType: events.FireStatusChanged, FireStatus: "reported", Language: "xx"},
+			subject:      "Fire #42 is now reported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := templates.Render(&tt.notification, fire)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.Subject != tt.subject {
+				t.Errorf("subject %q, want %q", msg.Subject, tt.subject)
+			}
+			for _, want := range tt.body {
+				if !strings.Contains(msg.Body, want) {
+					t.Errorf("body %q does not contain %q", msg.Body, want)
+				}
+			}
+			if msg.To != tt.notification.Address || msg.URL != "https://fires.example.com/fires/42" {
+				t.Errorf("unexpected message %+v", msg)
+			}
+		})
+	}
+}
+
+func TestTemplatesUnknownEvent(t *testing.T) {
+	_, err := NewTemplates("").Render(&models.Notification{EventType: "fire.exploded", Language: "en"}, &models.Fire{ID: 1})
+	if err == nil {
+		t.Fatal("expected an error for an event type without templates")
+	}
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+type WorkerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Concurrency  int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Lease        time.Duration // Must exceed the slowest notifier's timeout
+}
+
+// Worker sends queued notifications through the notifier registered for
+// their channel, retrying failures with exponential backoff until
+// MaxAttempts is reached.
+type Worker struct {
+	notificationsRepo *repository.NotificationsRepository
+	firesRepo         *repository.FiresRepository
+	templates         *Templates
+	notifiers         map[string]Notifier
+	config            WorkerConfig
+	logger            *zap.Logger
+}
+
+func NewWorker(notificationsRepo *repository.NotificationsRepository, firesRepo *repository.FiresRepository, templates *Templates, config WorkerConfig, logger *zap.Logger) *Worker {
+	return &Worker{
+		notificationsRepo: notificationsRepo,
+		firesRepo:         firesRepo,
+		templates:         templates,
+		notifiers:         make(map[string]Notifier),
+		config:            config,
+		logger:            logger,
+	}
+}
+
+// Register adds a notifier for its channel. Must be called before Run.
+func (w *Worker) Register(notifier Notifier) {
+	w.notifiers[notifier.Channel()] = notifier
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) poll(ctx context.Context) {
+	notifications, err := w.notificationsRepo.ClaimDue(ctx, w.config.BatchSize, w.config.Lease)
+	if err != nil {
+		w.logger.Error("failed to claim notifications", zap.Error(err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, w.config.Concurrency)
+	for _, n := range notifications {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n *models.Notification) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.send(ctx, n)
+		}(n)
+	}
+	wg.Wait()
+}
+
+func (w *Worker) send(ctx context.Context, n *models.Notification) {
+	logger := w.logger.With(
+		zap.Int64("notification_id", n.ID),
+		zap.String("channel", n.Channel),
+		zap.Int("attempt", n.Attempts),
+	)
+
+	notifier, ok := w.notifiers[n.Channel]
+	if !ok {
+		w.fail(ctx, logger, n, "no notifier configured for channel")
+		return
+	}
+
+	fire, err := w.firesRepo.GetByID(ctx, n.FireID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.fail(ctx, logger, n, "fire not found")
+		return
+	} else if err != nil {
+		w.retry(ctx, logger, n, err)
+		return
+	}
+
+	msg, err := w.templates.Render(n, fire)
+	if err != nil {
+		w.fail(ctx, logger, n, err.Error())
+		return
+	}
+
+	if err := notifier.Send(ctx, msg); isPermanent(err) {
+		w.fail(ctx, logger, n, err.Error())
+		return
+	} else if err != nil {
+		w.retry(ctx, logger, n, err)
+		return
+	}
+
+	if err := w.notificationsRepo.MarkSent(ctx, n.ID); err != nil {
+		logger.Error("failed to mark notification sent", zap.Error(err))
+	}
+}
+
+func (w *Worker) retry(ctx context.Context, logger *zap.Logger, n *models.Notification, sendErr error) {
+	if n.Attempts >= w.config.MaxAttempts {
+		w.fail(ctx, logger, n, sendErr.Error())
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(w.backoff(n.Attempts))
+	logger.Info("notification failed, will retry", zap.Time("next_attempt_at", nextAttemptAt), zap.Error(sendErr))
+	if err := w.notificationsRepo.MarkRetry(ctx, n.ID, sendErr.Error(), nextAttemptAt); err != nil {
+		logger.Error("failed to reschedule notification", zap.Error(err))
+	}
+}
+
+func (w *Worker) fail(ctx context.Context, logger *zap.Logger, n *models.Notification, reason string) {
+	logger.Warn("notification failed permanently", zap.String("reason", reason))
+	if err := w.notificationsRepo.MarkFailed(ctx, n.ID, reason); err != nil {
+		logger.Error("failed to mark notification failed", zap.Error(err))
+	}
+}
+
+func (w *Worker) backoff(attempt int) time.Duration {
+	delay := float64(w.config.BaseBackoff) * math.Pow(2, float64(attempt-1))
+	if delay > float64(w.config.MaxBackoff) {
+		return w.config.MaxBackoff
+	}
+	return time.Duration(delay)
+}
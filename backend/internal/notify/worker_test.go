@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"context"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/notify/notifytest"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/testdb"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func TestWorkerBackoff(t *testing.T) {
+	w := &Worker{config: WorkerConfig{BaseBackoff: time.Minute, MaxBackoff: 5 * time.Minute}}
+
+	for attempt, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 4: 5 * time.Minute, 10: 5 * time.Minute} {
+		if got := w.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+type queuedNotification struct {
+	status    string
+	attempts  int
+	lastError *string
+	nextAt    time.Time
+}
+
+// newWorkerTest returns a worker sending SMS through a stub gateway, with
+// one notification queued for it.
+func newWorkerTest(t *testing.T, config WorkerConfig) (*Worker, *pgxpool.Pool, *notifytest.Gateway, int64) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	gateway := notifytest.NewGateway()
+	t.Cleanup(gateway.Close)
+
+	user, err := repository.NewUsersRepository(db).Create(ctx, "watcher", "Watcher", nil, "citizen", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	firesRepo := repository.NewFiresRepository(db)
+	fire, err := firesRepo.Create(ctx, &user.ID, 38.0, 23.7, "Smoke", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notificationsRepo := repository.NewNotificationsRepository(db)
+	n, err := notificationsRepo.Enqueue(ctx, &models.Notification{
+		UserID:     user.ID,
+		FireID:     fire.ID,
+		EventType:  events.FireCreated,
+		FireStatus: fire.Status,
+		Channel:    "sms",
+		Address:    "+300000000",
+		Language:   "en",
+	}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worker := NewWorker(notificationsRepo, firesRepo, NewTemplates("http://frontend.test"), config, zap.NewNop())
+	worker.Register(NewSMSNotifier(gateway.URL(), "key", "FireTracker", 5*time.Second))
+	return worker, db, gateway, n.ID
+}
+
+func loadNotification(t *testing.T, db *pgxpool.Pool, id int64) queuedNotification {
+	t.Helper()
+	var n queuedNotification
+	err := db.QueryRow(context.Background(),
+		`SELECT status, attempts, last_error, next_attempt_at FROM notifications WHERE id = $1`, id,
+	).Scan(&n.status, &n.attempts, &n.lastError, &n.nextAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func makeDue(t *testing.T, db *pgxpool.Pool, id int64) {
+	t.Helper()
+	if _, err := db.Exec(context.Background(), `UPDATE notifications SET next_attempt_at = NOW() - INTERVAL '1 second' WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkerRetriesAfterGatewayError(t *testing.T) {
+	config := WorkerConfig{BatchSize: 10, Concurrency: 2, MaxAttempts: 3, BaseBackoff: time.Hour, MaxBackoff: time.Hour, Lease: time.Minute}
+	w, db, gateway, id := newWorkerTest(t, config)
+	ctx := context.Background()
+
+	gateway.SetStatusCode(http.StatusServiceUnavailable)
+	before := time.Now()
+	w.poll(ctx)
+
+	n := loadNotification(t, db, id)
+	if n.status != "pending" || n.attempts != 1 || n.lastError == nil {
+		t.Fatalf("after a 503: %+v, want pending after 1 attempt with the error kept", n)
+	}
+	if wait := n.nextAt.Sub(before); wait < 59*time.Minute || wait > 61*time.Minute {
+		t.Errorf("next attempt in %v, want the one-hour backoff", wait)
+	}
+
+	// Not due yet, so the next poll leaves it alone
+	w.poll(ctx)
+	if got := len(gateway.Requests()); got != 1 {
+		t.Fatalf("gateway got %d requests before the backoff elapsed, want 1", got)
+	}
+
+	makeDue(t, db, id)
+	gateway.SetStatusCode(http.StatusOK)
+	w.poll(ctx)
+
+	n = loadNotification(t, db, id)
+	if n.status != "sent" || n.attempts != 2 || n.lastError != nil {
+		t.Fatalf("after a 200: %+v, want sent after 2 attempts", n)
+	}
+	requests := gateway.Requests()
+	if len(requests) != 2 || requests[1].Header.Get("Authorization") != "Bearer key" {
+		t.Errorf("unexpected gateway requests %+v", requests)
+	}
+}
+
+func TestWorkerGivesUpAfterMaxAttempts(t *testing.T) {
+	config := WorkerConfig{BatchSize: 10, Concurrency: 1, MaxAttempts: 2, BaseBackoff: time.Hour, MaxBackoff: time.Hour, Lease: time.Minute}
+	w, db, gateway, id := newWorkerTest(t, config)
+	ctx := context.Background()
+	gateway.SetStatusCode(http.StatusBadGateway)
+
+	for i := 0; i < 3; i++ {
+		makeDue(t, db, id)
+		w.poll(ctx)
+	}
+
+	n := loadNotification(t, db, id)
+	if n.status != "failed" || n.attempts != 2 {
+		t.Fatalf("got %+v, want failed after 2 attempts", n)
+	}
+	if got := len(gateway.Requests()); got != 2 {
+		t.Errorf("gateway got %d requests, want 2", got)
+	}
+}
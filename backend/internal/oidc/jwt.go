@@ -0,0 +1,287 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far the provider's clock may be ahead of or behind ours.
+const clockSkew = 2 * time.Minute
+
+// keySet is the provider's signing keys by key ID.
+type keySet struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// verify checks the ID token signature and standard claims and returns the
+// claims the application uses.
+func (c *Client) verify(ctx context.Context, token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("decode id token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode id token signature: %w", err)
+	}
+
+	key, err := c.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch header.Alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("id token key does not match RS256")
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, errors.New("invalid id token signature")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return nil, errors.New("id token key does not match ES256")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return nil, errors.New("invalid id token signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported id token algorithm %q", header.Alg)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("decode id token claims: %w", err)
+	}
+	var std struct {
+		Iss               string          `json:"iss"`
+		Sub               string          `json:"sub"`
+		Aud               audience        `json:"aud"`
+		Azp               string          `json:"azp"`
+		Exp               int64           `json:"exp"`
+		Iat               int64           `json:"iat"`
+		Nonce             string          `json:"nonce"`
+		Email             string          `json:"email"`
+		EmailVerified     json.RawMessage `json:"email_verified"`
+		Name              string          `json:"name"`
+		PreferredUsername string          `json:"preferred_username"`
+	}
+	if err := decodeSegment(parts[1], &std); err != nil {
+		return nil, fmt.Errorf("decode id token claims: %w", err)
+	}
+
+	if std.Iss != c.config.Issuer {
+		return nil, fmt.Errorf("id token issuer %q is not %q", std.Iss, c.config.Issuer)
+	}
+	if !std.Aud.contains(c.config.ClientID) {
+		return nil, errors.New("id token is not for this client")
+	}
+	if len(std.Aud) > 1 && std.Azp != c.config.ClientID {
+		return nil, errors.New("id token authorized party is not this client")
+	}
+	if std.Sub == "" {
+		return nil, errors.New("id token has no subject")
+	}
+	if std.Exp == 0 || now.After(time.Unix(std.Exp, 0).Add(clockSkew)) {
+		return nil, errors.New("id token has expired")
+	}
+	if std.Iat != 0 && time.Unix(std.Iat, 0).After(now.Add(clockSkew)) {
+		return nil, errors.New("id token was issued in the future")
+	}
+
+	claims := &Claims{
+		Issuer:            std.Iss,
+		Subject:           std.Sub,
+		Email:             std.Email,
+		EmailVerified:     parseBool(std.EmailVerified),
+		Name:              std.Name,
+		PreferredUsername: std.PreferredUsername,
+		Nonce:             std.Nonce,
+	}
+	if groups, ok := raw[c.config.GroupsClaim]; ok {
+		claims.Groups = parseGroups(groups)
+	}
+	return claims, nil
+}
+
+// key returns the signing key with the given ID, refetching the key set
+// when the ID is unknown so provider key rotation is picked up.
+func (c *Client) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	keys := c.keys
+	c.mu.Unlock()
+
+	if keys != nil {
+		if key, ok := keys.lookup(kid); ok {
+			return key, nil
+		}
+		// Don't let tokens with made-up key IDs hammer the provider.
+		if time.Since(keys.fetchedAt) < time.Minute {
+			return nil, fmt.Errorf("unknown id token key %q", kid)
+		}
+	}
+
+	d, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := c.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch provider keys: %w", err)
+	}
+
+	keys = &keySet{keys: map[string]crypto.PublicKey{}, fetchedAt: time.Now()}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.publicKey(); err == nil {
+			keys.keys[k.Kid] = pub
+		}
+	}
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+
+	if key, ok := keys.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown id token key %q", kid)
+}
+
+// lookup finds a key by ID. Tokens without a key ID are accepted when the
+// provider publishes a single key.
+func (s *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("key is not on the curve")
+		}
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// audience accepts the aud claim as a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBool accepts true and "true"; some providers send the string.
+func parseBool(raw json.RawMessage) bool {
+	s := strings.Trim(string(raw), `"`)
+	return s == "true"
+}
+
+// parseGroups accepts a list of group names or a single space-separated
+// string.
+func parseGroups(raw json.RawMessage) []string {
+	var groups []string
+	if err := json.Unmarshal(raw, &groups); err == nil {
+		return groups
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return strings.Fields(single)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,148 @@
+// Package outbox delivers events recorded in the outbox table to in-process
+// consumers, at least once per consumer, retrying failures with backoff.
+package outbox
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Consumer handles outbox entries. Handle may be called more than once for
+// the same entry and must be idempotent or tolerate duplicates.
+type Consumer interface {
+	Name() string
+	Handle(ctx context.Context, entry *models.OutboxEntry) error
+}
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Lease        time.Duration // How long a claimed delivery is hidden from other instances
+}
+
+type Relay struct {
+	outboxRepo *repository.OutboxRepository
+	config     Config
+	logger     *zap.Logger
+	consumers  map[string]Consumer
+	names      []string
+}
+
+func NewRelay(outboxRepo *repository.OutboxRepository, config Config, logger *zap.Logger) *Relay {
+	return &Relay{
+		outboxRepo: outboxRepo,
+		config:     config,
+		logger:     logger,
+		consumers:  make(map[string]Consumer),
+	}
+}
+
+// Register adds a consumer. Entries recorded before a consumer is first
+// registered are not delivered to it. Must be called before Run.
+func (r *Relay) Register(consumer Consumer) {
+	if _, ok := r.consumers[consumer.Name()]; ok {
+		return
+	}
+	r.consumers[consumer.Name()] = consumer
+	r.names = append(r.names, consumer.Name())
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	if len(r.consumers) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) poll(ctx context.Context) {
+	for {
+		dispatched, err := r.outboxRepo.Dispatch(ctx, r.names, r.config.BatchSize)
+		if err != nil {
+			r.logger.Error("failed to dispatch outbox entries", zap.Error(err))
+			break
+		}
+		if dispatched < r.config.BatchSize {
+			break
+		}
+	}
+
+	for {
+		deliveries, err := r.outboxRepo.ClaimDue(ctx, r.names, r.config.BatchSize, r.config.Lease)
+		if err != nil {
+			r.logger.Error("failed to claim outbox deliveries", zap.Error(err))
+			return
+		}
+
+		for _, delivery := range deliveries {
+			r.deliver(ctx, delivery)
+		}
+		if len(deliveries) < r.config.BatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (r *Relay) deliver(ctx context.Context, delivery *models.OutboxDelivery) {
+	consumer := r.consumers[delivery.Consumer]
+	logger := r.logger.With(
+		zap.String("consumer", delivery.Consumer),
+		zap.Int64("outbox_id", delivery.Entry.ID),
+		zap.String("event_type", delivery.Entry.EventType),
+		zap.Int("attempt", delivery.Attempts),
+	)
+
+	handleCtx, cancel := context.WithTimeout(ctx, r.config.Lease)
+	err := consumer.Handle(handleCtx, delivery.Entry)
+	cancel()
+
+	if err == nil {
+		if err := r.outboxRepo.MarkDelivered(ctx, delivery.ID); err != nil {
+			logger.Error("failed to mark outbox delivery delivered", zap.Error(err))
+		}
+		return
+	}
+
+	if delivery.Attempts >= r.config.MaxAttempts {
+		logger.Error("outbox delivery failed permanently", zap.Error(err))
+		if err := r.outboxRepo.MarkFailed(ctx, delivery.ID, err.Error()); err != nil {
+			logger.Error("failed to mark outbox delivery failed", zap.Error(err))
+		}
+		return
+	}
+
+	next := time.Now().Add(r.backoff(delivery.Attempts))
+	logger.Warn("outbox delivery failed, will retry", zap.Time("next_attempt_at", next), zap.Error(err))
+	if err := r.outboxRepo.MarkRetry(ctx, delivery.ID, err.Error(), next); err != nil {
+		logger.Error("failed to schedule outbox retry", zap.Error(err))
+	}
+}
+
+// backoff returns the delay before the attempt following the given one.
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := float64(r.config.BaseBackoff) * math.Pow(2, float64(attempt-1))
+	if delay > float64(r.config.MaxBackoff) {
+		return r.config.MaxBackoff
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,140 @@
+// Package password hashes and verifies account passwords. Hashes are stored
+// as self-describing strings, so hashes made with either algorithm, or
+// older parameters, keep verifying after the configuration changes.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHash is returned for hashes in a format this package does not
+// produce.
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher hashes new passwords.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether a stored hash was made with another
+	// algorithm or weaker parameters and should be replaced on next login.
+	NeedsRehash(encoded string) bool
+}
+
+// NewHasher returns the hasher for algorithm, "argon2id" or "bcrypt".
+func NewHasher(algorithm string) (Hasher, error) {
+	switch algorithm {
+	case "argon2id", "":
+		return DefaultArgon2id, nil
+	case "bcrypt":
+		return Bcrypt{Cost: 12}, nil
+	default:
+		return nil, fmt.Errorf("unknown password hasher %q", algorithm)
+	}
+}
+
+// Verify checks password against a hash made by any supported hasher.
+func Verify(encoded, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	default:
+		return false, ErrUnknownHash
+	}
+}
+
+// Argon2id hashes with argon2id and encodes the result in the PHC string
+// format, e.g. "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>".
+type Argon2id struct {
+	Memory    uint32 // KiB
+	Time      uint32
+	Threads   uint8
+	SaltLen   int
+	KeyLength uint32
+}
+
+// DefaultArgon2id follows the OWASP recommended minimums.
+var DefaultArgon2id = Argon2id{Memory: 64 * 1024, Time: 3, Threads: 2, SaltLen: 16, KeyLength: 32}
+
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Time, a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a Argon2id) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory < a.Memory || params.Time < a.Time || params.Threads < a.Threads
+}
+
+func decodeArgon2id(encoded string) (Argon2id, []byte, []byte, error) {
+	var params Argon2id
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+	return params, salt, key, nil
+}
+
+// Bcrypt hashes with bcrypt. Passwords longer than 72 bytes are rejected by
+// bcrypt itself.
+type Bcrypt struct {
+	Cost int
+}
+
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < b.Cost
+}
@@ -0,0 +1,45 @@
+// Package pow implements a hashcash-style proof of work: given a random
+// challenge, the client searches for a nonce such that
+// SHA-256(challenge + ":" + nonce) starts with a number of zero bits. Each
+// extra bit doubles the expected work.
+package pow
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/bits"
+)
+
+// MaxNonceLength bounds the work a server does to check one solution.
+const MaxNonceLength = 64
+
+// NewChallenge returns a random, URL-safe challenge.
+func NewChallenge() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// Verify reports whether nonce solves challenge at the given difficulty.
+func Verify(challenge, nonce string, difficulty int) bool {
+	if nonce == "" || len(nonce) > MaxNonceLength {
+		return false
+	}
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	return LeadingZeroBits(sum[:]) >= difficulty
+}
+
+// LeadingZeroBits counts the zero bits before the first one bit.
+func LeadingZeroBits(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c != 0 {
+			return n + bits.LeadingZeros8(c)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,71 @@
+// Package ratelimit implements token-bucket rate limits backed by memory or
+// by Postgres when several instances must share counts.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows bursts of up to Requests and refills at Requests per Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit reads "<requests>/<duration>", such as "10/15m". An empty spec
+// returns the zero Limit, which disables limiting.
+func ParseLimit(spec string) (Limit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Limit{}, nil
+	}
+
+	requestsPart, perPart, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<duration>", spec)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(requestsPart))
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", spec)
+	}
+	per, err := time.ParseDuration(strings.TrimSpace(perPart))
+	if err != nil || per <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: duration must be positive", spec)
+	}
+	return Limit{Requests: requests, Per: per}, nil
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Per.String()
+}
+
+func (l Limit) capacity() float64 {
+	return float64(l.Requests)
+}
+
+func (l Limit) refillPerSecond() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// retryAfter is how long until a bucket holding tokens has a whole token.
+func (l Limit) retryAfter(tokens float64) time.Duration {
+	seconds := (1 - tokens) / l.refillPerSecond()
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// Store takes tokens from buckets identified by key.
+type Store interface {
+	// Take removes a token from key's bucket if one is available. When it
+	// is not, retryAfter says how long until one will be.
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
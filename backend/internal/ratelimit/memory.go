@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store drops full buckets.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Limits apply per instance,
+// so N instances let through up to N times the configured rate.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+	refill   float64 // Tokens per second
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.capacity(), updated: now}
+		s.buckets[key] = b
+	}
+	b.capacity = limit.capacity()
+	b.refill = limit.refillPerSecond()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*b.refill)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, limit.retryAfter(b.tokens), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// sweep drops buckets that have refilled completely, since a missing
+// bucket behaves the same.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.refill >= b.capacity {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
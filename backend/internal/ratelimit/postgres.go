@@ -0,0 +1,25 @@
+package ratelimit
+
+import (
+	"context"
+	"fire-tracker/internal/repository"
+	"time"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every
+// instance sees the same counts. Each request costs a short transaction.
+type PostgresStore struct {
+	rateLimitsRepo *repository.RateLimitsRepository
+}
+
+func NewPostgresStore(rateLimitsRepo *repository.RateLimitsRepository) *PostgresStore {
+	return &PostgresStore{rateLimitsRepo: rateLimitsRepo}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	allowed, tokens, err := s.rateLimitsRepo.Take(ctx, key, limit.capacity(), limit.refillPerSecond())
+	if err != nil || allowed {
+		return allowed, 0, err
+	}
+	return false, limit.retryAfter(tokens), nil
+}
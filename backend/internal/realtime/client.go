@@ -0,0 +1,212 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fire-tracker/internal/api/middleware"
+	"fire-tracker/internal/events"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = 30 * time.Second
+	maxMessageSize = 8192
+	sendQueueSize  = 64
+
+	// Clients that fall this far behind are disconnected instead of being
+	// sent a lag notice.
+	maxDropped = 256
+)
+
+// message is the envelope for everything sent over the socket.
+type message struct {
+	Type         string        `json:"type"` // "subscribe", "unsubscribe", "subscribed", "event", "lagged", "resync", "error"
+	Event        *events.Event `json:"event,omitempty"`
+	Subscription *Filter       `json:"subscription,omitempty"`
+	Dropped      int           `json:"dropped,omitempty"`
+	Message      string        `json:"message,omitempty"`
+}
+
+// subscribeRequest is the client message selecting what to receive.
+type subscribeRequest struct {
+	Type string `json:"type"`
+	Filter
+}
+
+// Client is a single WebSocket connection.
+type Client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	userID int
+
+	mu      sync.Mutex
+	send    chan []byte
+	closed  bool
+	dropped int
+	filter  *Filter
+}
+
+// ServeHTTP upgrades an authenticated request to a WebSocket connection.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written an error response.
+		return
+	}
+
+	client := &Client{
+		hub:    h,
+		conn:   conn,
+		userID: userID,
+		send:   make(chan []byte, sendQueueSize),
+	}
+	h.register(client)
+
+	go client.writePump()
+	go client.readPump()
+}
+
+func (c *Client) matches(fireID int, latitude, longitude float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter != nil && c.filter.matches(fireID, latitude, longitude)
+}
+
+// enqueue queues a message without blocking. When the queue is full the
+// message is dropped and counted so the client can be told it lagged.
+func (c *Client) enqueue(payload []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	select {
+	case c.send <- payload:
+	default:
+		c.dropped++
+		if c.dropped > maxDropped {
+			c.hub.logger.Info("disconnecting slow realtime client", zap.Int("user_id", c.userID))
+			c.closed = true
+			close(c.send)
+		}
+	}
+}
+
+func (c *Client) closeSend() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+func (c *Client) takeDropped() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dropped := c.dropped
+	c.dropped = 0
+	return dropped
+}
+
+func (c *Client) sendControl(msg *message) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	c.enqueue(payload)
+}
+
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var req subscribeRequest
+		if err := c.conn.ReadJSON(&req); err != nil {
+			switch err.(type) {
+			case *json.SyntaxError, *json.UnmarshalTypeError:
+				c.sendControl(&message{Type: "error", Message: "invalid message"})
+				continue
+			}
+			return
+		}
+
+		switch req.Type {
+		case "subscribe":
+			filter := req.Filter
+			if err := filter.validate(); err != nil {
+				c.sendControl(&message{Type: "error", Message: err.Error()})
+				continue
+			}
+			c.mu.Lock()
+			c.filter = &filter
+			c.mu.Unlock()
+			c.sendControl(&message{Type: "subscribed", Subscription: &filter})
+		case "unsubscribe":
+			c.mu.Lock()
+			c.filter = nil
+			c.mu.Unlock()
+			c.sendControl(&message{Type: "subscribed"})
+		default:
+			c.sendControl(&message{Type: "error", Message: "unknown message type"})
+		}
+	}
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "connection closed"))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+
+			if dropped := c.takeDropped(); dropped > 0 {
+				notice, _ := json.Marshal(&message{Type: "lagged", Dropped: dropped})
+				if err := c.conn.WriteMessage(websocket.TextMessage, notice); err != nil {
+					return
+				}
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
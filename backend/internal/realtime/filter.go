@@ -0,0 +1,86 @@
+package realtime
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	earthRadiusMeters = 6371000.0
+	maxRadiusMeters   = 500000.0
+	maxFireIDs        = 100
+)
+
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Filter selects the events a client receives. An event matches when its
+// fire is listed in FireIDs or lies inside the bounding box or radius.
+type Filter struct {
+	BBox    *[4]float64 `json:"bbox,omitempty"` // [min_lon, min_lat, max_lon, max_lat]
+	Center  *Point      `json:"center,omitempty"`
+	Radius  float64     `json:"radius,omitempty"` // Meters around Center
+	FireIDs []int       `json:"fire_ids,omitempty"`
+
+	fireIDs map[int]struct{}
+}
+
+func (f *Filter) validate() error {
+	if f.BBox == nil && f.Center == nil && len(f.FireIDs) == 0 {
+		return errors.New("subscription needs a bbox, a center and radius, or fire_ids")
+	}
+	if f.BBox != nil && f.Center != nil {
+		return errors.New("use either bbox or center and radius, not both")
+	}
+	if f.BBox != nil {
+		b := f.BBox
+		if b[0] < -180 || b[2] > 180 || b[1] < -90 || b[3] > 90 || b[0] > b[2] || b[1] > b[3] {
+			return errors.New("bbox must be [min_lon, min_lat, max_lon, max_lat]")
+		}
+	}
+	if f.Center != nil {
+		if f.Center.Latitude < -90 || f.Center.Latitude > 90 || f.Center.Longitude < -180 || f.Center.Longitude > 180 {
+			return errors.New("center must be a valid latitude and longitude")
+		}
+		if f.Radius <= 0 || f.Radius > maxRadiusMeters {
+			return errors.New("radius must be between 0 and 500000 meters")
+		}
+	}
+	if len(f.FireIDs) > maxFireIDs {
+		return errors.New("at most 100 fire_ids may be subscribed")
+	}
+
+	f.fireIDs = make(map[int]struct{}, len(f.FireIDs))
+	for _, id := range f.FireIDs {
+		f.fireIDs[id] = struct{}{}
+	}
+	return nil
+}
+
+// matches reports whether an event about the fire at latitude/longitude
+// should be delivered.
+func (f *Filter) matches(fireID int, latitude, longitude float64) bool {
+	if _, ok := f.fireIDs[fireID]; ok {
+		return true
+	}
+	if f.BBox != nil {
+		b := f.BBox
+		return longitude >= b[0] && longitude <= b[2] && latitude >= b[1] && latitude <= b[3]
+	}
+	if f.Center != nil {
+		return distance(f.Center.Latitude, f.Center.Longitude, latitude, longitude) <= f.Radius
+	}
+	return false
+}
+
+// distance returns the great-circle distance between two points in meters.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
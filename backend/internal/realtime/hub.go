@@ -0,0 +1,178 @@
+// Package realtime pushes fire and comment changes to WebSocket clients that
+// subscribe to a geographic area or a set of fires.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const hubQueueSize = 1024
+
+// Hub tracks connected clients and routes broker events to the ones whose
+// filter matches.
+type Hub struct {
+	broker    *events.Broker
+	firesRepo *repository.FiresRepository
+	logger    *zap.Logger
+	upgrader  websocket.Upgrader
+
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+}
+
+func NewHub(broker *events.Broker, firesRepo *repository.FiresRepository, allowedOrigins []string, logger *zap.Logger) *Hub {
+	origins := make(map[string]struct{}, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[origin] = struct{}{}
+	}
+
+	return &Hub{
+		broker:    broker,
+		firesRepo: firesRepo,
+		logger:    logger,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 4096,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" {
+					// Non-browser clients such as field tablets.
+					return true
+				}
+				_, ok := origins[origin]
+				return ok
+			},
+		},
+		clients: make(map[*Client]struct{}),
+	}
+}
+
+// Run forwards broker events to clients until ctx is cancelled.
+func (h *Hub) Run(ctx context.Context) {
+	lastID := int64(0)
+	for {
+		sub, replay, complete := h.broker.Subscribe(lastID, hubQueueSize)
+		if !complete {
+			h.logger.Warn("realtime hub missed events, asking clients to resync", zap.Int64("last_event_id", lastID))
+			h.broadcastControl(&message{Type: "resync"})
+		}
+		for _, event := range replay {
+			h.dispatch(ctx, event)
+			lastID = event.ID
+		}
+
+		if !h.consume(ctx, sub, &lastID) {
+			sub.Unsubscribe()
+			return
+		}
+	}
+}
+
+// consume dispatches events until the subscription is dropped (returns true)
+// or ctx is cancelled (returns false).
+func (h *Hub) consume(ctx context.Context, sub *events.Subscription, lastID *int64) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case event, ok := <-sub.C:
+			if !ok {
+				return true
+			}
+			h.dispatch(ctx, event)
+			*lastID = event.ID
+		}
+	}
+}
+
+func (h *Hub) dispatch(ctx context.Context, event events.Event) {
+	if event.Type == events.Reset {
+		h.broadcastControl(&message{Type: "resync"})
+		return
+	}
+
+	fireID, latitude, longitude, ok := h.locate(ctx, event)
+	if !ok {
+		return
+	}
+
+	payload, err := json.Marshal(&message{Type: "event", Event: &event})
+	if err != nil {
+		h.logger.Error("failed to encode realtime event", zap.Int64("event_id", event.ID), zap.Error(err))
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if client.matches(fireID, latitude, longitude) {
+			client.enqueue(payload)
+		}
+	}
+}
+
+// locate returns the fire an event is about and where it is.
+func (h *Hub) locate(ctx context.Context, event events.Event) (int, float64, float64, bool) {
+	switch data := event.Data.(type) {
+	case *models.Fire:
+		return data.ID, data.Latitude, data.Longitude, true
+	case *models.Comment:
+		return h.locateFire(ctx, data.FireID)
+	case *events.DeletedComment:
+		return h.locateFire(ctx, data.FireID)
+	default:
+		return 0, 0, 0, false
+	}
+}
+
+// locateFire looks up the fire a comment event is about.
+func (h *Hub) locateFire(ctx context.Context, fireID int) (int, float64, float64, bool) {
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	fire, err := h.firesRepo.GetByID(lookupCtx, fireID)
+	if err != nil {
+		h.logger.Warn("failed to locate fire for comment event",
+			zap.Int("fire_id", fireID), zap.Error(err))
+		return 0, 0, 0, false
+	}
+	return fire.ID, fire.Latitude, fire.Longitude, true
+}
+
+func (h *Hub) broadcastControl(msg *message) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		client.enqueue(payload)
+	}
+}
+
+func (h *Hub) register(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client] = struct{}{}
+}
+
+func (h *Hub) unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		client.closeSend()
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const apiKeyColumns = `id, name, prefix, user_id, scopes, expires_at, last_used_at, revoked_at, created_by, created_at`
+
+type APIKeysRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeysRepository(db *pgxpool.Pool) *APIKeysRepository {
+	return &APIKeysRepository{db: db}
+}
+
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.UserID, &key.Scopes, &key.ExpiresAt,
+		&key.LastUsedAt, &key.RevokedAt, &key.CreatedBy, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (r *APIKeysRepository) Create(ctx context.Context, name, prefix, keyHash string, userID int, scopes []string, expiresAt *time.Time, createdBy int) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRow(ctx,
+		`INSERT INTO api_keys (name, prefix, key_hash, user_id, scopes, expires_at, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING `+apiKeyColumns,
+		name, prefix, keyHash, userID, scopes, expiresAt, createdBy,
+	))
+}
+
+// GetActiveByHash returns the key with the given hash unless it is revoked
+// or expired.
+func (r *APIKeysRepository) GetActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys
+		 WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`,
+		keyHash,
+	))
+}
+
+func (r *APIKeysRepository) List(ctx context.Context) ([]*models.APIKey, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// TouchLastUsed records that the key was used.
+func (r *APIKeysRepository) TouchLastUsed(ctx context.Context, id int) error {
+	_, err := r.db.Exec(ctx, `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// Revoke disables a key. It returns pgx.ErrNoRows if the key does not
+// exist or is already revoked.
+func (r *APIKeysRepository) Revoke(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const areaSubscriptionColumns = `id, user_id, name, ST_Y(center::geometry), ST_X(center::geometry), radius_m,
+	ST_AsGeoJSON(area::geometry)::json, channels, language, active, created_at, updated_at`
+
+type AreaSubscriptionsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAreaSubscriptionsRepository(db *pgxpool.Pool) *AreaSubscriptionsRepository {
+	return &AreaSubscriptionsRepository{db: db}
+}
+
+func scanAreaSubscription(row pgx.Row) (*models.AreaSubscription, error) {
+	sub := &models.AreaSubscription{}
+	var latitude, longitude *float64
+	err := row.Scan(&sub.ID, &sub.UserID, &sub.Name, &latitude, &longitude, &sub.Radius,
+		&sub.Area, &sub.Channels, &sub.Language, &sub.Active, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if latitude != nil && longitude != nil {
+		sub.Center = &models.Point{Latitude: *latitude, Longitude: *longitude}
+	}
+	return sub, nil
+}
+
+func (r *AreaSubscriptionsRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]*models.AreaSubscription, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*models.AreaSubscription{}
+	for rows.Next() {
+		sub, err := scanAreaSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// centerParams returns the longitude and latitude of an optional center.
+func centerParams(center *models.Point) (*float64, *float64) {
+	if center == nil {
+		return nil, nil
+	}
+	return &center.Longitude, &center.Latitude
+}
+
+func (r *AreaSubscriptionsRepository) Create(ctx context.Context, sub *models.AreaSubscription) (*models.AreaSubscription, error) {
+	area, err := regionParam(sub.Area)
+	if err != nil {
+		return nil, err
+	}
+	longitude, latitude := centerParams(sub.Center)
+
+	return scanAreaSubscription(r.db.QueryRow(ctx,
+		`INSERT INTO area_subscriptions (user_id, name, center, radius_m, area, channels, language, active)
+		 VALUES ($1, $2, ST_SetSRID(ST_MakePoint($3, $4), 4326)::geography, $5,
+		         ST_SetSRID(ST_GeomFromGeoJSON($6), 4326)::geography, $7, $8, $9)
+		 RETURNING `+areaSubscriptionColumns,
+		sub.UserID, sub.Name, longitude, latitude, sub.Radius, area, sub.Channels, sub.Language, sub.Active,
+	))
+}
+
+func (r *AreaSubscriptionsRepository) GetByIDForUser(ctx context.Context, id, userID int) (*models.AreaSubscription, error) {
+	return scanAreaSubscription(r.db.QueryRow(ctx,
+		`SELECT `+areaSubscriptionColumns+` FROM area_subscriptions WHERE id = $1 AND user_id = $2`,
+		id, userID,
+	))
+}
+
+func (r *AreaSubscriptionsRepository) GetByUserID(ctx context.Context, userID int) ([]*models.AreaSubscription, error) {
+	return r.querySubscriptions(ctx,
+		`SELECT `+areaSubscriptionColumns+` FROM area_subscriptions WHERE user_id = $1 ORDER BY id`,
+		userID,
+	)
+}
+
+func (r *AreaSubscriptionsRepository) Update(ctx context.Context, sub *models.AreaSubscription) (*models.AreaSubscription, error) {
+	area, err := regionParam(sub.Area)
+	if err != nil {
+		return nil, err
+	}
+	longitude, latitude := centerParams(sub.Center)
+
+	return scanAreaSubscription(r.db.QueryRow(ctx,
+		`UPDATE area_subscriptions
+		 SET name = $1, center = ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, radius_m = $4,
+		     area = ST_SetSRID(ST_GeomFromGeoJSON($5), 4326)::geography, channels = $6, language = $7,
+		     active = $8, updated_at = NOW()
+		 WHERE id = $9 AND user_id = $10
+		 RETURNING `+areaSubscriptionColumns,
+		sub.Name, longitude, latitude, sub.Radius, area, sub.Channels, sub.Language, sub.Active, sub.ID, sub.UserID,
+	))
+}
+
+func (r *AreaSubscriptionsRepository) Delete(ctx context.Context, id, userID int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM area_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetActiveContaining returns active subscriptions whose area or radius
+// covers the point.
+func (r *AreaSubscriptionsRepository) GetActiveContaining(ctx context.Context, latitude, longitude float64) ([]*models.AreaSubscription, error) {
+	return r.querySubscriptions(ctx,
+		`WITH point AS (SELECT ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography AS location)
+		 SELECT `+areaSubscriptionColumns+`
+		 FROM area_subscriptions, point
+		 WHERE active
+		   AND ((center IS NOT NULL AND ST_DWithin(center, point.location, radius_m))
+		     OR (area IS NOT NULL AND ST_Covers(area, point.location)))`,
+		longitude, latitude,
+	)
+}
@@ -2,27 +2,57 @@ package repository
 
 import (
 	"context"
+	"fire-tracker/internal/events"
 	"fire-tracker/internal/models"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type CommentsRepository struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	uow *UnitOfWork
 }
 
 func NewCommentsRepository(db *pgxpool.Pool) *CommentsRepository {
-	return &CommentsRepository{db: db}
+	return &CommentsRepository{db: db, uow: NewUnitOfWork(db)}
 }
 
 func (r *CommentsRepository) Create(ctx context.Context, fireID, userID int, text string) (*models.Comment, error) {
+	comment := &models.Comment{User: &models.User{}}
+	err := r.uow.Do(ctx, func(tx *Tx) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO comments (fire_id, user_id, text)
+			 SELECT $1, $2, $3
+			 WHERE EXISTS (SELECT 1 FROM fires WHERE id = $1 AND moderation_status = 'approved')
+			 RETURNING id, fire_id, user_id, text, created_at`,
+			fireID, userID, text,
+		).Scan(&comment.ID, &comment.FireID, &comment.UserID, &comment.Text, &comment.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		return tx.RecordEvent(ctx, events.CommentAdded, comment.FireID, comment.ID, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *CommentsRepository) GetByID(ctx context.Context, id int) (*models.Comment, error) {
 	comment := &models.Comment{User: &models.User{}}
 	err := r.db.QueryRow(ctx,
-		`INSERT INTO comments (fire_id, user_id, text)
-		 VALUES ($1, $2, $3)
-		 RETURNING id, fire_id, user_id, text, created_at`,
-		fireID, userID, text,
-	).Scan(&comment.ID, &comment.FireID, &comment.UserID, &comment.Text, &comment.CreatedAt)
+		`SELECT c.id, c.fire_id, c.user_id, c.text, c.created_at,
+		        u.id, u.name, u.role, u.created_at
+		 FROM comments c
+		 LEFT JOIN users u ON c.user_id = u.id
+		 WHERE c.id = $1`,
+		id,
+	).Scan(
+		&comment.ID, &comment.FireID, &comment.UserID, &comment.Text, &comment.CreatedAt,
+		&comment.User.ID, &comment.User.Name, &comment.User.Role, &comment.User.CreatedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -34,6 +64,7 @@ func (r *CommentsRepository) GetByFireID(ctx context.Context, fireID int) ([]*mo
 		`SELECT c.id, c.fire_id, c.user_id, c.text, c.created_at,
 		        u.id, u.name, u.role, u.created_at
 		 FROM comments c
+		 JOIN fires f ON c.fire_id = f.id AND f.moderation_status = 'approved'
 		 LEFT JOIN users u ON c.user_id = u.id
 		 WHERE c.fire_id = $1
 		 ORDER BY c.created_at ASC`,
@@ -59,3 +90,55 @@ func (r *CommentsRepository) GetByFireID(ctx context.Context, fireID int) ([]*mo
 
 	return comments, nil
 }
+
+// GetByUserID returns a user's comments, newest first, and how many there
+// are in total.
+func (r *CommentsRepository) GetByUserID(ctx context.Context, userID, limit, offset int) ([]*models.Comment, int, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT id, fire_id, user_id, text, created_at
+		 FROM comments
+		 WHERE user_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	comments := []*models.Comment{}
+	for rows.Next() {
+		comment := &models.Comment{}
+		if err := rows.Scan(&comment.ID, &comment.FireID, &comment.UserID, &comment.Text, &comment.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM comments WHERE user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return comments, total, nil
+}
+
+// Delete removes a comment from a fire and announces the deletion.
+func (r *CommentsRepository) Delete(ctx context.Context, fireID, id int) error {
+	return r.uow.Do(ctx, func(tx *Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM comments WHERE id = $1 AND fire_id = $2`, id, fireID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+
+		return tx.RecordEvent(ctx, events.CommentDeleted, fireID, id, events.DeletedComment{ID: id, FireID: fireID})
+	})
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/models"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const evacuationZoneColumns = `id, fire_id, created_by, ST_AsGeoJSON(area::geometry)::json, level, message, expires_at, created_at, updated_at`
+
+type EvacuationZonesRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEvacuationZonesRepository(db *pgxpool.Pool) *EvacuationZonesRepository {
+	return &EvacuationZonesRepository{db: db}
+}
+
+func scanEvacuationZone(row pgx.Row) (*models.EvacuationZone, error) {
+	zone := &models.EvacuationZone{}
+	err := row.Scan(&zone.ID, &zone.FireID, &zone.CreatedBy, &zone.Area, &zone.Level,
+		&zone.Message, &zone.ExpiresAt, &zone.CreatedAt, &zone.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (r *EvacuationZonesRepository) Create(ctx context.Context, zone *models.EvacuationZone) (*models.EvacuationZone, error) {
+	area, err := json.Marshal(zone.Area)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvacuationZone(r.db.QueryRow(ctx,
+		`INSERT INTO evacuation_zones (fire_id, created_by, area, level, message, expires_at)
+		 VALUES ($1, $2, ST_SetSRID(ST_GeomFromGeoJSON($3), 4326)::geography, $4, $5, $6)
+		 RETURNING `+evacuationZoneColumns,
+		zone.FireID, zone.CreatedBy, string(area), zone.Level, zone.Message, zone.ExpiresAt,
+	))
+}
+
+func (r *EvacuationZonesRepository) GetByID(ctx context.Context, id int) (*models.EvacuationZone, error) {
+	return scanEvacuationZone(r.db.QueryRow(ctx,
+		`SELECT `+evacuationZoneColumns+` FROM evacuation_zones WHERE id = $1`,
+		id,
+	))
+}
+
+// GetActive returns zones that have not expired, optionally limited to a
+// single fire when fireID is non-zero.
+func (r *EvacuationZonesRepository) GetActive(ctx context.Context, fireID int) ([]*models.EvacuationZone, error) {
+	query := `SELECT ` + evacuationZoneColumns + `
+		FROM evacuation_zones
+		WHERE (expires_at IS NULL OR expires_at > NOW())`
+	args := []interface{}{}
+
+	if fireID != 0 {
+		query += fmt.Sprintf(" AND fire_id = $%d", len(args)+1)
+		args = append(args, fireID)
+	}
+	query += " ORDER BY created_at DESC"
+
+	return r.queryZones(ctx, query, args...)
+}
+
+// GetActiveContaining returns the active zones that contain the point, most
+// severe level first.
+func (r *EvacuationZonesRepository) GetActiveContaining(ctx context.Context, latitude, longitude float64) ([]*models.EvacuationZone, error) {
+	return r.queryZones(ctx,
+		`SELECT `+evacuationZoneColumns+`
+		 FROM evacuation_zones
+		 WHERE (expires_at IS NULL OR expires_at > NOW())
+		   AND ST_Covers(area, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography)
+		 ORDER BY CASE level WHEN 'evacuate' THEN 0 ELSE 1 END, created_at DESC`,
+		longitude, latitude,
+	)
+}
+
+func (r *EvacuationZonesRepository) Update(ctx context.Context, zone *models.EvacuationZone) (*models.EvacuationZone, error) {
+	area, err := json.Marshal(zone.Area)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvacuationZone(r.db.QueryRow(ctx,
+		`UPDATE evacuation_zones
+		 SET fire_id = $1, area = ST_SetSRID(ST_GeomFromGeoJSON($2), 4326)::geography,
+		     level = $3, message = $4, expires_at = $5, updated_at = NOW()
+		 WHERE id = $6
+		 RETURNING `+evacuationZoneColumns,
+		zone.FireID, string(area), zone.Level, zone.Message, zone.ExpiresAt, zone.ID,
+	))
+}
+
+func (r *EvacuationZonesRepository) Delete(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM evacuation_zones WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *EvacuationZonesRepository) queryZones(ctx context.Context, query string, args ...interface{}) ([]*models.EvacuationZone, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := []*models.EvacuationZone{}
+	for rows.Next() {
+		zone, err := scanEvacuationZone(rows)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+
+	return zones, rows.Err()
+}
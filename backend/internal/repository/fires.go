@@ -2,55 +2,143 @@ package repository
 
 import (
 	"context"
+	"fire-tracker/internal/events"
 	"fire-tracker/internal/models"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// fireColumns expects the fires table to be aliased f.
+const fireColumns = `f.id, f.reporter_id, f.anonymous, ST_Y(f.location::geometry) as latitude, ST_X(f.location::geometry) as longitude,
+	f.description, f.status, f.organisation_id, f.merged_into_id, f.moderation_status, f.verdict, f.verdict_at, f.created_at, f.updated_at`
+
+// fireReporterColumns go with a LEFT JOIN of users aliased u.
+const fireReporterColumns = `u.id, u.name, u.role, u.created_at`
+
+// FireScope limits which fires staff may manage. Staff manage the fires of
+// their organisation only; staff without one work for the default
+// organisation, which also looks after fires outside every jurisdiction.
+// All is for administrators. Reading public fires is never scoped.
+type FireScope struct {
+	All            bool
+	OrganisationID *int
+}
+
+// scopeCondition restricts fires aliased f to the scope passed as
+// parameters n (All) and n+1 (OrganisationID).
+func scopeCondition(n int) string {
+	return fmt.Sprintf("($%d::bool OR f.organisation_id = COALESCE($%d::int, %s))", n, n+1, defaultOrganisation)
+}
+
+// defaultOrganisation selects the organisation looking after fires outside
+// every jurisdiction.
+const defaultOrganisation = `(SELECT id FROM organisations WHERE is_default)`
+
+// coveringOrganisation selects the organisation responsible for a location:
+// the one with the smallest jurisdiction containing it, else the default.
+func coveringOrganisation(location string) string {
+	return `COALESCE((SELECT o.id FROM organisations o
+	                  WHERE ST_Covers(o.jurisdiction, ` + location + `)
+	                  ORDER BY ST_Area(o.jurisdiction), o.id
+	                  LIMIT 1), ` + defaultOrganisation + `)`
+}
+
 type FiresRepository struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	uow *UnitOfWork
 }
 
 func NewFiresRepository(db *pgxpool.Pool) *FiresRepository {
-	return &FiresRepository{db: db}
+	return &FiresRepository{db: db, uow: NewUnitOfWork(db)}
+}
+
+func fireDest(fire *models.Fire) []interface{} {
+	return []interface{}{
+		&fire.ID, &fire.ReporterID, &fire.Anonymous, &fire.Latitude, &fire.Longitude,
+		&fire.Description, &fire.Status, &fire.OrganisationID, &fire.MergedIntoID, &fire.ModerationStatus, &fire.Verdict, &fire.VerdictAt,
+		&fire.CreatedAt, &fire.UpdatedAt,
+	}
+}
+
+func scanFire(row pgx.Row) (*models.Fire, error) {
+	fire := &models.Fire{}
+	if err := row.Scan(fireDest(fire)...); err != nil {
+		return nil, err
+	}
+	return fire, nil
 }
 
-func (r *FiresRepository) Create(ctx context.Context, reporterID int, latitude, longitude float64, description string) (*models.Fire, error) {
+// scanFireWithReporter scans fireColumns followed by fireReporterColumns.
+func scanFireWithReporter(row pgx.Row) (*models.Fire, error) {
 	fire := &models.Fire{}
-	err := r.db.QueryRow(ctx,
-		`INSERT INTO fires (reporter_id, location, description, status)
-		 VALUES ($1, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, $4, 'reported')
-		 RETURNING id, reporter_id, ST_Y(location::geometry) as latitude, ST_X(location::geometry) as longitude,
-		           description, status, created_at, updated_at`,
-		reporterID, longitude, latitude, description,
-	).Scan(&fire.ID, &fire.ReporterID, &fire.Latitude, &fire.Longitude,
-		&fire.Description, &fire.Status, &fire.CreatedAt, &fire.UpdatedAt)
+	var reporter fireReporter
+	if err := row.Scan(append(fireDest(fire), reporter.dest()...)...); err != nil {
+		return nil, err
+	}
+	fire.Reporter = reporter.user()
+	return fire, nil
+}
+
+// Create records a report. A nil reporterID records an anonymous report.
+// The fire is assigned to the organisation with the smallest jurisdiction
+// containing it, or to the default organisation. Pending reports wait in the moderation queue and
+// are only announced once approved.
+func (r *FiresRepository) Create(ctx context.Context, reporterID *int, latitude, longitude float64, description string, pending bool) (*models.Fire, error) {
+	var fire *models.Fire
+	err := r.uow.Do(ctx, func(tx *Tx) error {
+		var err error
+		fire, err = scanFire(tx.QueryRow(ctx,
+			`INSERT INTO fires AS f (reporter_id, anonymous, location, description, status, moderation_status, organisation_id)
+			 VALUES ($1, $1::int IS NULL, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, $4, 'reported',
+			         CASE WHEN $5 THEN 'pending' ELSE 'approved' END,
+			         `+coveringOrganisation(`ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography`)+`)
+			 RETURNING `+fireColumns,
+			reporterID, longitude, latitude, description, pending,
+		))
+		if err != nil {
+			return err
+		}
+		if pending {
+			return nil
+		}
+
+		return tx.RecordEvent(ctx, events.FireCreated, fire.ID, 0, fire)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return fire, nil
 }
 
-func (r *FiresRepository) GetAll(ctx context.Context, status string, limit, offset int) ([]*models.Fire, int, error) {
+// GetAll lists public fires, which excludes reports awaiting or refused by
+// moderation, optionally only those of one organisation.
+func (r *FiresRepository) GetAll(ctx context.Context, status string, organisationID *int, limit, offset int) ([]*models.Fire, int, error) {
 	query := `
-		SELECT f.id, f.reporter_id, ST_Y(f.location::geometry) as latitude, ST_X(f.location::geometry) as longitude,
-		       f.description, f.status, f.created_at, f.updated_at,
-		       u.id, u.name, u.role, u.created_at
+		SELECT ` + fireColumns + `, ` + fireReporterColumns + `
 		FROM fires f
 		LEFT JOIN users u ON f.reporter_id = u.id
+		WHERE f.moderation_status = 'approved'
 	`
-	countQuery := `SELECT COUNT(*) FROM fires`
+	countQuery := `SELECT COUNT(*) FROM fires WHERE moderation_status = 'approved'`
 
 	args := []interface{}{}
 	argIndex := 1
 
 	if status != "" {
-		query += fmt.Sprintf(" WHERE f.status = $%d", argIndex)
-		countQuery += fmt.Sprintf(" WHERE status = $%d", argIndex)
+		query += fmt.Sprintf(" AND f.status = $%d", argIndex)
+		countQuery += fmt.Sprintf(" AND status = $%d", argIndex)
 		args = append(args, status)
 		argIndex++
 	}
+	if organisationID != nil {
+		query += fmt.Sprintf(" AND f.organisation_id = $%d", argIndex)
+		countQuery += fmt.Sprintf(" AND organisation_id = $%d", argIndex)
+		args = append(args, *organisationID)
+		argIndex++
+	}
 
 	query += " ORDER BY f.created_at DESC"
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
@@ -64,12 +152,7 @@ func (r *FiresRepository) GetAll(ctx context.Context, status string, limit, offs
 
 	fires := []*models.Fire{}
 	for rows.Next() {
-		fire := &models.Fire{Reporter: &models.User{}}
-		err := rows.Scan(
-			&fire.ID, &fire.ReporterID, &fire.Latitude, &fire.Longitude,
-			&fire.Description, &fire.Status, &fire.CreatedAt, &fire.UpdatedAt,
-			&fire.Reporter.ID, &fire.Reporter.Name, &fire.Reporter.Role, &fire.Reporter.CreatedAt,
-		)
+		fire, err := scanFireWithReporter(rows)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -86,40 +169,366 @@ func (r *FiresRepository) GetAll(ctx context.Context, status string, limit, offs
 	return fires, total, nil
 }
 
+// GetByID returns a fire whatever its moderation status. It is for
+// internal consumers; public reads go through GetApproved.
 func (r *FiresRepository) GetByID(ctx context.Context, id int) (*models.Fire, error) {
-	fire := &models.Fire{Reporter: &models.User{}}
-	err := r.db.QueryRow(ctx,
-		`SELECT f.id, f.reporter_id, ST_Y(f.location::geometry) as latitude, ST_X(f.location::geometry) as longitude,
-		        f.description, f.status, f.created_at, f.updated_at,
-		        u.id, u.name, u.role, u.created_at
+	return scanFireWithReporter(r.db.QueryRow(ctx,
+		`SELECT `+fireColumns+`, `+fireReporterColumns+`
 		 FROM fires f
 		 LEFT JOIN users u ON f.reporter_id = u.id
 		 WHERE f.id = $1`,
 		id,
-	).Scan(
-		&fire.ID, &fire.ReporterID, &fire.Latitude, &fire.Longitude,
-		&fire.Description, &fire.Status, &fire.CreatedAt, &fire.UpdatedAt,
-		&fire.Reporter.ID, &fire.Reporter.Name, &fire.Reporter.Role, &fire.Reporter.CreatedAt,
+	))
+}
+
+// GetApproved returns a fire published by moderation. Pending and rejected
+// reports return pgx.ErrNoRows.
+func (r *FiresRepository) GetApproved(ctx context.Context, id int) (*models.Fire, error) {
+	return scanFireWithReporter(r.db.QueryRow(ctx,
+		`SELECT `+fireColumns+`, `+fireReporterColumns+`
+		 FROM fires f
+		 LEFT JOIN users u ON f.reporter_id = u.id
+		 WHERE f.id = $1 AND f.moderation_status = 'approved'`,
+		id,
+	))
+}
+
+// GetInScope returns a fire in scope whatever its moderation status, so
+// moderators can look at reports before deciding on them. It returns
+// pgx.ErrNoRows for unknown fires and fires outside scope.
+func (r *FiresRepository) GetInScope(ctx context.Context, scope FireScope, id int) (*models.Fire, error) {
+	return scanFireWithReporter(r.db.QueryRow(ctx,
+		`SELECT `+fireColumns+`, `+fireReporterColumns+`
+		 FROM fires f
+		 LEFT JOIN users u ON f.reporter_id = u.id
+		 WHERE f.id = $1 AND `+scopeCondition(2),
+		id, scope.All, scope.OrganisationID,
+	))
+}
+
+// GetByReporter returns a user's reports, newest first, and how many there
+// are in total.
+func (r *FiresRepository) GetByReporter(ctx context.Context, reporterID, limit, offset int) ([]*models.Fire, int, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+fireColumns+`
+		 FROM fires f
+		 WHERE f.reporter_id = $1
+		 ORDER BY f.created_at DESC
+		 LIMIT $2 OFFSET $3`,
+		reporterID, limit, offset,
 	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	fires := []*models.Fire{}
+	for rows.Next() {
+		fire, err := scanFire(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		fires = append(fires, fire)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM fires WHERE reporter_id = $1`, reporterID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return fires, total, nil
+}
+
+// GetLatestOpenByReporter returns the reporter's most recent fire that is
+// not closed and was reported after since. Reports awaiting moderation are
+// included; rejected ones are not.
+func (r *FiresRepository) GetLatestOpenByReporter(ctx context.Context, reporterID int, since time.Time) (*models.Fire, error) {
+	return scanFire(r.db.QueryRow(ctx,
+		`SELECT `+fireColumns+`
+		 FROM fires f
+		 WHERE f.reporter_id = $1 AND f.status != 'closed' AND f.moderation_status != 'rejected' AND f.created_at > $2
+		 ORDER BY f.created_at DESC
+		 LIMIT 1`,
+		reporterID, since,
+	))
+}
+
+// UpdateStatus changes the status of an approved fire. Marking a fire seen
+// confirms the report unless a verdict was already given. It returns
+// pgx.ErrNoRows for unknown fires, fires outside scope and fires not
+// approved by moderation.
+func (r *FiresRepository) UpdateStatus(ctx context.Context, scope FireScope, id int, status string) (*models.Fire, error) {
+	var fire *models.Fire
+	err := r.uow.Do(ctx, func(tx *Tx) error {
+		var err error
+		fire, err = scanFire(tx.QueryRow(ctx,
+			`UPDATE fires f
+			 SET status = $1, updated_at = NOW(),
+			     verdict = CASE WHEN $1 = 'seen' THEN COALESCE(verdict, 'confirmed') ELSE verdict END,
+			     verdict_at = CASE WHEN $1 = 'seen' AND verdict IS NULL THEN NOW() ELSE verdict_at END
+			 WHERE id = $2 AND moderation_status = 'approved' AND `+scopeCondition(3)+`
+			 RETURNING `+fireColumns,
+			status, id, scope.All, scope.OrganisationID,
+		))
+		if err != nil {
+			return err
+		}
+
+		return tx.RecordEvent(ctx, events.FireStatusChanged, fire.ID, 0, fire)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return fire, nil
 }
 
-func (r *FiresRepository) UpdateStatus(ctx context.Context, id int, status string) (*models.Fire, error) {
-	fire := &models.Fire{}
-	err := r.db.QueryRow(ctx,
-		`UPDATE fires
-		 SET status = $1, updated_at = NOW()
-		 WHERE id = $2
-		 RETURNING id, reporter_id, ST_Y(location::geometry) as latitude, ST_X(location::geometry) as longitude,
-		           description, status, created_at, updated_at`,
-		status, id,
-	).Scan(&fire.ID, &fire.ReporterID, &fire.Latitude, &fire.Longitude,
-		&fire.Description, &fire.Status, &fire.CreatedAt, &fire.UpdatedAt)
+// ListPendingModeration returns reports in scope awaiting moderation,
+// oldest first, and how many there are in total.
+func (r *FiresRepository) ListPendingModeration(ctx context.Context, scope FireScope, limit, offset int) ([]*models.Fire, int, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+fireColumns+`, `+fireReporterColumns+`
+		 FROM fires f
+		 LEFT JOIN users u ON f.reporter_id = u.id
+		 WHERE f.moderation_status = 'pending' AND `+scopeCondition(1)+`
+		 ORDER BY f.created_at
+		 LIMIT $3 OFFSET $4`,
+		scope.All, scope.OrganisationID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	fires := []*models.Fire{}
+	for rows.Next() {
+		fire, err := scanFireWithReporter(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		fires = append(fires, fire)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM fires f WHERE f.moderation_status = 'pending' AND `+scopeCondition(1),
+		scope.All, scope.OrganisationID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return fires, total, nil
+}
+
+// Approve publishes a pending report, announcing it as newly created. It
+// returns pgx.ErrNoRows unless the fire is pending and in scope.
+func (r *FiresRepository) Approve(ctx context.Context, scope FireScope, id, moderatorID int) (*models.Fire, error) {
+	var fire *models.Fire
+	err := r.uow.Do(ctx, func(tx *Tx) error {
+		var err error
+		fire, err = scanFire(tx.QueryRow(ctx,
+			`UPDATE fires f
+			 SET moderation_status = 'approved', moderated_by = $2, moderated_at = NOW(), updated_at = NOW()
+			 WHERE id = $1 AND moderation_status = 'pending' AND `+scopeCondition(3)+`
+			 RETURNING `+fireColumns,
+			id, moderatorID, scope.All, scope.OrganisationID,
+		))
+		if err != nil {
+			return err
+		}
+
+		return tx.RecordEvent(ctx, events.FireCreated, fire.ID, 0, fire)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fire, nil
+}
+
+// Reject refuses a pending report and records verdict against it. It
+// returns pgx.ErrNoRows unless the fire is pending and in scope.
+func (r *FiresRepository) Reject(ctx context.Context, scope FireScope, id, moderatorID int, verdict string, note *string) (*models.Fire, error) {
+	return scanFire(r.db.QueryRow(ctx,
+		`UPDATE fires f
+		 SET moderation_status = 'rejected', moderated_by = $2, moderated_at = NOW(), updated_at = NOW(),
+		     verdict = $3, verdict_note = $4, verdict_by = $2, verdict_at = NOW()
+		 WHERE id = $1 AND moderation_status = 'pending' AND `+scopeCondition(5)+`
+		 RETURNING `+fireColumns,
+		id, moderatorID, verdict, note, scope.All, scope.OrganisationID,
+	))
+}
+
+// SetVerdict records whether a report turned out to be real. It returns
+// pgx.ErrNoRows for unknown fires and fires outside scope.
+func (r *FiresRepository) SetVerdict(ctx context.Context, scope FireScope, id, userID int, verdict string, note *string) (*models.Fire, error) {
+	return scanFire(r.db.QueryRow(ctx,
+		`UPDATE fires f
+		 SET verdict = $3, verdict_note = $4, verdict_by = $2, verdict_at = NOW(), updated_at = NOW()
+		 WHERE id = $1 AND `+scopeCondition(5)+`
+		 RETURNING `+fireColumns,
+		id, userID, verdict, note, scope.All, scope.OrganisationID,
+	))
+}
+
+// Transfer hands a fire in scope over to another organisation, or to the
+// default organisation when organisationID is nil. Transfers of
+// approved fires are announced. It returns pgx.ErrNoRows for unknown fires
+// and fires outside scope.
+func (r *FiresRepository) Transfer(ctx context.Context, scope FireScope, id int, organisationID *int) (*models.Fire, error) {
+	var fire *models.Fire
+	err := r.uow.Do(ctx, func(tx *Tx) error {
+		var err error
+		fire, err = scanFire(tx.QueryRow(ctx,
+			`UPDATE fires f
+			 SET organisation_id = COALESCE($2::int, `+defaultOrganisation+`), updated_at = NOW()
+			 WHERE id = $1 AND `+scopeCondition(3)+`
+			 RETURNING `+fireColumns,
+			id, organisationID, scope.All, scope.OrganisationID,
+		))
+		if err != nil {
+			return err
+		}
+		if fire.ModerationStatus != "approved" {
+			return nil
+		}
+
+		return tx.RecordEvent(ctx, events.FireTransferred, fire.ID, 0, fire)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fire, nil
+}
+
+// Merge folds the duplicate report id into intoID: its comments move over,
+// fires merged into it earlier follow, and it is closed pointing at intoID.
+// Both fires must be approved, not merged themselves and in scope; it
+// returns pgx.ErrNoRows otherwise.
+func (r *FiresRepository) Merge(ctx context.Context, scope FireScope, id, intoID int) (*models.Fire, error) {
+	var fire *models.Fire
+	err := r.uow.Do(ctx, func(tx *Tx) error {
+		// Locked so it cannot be merged away in the meantime
+		var target int
+		err := tx.QueryRow(ctx,
+			`SELECT f.id FROM fires f
+			 WHERE f.id = $1 AND f.moderation_status = 'approved' AND f.merged_into_id IS NULL AND `+scopeCondition(2)+`
+			 FOR UPDATE`,
+			intoID, scope.All, scope.OrganisationID,
+		).Scan(&target)
+		if err != nil {
+			return err
+		}
+
+		fire, err = scanFire(tx.QueryRow(ctx,
+			`UPDATE fires f
+			 SET merged_into_id = $2, status = 'closed', updated_at = NOW()
+			 WHERE f.id = $1 AND f.id != $2 AND f.moderation_status = 'approved' AND f.merged_into_id IS NULL
+			   AND `+scopeCondition(3)+`
+			 RETURNING `+fireColumns,
+			id, intoID, scope.All, scope.OrganisationID,
+		))
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE comments SET fire_id = $2 WHERE fire_id = $1`, id, intoID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE fires SET merged_into_id = $2 WHERE merged_into_id = $1`, id, intoID); err != nil {
+			return err
+		}
+
+		return tx.RecordEvent(ctx, events.FireMerged, fire.ID, 0, fire)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return fire, nil
 }
+
+// GetTrust computes trust scores for the given users from the verdicts on
+// their reports. Every requested user gets a score.
+func (r *FiresRepository) GetTrust(ctx context.Context, userIDs []int) (map[int]*models.TrustScore, error) {
+	scores := make(map[int]*models.TrustScore, len(userIDs))
+	for _, id := range userIDs {
+		scores[id] = newTrustScore(0, 0, 0, 0)
+	}
+	if len(userIDs) == 0 {
+		return scores, nil
+	}
+
+	rows, err := r.db.Query(ctx,
+		`SELECT reporter_id, COUNT(*),
+		        COUNT(*) FILTER (WHERE verdict = 'confirmed'),
+		        COUNT(*) FILTER (WHERE verdict = 'false'),
+		        COUNT(*) FILTER (WHERE verdict = 'prank')
+		 FROM fires
+		 WHERE reporter_id = ANY($1)
+		 GROUP BY reporter_id`,
+		userIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, reports, confirmed, falseReports, pranks int
+		if err := rows.Scan(&id, &reports, &confirmed, &falseReports, &pranks); err != nil {
+			return nil, err
+		}
+		scores[id] = newTrustScore(reports, confirmed, falseReports, pranks)
+	}
+	return scores, rows.Err()
+}
+
+// newTrustScore is the share of judged reports that were confirmed,
+// smoothed towards 0.5 so a single verdict does not swing it to either
+// end. Pranks count double.
+func newTrustScore(reports, confirmed, falseReports, pranks int) *models.TrustScore {
+	score := float64(confirmed+1) / float64(confirmed+falseReports+2*pranks+2)
+	return &models.TrustScore{
+		Score:     score,
+		Reports:   reports,
+		Confirmed: confirmed,
+		False:     falseReports,
+		Pranks:    pranks,
+	}
+}
+
+// fireReporter receives the joined reporter columns, which are NULL for
+// anonymous reports.
+type fireReporter struct {
+	id        *int
+	name      *string
+	role      *string
+	createdAt *time.Time
+}
+
+func (r *fireReporter) dest() []interface{} {
+	return []interface{}{&r.id, &r.name, &r.role, &r.createdAt}
+}
+
+func (r *fireReporter) user() *models.User {
+	if r.id == nil {
+		return nil
+	}
+	user := &models.User{ID: *r.id}
+	if r.name != nil {
+		user.Name = *r.name
+	}
+	if r.role != nil {
+		user.Role = *r.role
+	}
+	if r.createdAt != nil {
+		user.CreatedAt = *r.createdAt
+	}
+	return user
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/testdb"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// outboxEvents returns the event types recorded for a fire, oldest first.
+func outboxEvents(t *testing.T, db *pgxpool.Pool, fireID int) []string {
+	t.Helper()
+	rows, err := db.Query(context.Background(), `SELECT event_type FROM outbox WHERE fire_id = $1 ORDER BY id`, fireID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types
+}
+
+func TestFiresMerge(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	firesRepo := NewFiresRepository(db)
+	commentsRepo := NewCommentsRepository(db)
+
+	user, err := NewUsersRepository(db).Create(ctx, "reporter", "Reporter", nil, "citizen", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := firesRepo.Create(ctx, &user.ID, 38.0, 23.7, "Smoke", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicate, err := firesRepo.Create(ctx, &user.ID, 38.001, 23.701, "Smoke over the hill", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment, err := commentsRepo.Create(ctx, duplicate.ID, user.ID, "Getting bigger")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Staff without an organisation manage no fires
+	if _, err := firesRepo.Merge(ctx, FireScope{}, duplicate.ID, original.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("merge outside scope: got %v, want pgx.ErrNoRows", err)
+	}
+
+	merged, err := firesRepo.Merge(ctx, FireScope{All: true}, duplicate.ID, original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Status != "closed" || merged.MergedIntoID == nil || *merged.MergedIntoID != original.ID {
+		t.Errorf("merged fire %+v, want closed and pointing at %d", merged, original.ID)
+	}
+	if moved, err := commentsRepo.GetByID(ctx, comment.ID); err != nil || moved.FireID != original.ID {
+		t.Errorf("comment on fire %v (%v), want it moved to %d", moved, err, original.ID)
+	}
+	if got := outboxEvents(t, db, duplicate.ID); len(got) != 2 || got[1] != events.FireMerged {
+		t.Errorf("recorded events %v, want %s last", got, events.FireMerged)
+	}
+
+	// A merged fire can neither be merged again nor receive merges
+	if _, err := firesRepo.Merge(ctx, FireScope{All: true}, duplicate.ID, original.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("merging twice: got %v, want pgx.ErrNoRows", err)
+	}
+	if _, err := firesRepo.Merge(ctx, FireScope{All: true}, original.ID, duplicate.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("merging into a merged fire: got %v, want pgx.ErrNoRows", err)
+	}
+}
+
+func TestFiresTransferRecordsEvent(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	firesRepo := NewFiresRepository(db)
+
+	organisation, err := NewOrganisationsRepository(db).Create(ctx, "Forestry", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fire, err := firesRepo.Create(ctx, nil, 38.0, 23.7, "Smoke", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := firesRepo.Create(ctx, nil, 38.0, 23.7, "Smoke", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transferred, err := firesRepo.Transfer(ctx, FireScope{All: true}, fire.ID, &organisation.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transferred.OrganisationID == nil || *transferred.OrganisationID != organisation.ID {
+		t.Errorf("fire belongs to %v, want %d", transferred.OrganisationID, organisation.ID)
+	}
+	if got := outboxEvents(t, db, fire.ID); len(got) != 2 || got[1] != events.FireTransferred {
+		t.Errorf("recorded events %v, want %s last", got, events.FireTransferred)
+	}
+
+	// Reports still waiting for moderation are not announced
+	if _, err := firesRepo.Transfer(ctx, FireScope{All: true}, pending.ID, &organisation.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := outboxEvents(t, db, pending.ID); len(got) != 0 {
+		t.Errorf("pending report recorded events %v", got)
+	}
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const identityColumns = `id, user_id, issuer, subject, email, groups, granted_role, created_at, last_login_at`
+
+type IdentitiesRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdentitiesRepository(db *pgxpool.Pool) *IdentitiesRepository {
+	return &IdentitiesRepository{db: db}
+}
+
+func scanIdentity(row pgx.Row) (*models.UserIdentity, error) {
+	identity := &models.UserIdentity{}
+	err := row.Scan(&identity.ID, &identity.UserID, &identity.Issuer, &identity.Subject, &identity.Email,
+		&identity.Groups, &identity.GrantedRole, &identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (r *IdentitiesRepository) GetByIssuerSubject(ctx context.Context, issuer, subject string) (*models.UserIdentity, error) {
+	return scanIdentity(r.db.QueryRow(ctx,
+		`SELECT `+identityColumns+` FROM user_identities WHERE issuer = $1 AND subject = $2`,
+		issuer, subject,
+	))
+}
+
+// Link attaches a provider account to an existing user.
+func (r *IdentitiesRepository) Link(ctx context.Context, userID int, issuer, subject string, email *string, groups []string) (*models.UserIdentity, error) {
+	return scanIdentity(r.db.QueryRow(ctx,
+		`INSERT INTO user_identities (user_id, issuer, subject, email, groups)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING `+identityColumns,
+		userID, issuer, subject, email, groups,
+	))
+}
+
+// CreateUser creates a passwordless user for a provider account and links
+// the two. It fails with a unique violation if the username is taken.
+func (r *IdentitiesRepository) CreateUser(ctx context.Context, username, name string, email *string, issuer, subject string, groups []string) (*models.User, *models.UserIdentity, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := scanUser(tx.QueryRow(ctx,
+		`INSERT INTO users (username, name, email, email_verified_at, role)
+		 VALUES ($1, $2, $3::varchar, CASE WHEN $3::varchar IS NULL THEN NULL ELSE NOW() END, 'citizen')
+		 RETURNING `+userColumns,
+		username, name, email,
+	))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	identity, err := scanIdentity(tx.QueryRow(ctx,
+		`INSERT INTO user_identities (user_id, issuer, subject, email, groups)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING `+identityColumns,
+		user.ID, issuer, subject, email, groups,
+	))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return user, identity, nil
+}
+
+// RecordLogin stores what the provider said about the account at the
+// latest sign-in.
+func (r *IdentitiesRepository) RecordLogin(ctx context.Context, id int, email *string, groups []string, grantedRole bool) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE user_identities SET email = $2, groups = $3, granted_role = $4, last_login_at = NOW()
+		 WHERE id = $1`,
+		id, email, groups, grantedRole,
+	)
+	return err
+}
+
+// CreateLoginState remembers a login sent to the provider. Expired states
+// are cleared out at the same time.
+func (r *IdentitiesRepository) CreateLoginState(ctx context.Context, state *models.OIDCLoginState, expiresAt time.Time) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO oidc_login_states (state, nonce, code_verifier, return_to, link_user_id, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		state.State, state.Nonce, state.CodeVerifier, state.ReturnTo, state.LinkUserID, expiresAt,
+	)
+	return err
+}
+
+// ConsumeLoginState returns and deletes a pending login. It returns
+// pgx.ErrNoRows if the state is unknown, used or expired.
+func (r *IdentitiesRepository) ConsumeLoginState(ctx context.Context, state string) (*models.OIDCLoginState, error) {
+	s := &models.OIDCLoginState{}
+	err := r.db.QueryRow(ctx,
+		`DELETE FROM oidc_login_states WHERE state = $1 AND expires_at > NOW()
+		 RETURNING state, nonce, code_verifier, return_to, link_user_id`,
+		state,
+	).Scan(&s.State, &s.Nonce, &s.CodeVerifier, &s.ReturnTo, &s.LinkUserID)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CreatePendingLink holds a provider account until the user confirms the
+// link. Expired pending links are cleared out at the same time.
+func (r *IdentitiesRepository) CreatePendingLink(ctx context.Context, codeHash string, link *models.OIDCPendingLink, expiresAt time.Time) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM oidc_pending_links WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO oidc_pending_links (code_hash, user_id, issuer, subject, email, groups, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		codeHash, link.UserID, link.Issuer, link.Subject, link.Email, link.Groups, expiresAt,
+	)
+	return err
+}
+
+// ConsumePendingLink returns and deletes a pending link started by userID.
+// It returns pgx.ErrNoRows if the code is unknown, used, expired or was
+// started by someone else.
+func (r *IdentitiesRepository) ConsumePendingLink(ctx context.Context, codeHash string, userID int) (*models.OIDCPendingLink, error) {
+	link := &models.OIDCPendingLink{}
+	err := r.db.QueryRow(ctx,
+		`DELETE FROM oidc_pending_links WHERE code_hash = $1 AND user_id = $2 AND expires_at > NOW()
+		 RETURNING user_id, issuer, subject, email, groups`,
+		codeHash, userID,
+	).Scan(&link.UserID, &link.Issuer, &link.Subject, &link.Email, &link.Groups)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrLockLost is returned by Check when the connection holding the lock
+// failed, which means Postgres has already released the lock.
+var ErrLockLost = errors.New("advisory lock lost")
+
+// LeaderLock is a session-level Postgres advisory lock. At most one process
+// holds a given lock at a time; it is held on a dedicated pool connection
+// and released automatically if that connection dies.
+type LeaderLock struct {
+	db   *pgxpool.Pool
+	name string
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+}
+
+func NewLeaderLock(db *pgxpool.Pool, name string) *LeaderLock {
+	return &LeaderLock{db: db, name: name}
+}
+
+// TryAcquire takes the lock without waiting and reports whether this
+// process holds it. Calling it while already holding the lock returns true.
+func (l *LeaderLock) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		return true, nil
+	}
+
+	conn, err := l.db.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, l.name).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Release()
+		return false, err
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Check verifies the lock is still held. On ErrLockLost the lock has been
+// dropped and TryAcquire must be called again.
+func (l *LeaderLock) Check(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return ErrLockLost
+	}
+	if err := l.conn.Ping(ctx); err != nil {
+		// Closing the connection guarantees the lock is gone, so another
+		// instance can take over instead of waiting on a half-dead session.
+		l.conn.Conn().Close(context.Background())
+		l.conn.Release()
+		l.conn = nil
+		return errors.Join(ErrLockLost, err)
+	}
+	return nil
+}
+
+// Release gives up the lock if held.
+func (l *LeaderLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+	_, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, l.name)
+	if err != nil {
+		l.conn.Conn().Close(context.Background())
+	}
+	l.conn.Release()
+	l.conn = nil
+	return err
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const notificationColumns = `id, user_id, subscription_id, fire_id, event_type, fire_status, channel, address,
+	language, status, attempts, last_error, next_attempt_at, sent_at, created_at`
+
+type NotificationsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationsRepository(db *pgxpool.Pool) *NotificationsRepository {
+	return &NotificationsRepository{db: db}
+}
+
+func scanNotification(row pgx.Row) (*models.Notification, error) {
+	n := &models.Notification{}
+	err := row.Scan(&n.ID, &n.UserID, &n.SubscriptionID, &n.FireID, &n.EventType, &n.FireStatus,
+		&n.Channel, &n.Address, &n.Language, &n.Status, &n.Attempts, &n.LastError,
+		&n.NextAttemptAt, &n.SentAt, &n.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Enqueue adds a pending notification unless one with the same dedup key
+// already exists, in which case it returns nil.
+func (r *NotificationsRepository) Enqueue(ctx context.Context, n *models.Notification, dedupKey string) (*models.Notification, error) {
+	created, err := scanNotification(r.db.QueryRow(ctx,
+		`INSERT INTO notifications (user_id, subscription_id, fire_id, event_type, fire_status, channel, address, language, dedup_key)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (dedup_key) DO NOTHING
+		 RETURNING `+notificationColumns,
+		n.UserID, n.SubscriptionID, n.FireID, n.EventType, n.FireStatus, n.Channel, n.Address, n.Language, dedupKey,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ClaimDue leases up to limit pending notifications whose next attempt is
+// due and counts the attempt.
+func (r *NotificationsRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.Notification, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE notifications
+		 SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+		 WHERE id IN (
+		     SELECT id FROM notifications
+		     WHERE status = 'pending' AND next_attempt_at <= NOW()
+		     ORDER BY id
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+notificationColumns,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *NotificationsRepository) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE notifications SET status = 'sent', sent_at = NOW(), last_error = NULL WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+// MarkRetry records a failed attempt and schedules the next one.
+func (r *NotificationsRepository) MarkRetry(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE notifications SET last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+		id, lastError, nextAttemptAt,
+	)
+	return err
+}
+
+// MarkFailed records the final failed attempt; the notification is not
+// retried.
+func (r *NotificationsRepository) MarkFailed(ctx context.Context, id int64, lastError string) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE notifications SET status = 'failed', last_error = $2 WHERE id = $1`,
+		id, lastError,
+	)
+	return err
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/events"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// notifyEvent queues a change notification on the events channel. Called
+// inside the transaction that made the change so the notification is only
+// delivered if the change commits.
+func notifyEvent(ctx context.Context, tx pgx.Tx, eventType string, fireID, commentID int) error {
+	_, err := tx.Exec(ctx,
+		`SELECT pg_notify($1, json_build_object(
+		     'seq', nextval('event_seq'),
+		     'type', $2::text,
+		     'fire_id', $3::int,
+		     'comment_id', NULLIF($4::int, 0)
+		 )::text)`,
+		events.Channel, eventType, fireID, commentID,
+	)
+	return err
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const organisationColumns = `id, name, ST_AsGeoJSON(jurisdiction::geometry)::json, is_default, created_at, updated_at`
+
+// ErrDefaultOrganisation is returned when deleting the default organisation,
+// which looks after fires outside every jurisdiction.
+var ErrDefaultOrganisation = errors.New("default organisation cannot be deleted")
+
+type OrganisationsRepository struct {
+	db  *pgxpool.Pool
+	uow *UnitOfWork
+}
+
+func NewOrganisationsRepository(db *pgxpool.Pool) *OrganisationsRepository {
+	return &OrganisationsRepository{db: db, uow: NewUnitOfWork(db)}
+}
+
+func scanOrganisation(row pgx.Row) (*models.Organisation, error) {
+	org := &models.Organisation{}
+	if err := row.Scan(&org.ID, &org.Name, &org.Jurisdiction, &org.IsDefault, &org.CreatedAt, &org.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// Create adds an organisation. Names are unique regardless of case. Fires
+// of the default organisation inside the jurisdiction are handed over to
+// the organisation now responsible for them.
+func (r *OrganisationsRepository) Create(ctx context.Context, name string, jurisdiction *models.Polygon) (*models.Organisation, error) {
+	area, err := regionParam(jurisdiction)
+	if err != nil {
+		return nil, err
+	}
+
+	var org *models.Organisation
+	err = r.uow.Do(ctx, func(tx *Tx) error {
+		var err error
+		org, err = scanOrganisation(tx.QueryRow(ctx,
+			`INSERT INTO organisations (name, jurisdiction)
+			 VALUES ($1, ST_SetSRID(ST_GeomFromGeoJSON($2), 4326)::geography)
+			 RETURNING `+organisationColumns,
+			name, area,
+		))
+		if err != nil {
+			return err
+		}
+
+		return reassignFires(ctx, tx, org.ID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (r *OrganisationsRepository) GetByID(ctx context.Context, id int) (*models.Organisation, error) {
+	return scanOrganisation(r.db.QueryRow(ctx,
+		`SELECT `+organisationColumns+` FROM organisations WHERE id = $1`,
+		id,
+	))
+}
+
+func (r *OrganisationsRepository) List(ctx context.Context) ([]*models.Organisation, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+organisationColumns+` FROM organisations ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []*models.Organisation{}
+	for rows.Next() {
+		org, err := scanOrganisation(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
+// Update renames an organisation and replaces its jurisdiction. Fires it
+// got through its old jurisdiction that lie outside the new one go to the
+// organisation now responsible for them, and fires of the default
+// organisation inside the new one come to it. Fires transferred by hand
+// elsewhere keep their organisation.
+func (r *OrganisationsRepository) Update(ctx context.Context, id int, name string, jurisdiction *models.Polygon) (*models.Organisation, error) {
+	area, err := regionParam(jurisdiction)
+	if err != nil {
+		return nil, err
+	}
+
+	var org *models.Organisation
+	err = r.uow.Do(ctx, func(tx *Tx) error {
+		var released []int
+		err := tx.QueryRow(ctx,
+			`SELECT COALESCE(array_agg(f.id), '{}')
+			 FROM fires f JOIN organisations o ON o.id = f.organisation_id
+			 WHERE o.id = $1 AND ST_Covers(o.jurisdiction, f.location)
+			   AND NOT COALESCE(ST_Covers(ST_SetSRID(ST_GeomFromGeoJSON($2), 4326)::geography, f.location), FALSE)`,
+			id, area,
+		).Scan(&released)
+		if err != nil {
+			return err
+		}
+
+		org, err = scanOrganisation(tx.QueryRow(ctx,
+			`UPDATE organisations
+			 SET name = $2, jurisdiction = ST_SetSRID(ST_GeomFromGeoJSON($3), 4326)::geography, updated_at = NOW()
+			 WHERE id = $1
+			 RETURNING `+organisationColumns,
+			id, name, area,
+		))
+		if err != nil {
+			return err
+		}
+
+		return reassignFires(ctx, tx, org.ID, released)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// Delete removes an organisation. Its fires go to the organisation now
+// responsible for them. It refuses the default organisation with
+// ErrDefaultOrganisation, and organisations with members through the
+// foreign key.
+func (r *OrganisationsRepository) Delete(ctx context.Context, id int) error {
+	return r.uow.Do(ctx, func(tx *Tx) error {
+		var isDefault bool
+		err := tx.QueryRow(ctx, `DELETE FROM organisations WHERE id = $1 RETURNING is_default`, id).Scan(&isDefault)
+		if err != nil {
+			return err
+		}
+		if isDefault {
+			return ErrDefaultOrganisation
+		}
+
+		// The foreign key left its fires without an organisation
+		return reassignFires(ctx, tx, 0, nil)
+	})
+}
+
+// reassignFires hands fires over to the organisation responsible for their
+// location: fires without an organisation, fires of the default
+// organisation inside the jurisdiction of organisationID, and the released
+// fires. Approved fires that change hands are announced like transfers.
+func reassignFires(ctx context.Context, tx *Tx, organisationID int, released []int) error {
+	rows, err := tx.Query(ctx,
+		`UPDATE fires f
+		 SET organisation_id = `+coveringOrganisation(`f.location`)+`, updated_at = NOW()
+		 WHERE (f.organisation_id IS NULL
+		        OR f.id = ANY($2)
+		        OR (f.organisation_id = `+defaultOrganisation+`
+		            AND ST_Covers((SELECT jurisdiction FROM organisations WHERE id = $1), f.location)))
+		   AND f.organisation_id IS DISTINCT FROM `+coveringOrganisation(`f.location`)+`
+		 RETURNING `+fireColumns,
+		organisationID, released,
+	)
+	if err != nil {
+		return err
+	}
+	// The rows are read out before recording events on the same connection
+	var fires []*models.Fire
+	for rows.Next() {
+		fire, err := scanFire(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		fires = append(fires, fire)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, fire := range fires {
+		if fire.ModerationStatus != "approved" {
+			continue
+		}
+		if err := tx.RecordEvent(ctx, events.FireTransferred, fire.ID, 0, fire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
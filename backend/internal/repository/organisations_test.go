@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/testdb"
+	"testing"
+)
+
+func square(west, south, east, north float64) *models.Polygon {
+	return &models.Polygon{
+		Type:        "Polygon",
+		Coordinates: [][][2]float64{{{west, south}, {east, south}, {east, north}, {west, north}, {west, south}}},
+	}
+}
+
+func TestOrganisationsReassignFires(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	firesRepo := NewFiresRepository(db)
+	organisationsRepo := NewOrganisationsRepository(db)
+
+	var defaultID int
+	if err := db.QueryRow(ctx, `SELECT id FROM organisations WHERE is_default`).Scan(&defaultID); err != nil {
+		t.Fatal(err)
+	}
+
+	fire, err := firesRepo.Create(ctx, nil, 38.0, 23.7, "Smoke", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fire.OrganisationID == nil || *fire.OrganisationID != defaultID {
+		t.Fatalf("fire outside every jurisdiction belongs to %v, want the default %d", fire.OrganisationID, defaultID)
+	}
+
+	// Staff without an organisation work for the default one
+	if _, err := firesRepo.UpdateStatus(ctx, FireScope{}, fire.ID, "seen"); err != nil {
+		t.Fatalf("staff without an organisation: %v", err)
+	}
+
+	organisation, err := organisationsRepo.Create(ctx, "Forestry", square(23, 37, 24, 39))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fire, err = firesRepo.GetByID(ctx, fire.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fire.OrganisationID == nil || *fire.OrganisationID != organisation.ID {
+		t.Errorf("after creating the jurisdiction the fire belongs to %v, want %d", fire.OrganisationID, organisation.ID)
+	}
+	if got := outboxEvents(t, db, fire.ID); got[len(got)-1] != events.FireTransferred {
+		t.Errorf("recorded events %v, want %s last", got, events.FireTransferred)
+	}
+
+	if _, err := organisationsRepo.Update(ctx, organisation.ID, "Forestry", square(20, 30, 21, 31)); err != nil {
+		t.Fatal(err)
+	}
+	fire, err = firesRepo.GetByID(ctx, fire.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fire.OrganisationID == nil || *fire.OrganisationID != defaultID {
+		t.Errorf("after moving the jurisdiction the fire belongs to %v, want the default %d", fire.OrganisationID, defaultID)
+	}
+
+	if err := organisationsRepo.Delete(ctx, defaultID); !errors.Is(err, ErrDefaultOrganisation) {
+		t.Errorf("deleting the default organisation: got %v, want ErrDefaultOrganisation", err)
+	}
+}
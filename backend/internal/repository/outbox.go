@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Dispatch creates a pending delivery for each consumer for up to limit
+// undispatched outbox entries and returns how many entries it dispatched.
+func (r *OutboxRepository) Dispatch(ctx context.Context, consumers []string, limit int) (int, error) {
+	var dispatched int
+	err := NewUnitOfWork(r.db).Do(ctx, func(tx *Tx) error {
+		return tx.QueryRow(ctx,
+			`WITH batch AS (
+			     SELECT id FROM outbox
+			     WHERE dispatched_at IS NULL
+			     ORDER BY id
+			     LIMIT $2
+			     FOR UPDATE SKIP LOCKED
+			 ), deliveries AS (
+			     INSERT INTO outbox_deliveries (outbox_id, consumer)
+			     SELECT batch.id, consumer FROM batch CROSS JOIN unnest($1::text[]) AS consumer
+			     ON CONFLICT (outbox_id, consumer) DO NOTHING
+			 ), marked AS (
+			     UPDATE outbox SET dispatched_at = NOW()
+			     WHERE id IN (SELECT id FROM batch)
+			     RETURNING id
+			 )
+			 SELECT COUNT(*) FROM marked`,
+			consumers, limit,
+		).Scan(&dispatched)
+	})
+	return dispatched, err
+}
+
+// ClaimDue leases up to limit due deliveries for the given consumers so no
+// other instance picks them up until the lease expires, and counts the
+// attempt.
+func (r *OutboxRepository) ClaimDue(ctx context.Context, consumers []string, limit int, lease time.Duration) ([]*models.OutboxDelivery, error) {
+	rows, err := r.db.Query(ctx,
+		`WITH claimed AS (
+		     UPDATE outbox_deliveries
+		     SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $3)
+		     WHERE id IN (
+		         SELECT id FROM outbox_deliveries
+		         WHERE consumer = ANY($1) AND delivered_at IS NULL AND failed_at IS NULL
+		           AND next_attempt_at <= NOW()
+		         ORDER BY id
+		         LIMIT $2
+		         FOR UPDATE SKIP LOCKED
+		     )
+		     RETURNING id, outbox_id, consumer, attempts
+		 )
+		 SELECT c.id, c.consumer, c.attempts,
+		        o.id, o.event_type, o.fire_id, COALESCE(o.comment_id, 0), o.payload, o.created_at
+		 FROM claimed c
+		 JOIN outbox o ON o.id = c.outbox_id
+		 ORDER BY c.id`,
+		consumers, limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.OutboxDelivery{}
+	for rows.Next() {
+		delivery := &models.OutboxDelivery{Entry: &models.OutboxEntry{}}
+		err := rows.Scan(
+			&delivery.ID, &delivery.Consumer, &delivery.Attempts,
+			&delivery.Entry.ID, &delivery.Entry.EventType, &delivery.Entry.FireID, &delivery.Entry.CommentID,
+			&delivery.Entry.Payload, &delivery.Entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, deliveryID int64) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE outbox_deliveries SET delivered_at = NOW(), last_error = NULL WHERE id = $1`,
+		deliveryID,
+	)
+	return err
+}
+
+// MarkRetry records a failed attempt and schedules the next one.
+func (r *OutboxRepository) MarkRetry(ctx context.Context, deliveryID int64, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE outbox_deliveries SET last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+		deliveryID, lastError, nextAttemptAt,
+	)
+	return err
+}
+
+// MarkFailed records the final failed attempt; the delivery is not retried.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, deliveryID int64, lastError string) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE outbox_deliveries SET last_error = $2, failed_at = NOW() WHERE id = $1`,
+		deliveryID, lastError,
+	)
+	return err
+}
+
+// DeleteProcessed removes entries dispatched longer than retention ago whose
+// deliveries have all been delivered or given up on, along with those
+// deliveries. It returns the number of entries deleted.
+func (r *OutboxRepository) DeleteProcessed(ctx context.Context, retention time.Duration) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM outbox o
+		 WHERE o.dispatched_at < NOW() - $1::interval
+		   AND NOT EXISTS (
+		       SELECT 1 FROM outbox_deliveries d
+		       WHERE d.outbox_id = o.id AND d.delivered_at IS NULL AND d.failed_at IS NULL
+		   )`,
+		retention,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PasswordResetsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPasswordResetsRepository(db *pgxpool.Pool) *PasswordResetsRepository {
+	return &PasswordResetsRepository{db: db}
+}
+
+// Create stores a reset token hash. Earlier unused tokens for the user are
+// invalidated so only the latest link works. emailed says the link went to
+// the user's email address rather than through an administrator.
+func (r *PasswordResetsRepository) Create(ctx context.Context, userID int, tokenHash string, expiresAt time.Time, emailed bool) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, emailed) VALUES ($1, $2, $3, $4)`,
+		userID, tokenHash, expiresAt, emailed,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Consume marks an unexpired, unused token as used and returns its user and
+// whether the link was emailed. It returns pgx.ErrNoRows for unknown,
+// expired or used tokens.
+func (r *PasswordResetsRepository) Consume(ctx context.Context, tokenHash string) (int, bool, error) {
+	var userID int
+	var emailed bool
+	err := r.db.QueryRow(ctx,
+		`UPDATE password_reset_tokens SET used_at = NOW()
+		 WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		 RETURNING user_id, emailed`,
+		tokenHash,
+	).Scan(&userID, &emailed)
+	return userID, emailed, err
+}
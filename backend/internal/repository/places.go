@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PlacesRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPlacesRepository(db *pgxpool.Pool) *PlacesRepository {
+	return &PlacesRepository{db: db}
+}
+
+// FindInText returns the place whose name or alternative name appears as
+// a whole word in text, preferring the longest match so "Pano Lefkara"
+// wins over "Lefkara". It returns pgx.ErrNoRows if no place is mentioned.
+func (r *PlacesRepository) FindInText(ctx context.Context, text string) (*models.Place, error) {
+	place := &models.Place{}
+	err := r.db.QueryRow(ctx,
+		`SELECT p.id, p.name, ST_Y(p.location::geometry), ST_X(p.location::geometry)
+		 FROM places p, unnest(array_prepend(p.name::text, p.alt_names)) AS n(name)
+		 WHERE $1 ~* ('\m' || n.name || '\M')
+		 ORDER BY length(n.name) DESC, p.id
+		 LIMIT 1`,
+		text,
+	).Scan(&place.ID, &place.Name, &place.Latitude, &place.Longitude)
+	if err != nil {
+		return nil, err
+	}
+	return place, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const pushSubscriptionColumns = `id, user_id, endpoint, p256dh, auth, user_agent, created_at, updated_at`
+
+type PushSubscriptionsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPushSubscriptionsRepository(db *pgxpool.Pool) *PushSubscriptionsRepository {
+	return &PushSubscriptionsRepository{db: db}
+}
+
+func scanPushSubscription(row pgx.Row) (*models.PushSubscription, error) {
+	sub := &models.PushSubscription{}
+	err := row.Scan(&sub.ID, &sub.UserID, &sub.Endpoint, &sub.P256dh, &sub.Auth, &sub.UserAgent, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Save stores a subscription. Endpoints are unique per browser, so saving
+// an existing endpoint of userID's replaces its keys. An endpoint registered
+// to someone else is left alone and pgx.ErrNoRows returned until its owner
+// unregisters it.
+func (r *PushSubscriptionsRepository) Save(ctx context.Context, sub *models.PushSubscription) (*models.PushSubscription, error) {
+	return scanPushSubscription(r.db.QueryRow(ctx,
+		`INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (endpoint) DO UPDATE
+		 SET p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth,
+		     user_agent = EXCLUDED.user_agent, updated_at = NOW()
+		 WHERE push_subscriptions.user_id = EXCLUDED.user_id
+		 RETURNING `+pushSubscriptionColumns,
+		sub.UserID, sub.Endpoint, sub.P256dh, sub.Auth, sub.UserAgent,
+	))
+}
+
+func (r *PushSubscriptionsRepository) GetByUserID(ctx context.Context, userID int) ([]*models.PushSubscription, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+pushSubscriptionColumns+` FROM push_subscriptions WHERE user_id = $1 ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*models.PushSubscription{}
+	for rows.Next() {
+		sub, err := scanPushSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r *PushSubscriptionsRepository) GetByEndpoint(ctx context.Context, endpoint string) (*models.PushSubscription, error) {
+	return scanPushSubscription(r.db.QueryRow(ctx,
+		`SELECT `+pushSubscriptionColumns+` FROM push_subscriptions WHERE endpoint = $1`,
+		endpoint,
+	))
+}
+
+func (r *PushSubscriptionsRepository) Delete(ctx context.Context, id, userID int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM push_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteByEndpoint removes a subscription the push service reported as
+// expired.
+func (r *PushSubscriptionsRepository) DeleteByEndpoint(ctx context.Context, endpoint string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM push_subscriptions WHERE endpoint = $1`, endpoint)
+	return err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateLimitsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRateLimitsRepository(db *pgxpool.Pool) *RateLimitsRepository {
+	return &RateLimitsRepository{db: db}
+}
+
+// Take refills the bucket for key at refillPerSecond up to capacity, then
+// removes one token if there is one. It returns whether a token was taken
+// and the tokens left.
+func (r *RateLimitsRepository) Take(ctx context.Context, key string, capacity, refillPerSecond float64) (bool, float64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// The upsert locks the row, so concurrent requests for the same key
+	// queue up behind each other until commit.
+	var tokens float64
+	err = tx.QueryRow(ctx,
+		`INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at)
+		 VALUES ($1, $2, NOW())
+		 ON CONFLICT (key) DO UPDATE SET
+		     tokens = LEAST($2, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3),
+		     updated_at = NOW()
+		 RETURNING tokens`,
+		key, capacity, refillPerSecond,
+	).Scan(&tokens)
+	if err != nil {
+		return false, 0, err
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+		if _, err := tx.Exec(ctx, `UPDATE rate_limit_buckets SET tokens = $2 WHERE key = $1`, key, tokens); err != nil {
+			return false, 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, 0, err
+	}
+	return allowed, tokens, nil
+}
+
+// DeleteIdle removes buckets untouched for longer than idle. A bucket that
+// has refilled completely behaves exactly like a missing one.
+func (r *RateLimitsRepository) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - $1::interval`, idle)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
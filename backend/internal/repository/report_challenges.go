@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReportChallengesRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReportChallengesRepository(db *pgxpool.Pool) *ReportChallengesRepository {
+	return &ReportChallengesRepository{db: db}
+}
+
+func (r *ReportChallengesRepository) Create(ctx context.Context, challenge *models.ReportChallenge) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO report_challenges (challenge, difficulty, expires_at) VALUES ($1, $2, $3)`,
+		challenge.Challenge, challenge.Difficulty, challenge.ExpiresAt,
+	)
+	return err
+}
+
+// Consume returns and deletes a challenge so it cannot be solved twice. It
+// returns pgx.ErrNoRows if the challenge is unknown, used or expired.
+func (r *ReportChallengesRepository) Consume(ctx context.Context, challenge string) (*models.ReportChallenge, error) {
+	c := &models.ReportChallenge{}
+	err := r.db.QueryRow(ctx,
+		`DELETE FROM report_challenges WHERE challenge = $1 AND expires_at > NOW()
+		 RETURNING challenge, difficulty, expires_at`,
+		challenge,
+	).Scan(&c.Challenge, &c.Difficulty, &c.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteExpired removes challenges nobody solved in time and returns how
+// many it deleted.
+func (r *ReportChallengesRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM report_challenges WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
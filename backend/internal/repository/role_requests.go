@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fire-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const roleRequestColumns = `id, user_id, role, status, message, invite_id, reviewed_by, review_note, reviewed_at, created_at`
+
+const roleInviteColumns = `id, role, note, max_uses, uses, expires_at, revoked_at, created_by, created_at`
+
+// ErrInvalidInvite is returned for unknown, revoked, expired or used-up
+// invite codes.
+var ErrInvalidInvite = errors.New("invalid invite code")
+
+// ErrNotCitizen is returned when granting a role to a user who already has
+// one besides citizen, so a request or invite never demotes staff.
+var ErrNotCitizen = errors.New("user is no longer a citizen")
+
+type RoleRequestsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRoleRequestsRepository(db *pgxpool.Pool) *RoleRequestsRepository {
+	return &RoleRequestsRepository{db: db}
+}
+
+func scanRoleRequest(row pgx.Row) (*models.RoleRequest, error) {
+	req := &models.RoleRequest{}
+	err := row.Scan(&req.ID, &req.UserID, &req.Role, &req.Status, &req.Message, &req.InviteID,
+		&req.ReviewedBy, &req.ReviewNote, &req.ReviewedAt, &req.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func scanRoleInvite(row pgx.Row) (*models.RoleInvite, error) {
+	invite := &models.RoleInvite{}
+	err := row.Scan(&invite.ID, &invite.Role, &invite.Note, &invite.MaxUses, &invite.Uses,
+		&invite.ExpiresAt, &invite.RevokedAt, &invite.CreatedBy, &invite.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// Create files a pending request. Users can only have one pending request
+// at a time; a second one fails with a unique violation.
+func (r *RoleRequestsRepository) Create(ctx context.Context, userID int, role string, message *string) (*models.RoleRequest, error) {
+	return scanRoleRequest(r.db.QueryRow(ctx,
+		`INSERT INTO role_requests (user_id, role, message) VALUES ($1, $2, $3)
+		 RETURNING `+roleRequestColumns,
+		userID, role, message,
+	))
+}
+
+// Redeem uses an invite code to grant its role straight away, recording an
+// approved request. It returns ErrInvalidInvite if the code cannot be used
+// and ErrNotCitizen, without using the code, if the user is not a citizen.
+func (r *RoleRequestsRepository) Redeem(ctx context.Context, userID int, codeHash string, message *string) (*models.RoleRequest, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var inviteID int
+	var role string
+	err = tx.QueryRow(ctx,
+		`UPDATE role_invites SET uses = uses + 1
+		 WHERE code_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW()) AND uses < max_uses
+		 RETURNING id, role`,
+		codeHash,
+	).Scan(&inviteID, &role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidInvite
+	} else if err != nil {
+		return nil, err
+	}
+
+	// An invite settles any request the user was still waiting on.
+	if _, err := tx.Exec(ctx,
+		`UPDATE role_requests SET status = 'approved', reviewed_at = NOW(), invite_id = $2
+		 WHERE user_id = $1 AND status = 'pending'`,
+		userID, inviteID,
+	); err != nil {
+		return nil, err
+	}
+
+	req, err := scanRoleRequest(tx.QueryRow(ctx,
+		`INSERT INTO role_requests (user_id, role, status, message, invite_id, reviewed_at)
+		 VALUES ($1, $2, 'approved', $3, $4, NOW())
+		 RETURNING `+roleRequestColumns,
+		userID, role, message, inviteID,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET role = $2 WHERE id = $1 AND role = 'citizen'`, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotCitizen
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (r *RoleRequestsRepository) GetLatestByUserID(ctx context.Context, userID int) (*models.RoleRequest, error) {
+	return scanRoleRequest(r.db.QueryRow(ctx,
+		`SELECT `+roleRequestColumns+` FROM role_requests WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1`,
+		userID,
+	))
+}
+
+// List returns requests with the requesting user, oldest first, optionally
+// filtered by status.
+func (r *RoleRequestsRepository) List(ctx context.Context, status string) ([]*models.RoleRequest, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT rr.id, rr.user_id, rr.role, rr.status, rr.message, rr.invite_id, rr.reviewed_by,
+		        rr.review_note, rr.reviewed_at, rr.created_at,
+		        u.id, COALESCE(u.username, ''), u.name, u.role, u.created_at
+		 FROM role_requests rr
+		 JOIN users u ON rr.user_id = u.id
+		 WHERE $1 = '' OR rr.status = $1
+		 ORDER BY rr.created_at ASC, rr.id ASC`,
+		status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*models.RoleRequest{}
+	for rows.Next() {
+		req := &models.RoleRequest{User: &models.User{}}
+		err := rows.Scan(&req.ID, &req.UserID, &req.Role, &req.Status, &req.Message, &req.InviteID,
+			&req.ReviewedBy, &req.ReviewNote, &req.ReviewedAt, &req.CreatedAt,
+			&req.User.ID, &req.User.Username, &req.User.Name, &req.User.Role, &req.User.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
+
+// Review approves or denies a pending request, granting the role on
+// approval. It returns pgx.ErrNoRows if the request is not pending, and
+// ErrNotCitizen, leaving it pending, when approving for a user whose role
+// has changed since they asked.
+func (r *RoleRequestsRepository) Review(ctx context.Context, id, reviewerID int, approve bool, note *string) (*models.RoleRequest, error) {
+	status := "denied"
+	if approve {
+		status = "approved"
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	req, err := scanRoleRequest(tx.QueryRow(ctx,
+		`UPDATE role_requests SET status = $2, reviewed_by = $3, review_note = $4, reviewed_at = NOW()
+		 WHERE id = $1 AND status = 'pending'
+		 RETURNING `+roleRequestColumns,
+		id, status, reviewerID, note,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if approve {
+		tag, err := tx.Exec(ctx, `UPDATE users SET role = $2 WHERE id = $1 AND role = 'citizen'`, req.UserID, req.Role)
+		if err != nil {
+			return nil, err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil, ErrNotCitizen
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (r *RoleRequestsRepository) CreateInvite(ctx context.Context, codeHash, role string, note *string, maxUses int, expiresAt *time.Time, createdBy int) (*models.RoleInvite, error) {
+	return scanRoleInvite(r.db.QueryRow(ctx,
+		`INSERT INTO role_invites (code_hash, role, note, max_uses, expires_at, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING `+roleInviteColumns,
+		codeHash, role, note, maxUses, expiresAt, createdBy,
+	))
+}
+
+func (r *RoleRequestsRepository) ListInvites(ctx context.Context) ([]*models.RoleInvite, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+roleInviteColumns+` FROM role_invites ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []*models.RoleInvite{}
+	for rows.Next() {
+		invite, err := scanRoleInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+// RevokeInvite stops an invite from being redeemed. It returns
+// pgx.ErrNoRows if the invite does not exist or is already revoked.
+func (r *RoleRequestsRepository) RevokeInvite(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `UPDATE role_invites SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
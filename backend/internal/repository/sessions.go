@@ -2,13 +2,30 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fire-tracker/internal/models"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const sessionColumns = `id, user_id, created_at, expires_at, absolute_expires_at, last_seen_at, ip, user_agent`
+
+// ErrRefreshTokenReused is returned when a refresh token is presented a
+// second time. The session it belonged to has been ended.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// SessionClient describes where a session is used from.
+type SessionClient struct {
+	IP        string
+	UserAgent string
+}
+
 type SessionsRepository struct {
 	db *pgxpool.Pool
 }
@@ -17,45 +34,251 @@ func NewSessionsRepository(db *pgxpool.Pool) *SessionsRepository {
 	return &SessionsRepository{db: db}
 }
 
-func (r *SessionsRepository) Create(ctx context.Context, userID int, expiry time.Duration) (*models.Session, error) {
-	session := &models.Session{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(expiry),
+func scanSession(row pgx.Row) (*models.Session, error) {
+	session := &models.Session{}
+	err := row.Scan(&session.ID, &session.UserID, &session.CreatedAt, &session.ExpiresAt,
+		&session.AbsoluteExpiresAt, &session.LastSeenAt, &session.IP, &session.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Create starts a session that stays valid for idle after its last use, up
+// to maxLifetime in total. The returned session carries the new access and
+// refresh tokens.
+func (r *SessionsRepository) Create(ctx context.Context, userID int, idle, maxLifetime time.Duration, client SessionClient) (*models.Session, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	absolute := now.Add(maxLifetime)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	session, err := scanSession(tx.QueryRow(ctx,
+		`INSERT INTO sessions (user_id, token_hash, created_at, expires_at, absolute_expires_at, last_seen_at, ip, user_agent)
+		 VALUES ($1, $2, $3, $4, $5, $3, $6, $7)
+		 RETURNING `+sessionColumns,
+		userID, hashSessionToken(token), now, minTime(now.Add(idle), absolute), absolute,
+		nullIfEmpty(client.IP), nullIfEmpty(client.UserAgent),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO session_refresh_tokens (token_hash, session_id) VALUES ($1, $2)`,
+		hashSessionToken(refreshToken), session.ID,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
+	session.Token = token
+	session.RefreshToken = refreshToken
+	return session, nil
+}
 
+// GetByToken returns the live session for an access token.
+func (r *SessionsRepository) GetByToken(ctx context.Context, token string) (*models.Session, error) {
+	return scanSession(r.db.QueryRow(ctx,
+		`SELECT `+sessionColumns+`
+		 FROM sessions
+		 WHERE token_hash = $1 AND expires_at > NOW() AND absolute_expires_at > NOW()`,
+		hashSessionToken(token),
+	))
+}
+
+// Touch records use of a session and pushes its expiry out to idle from
+// now, never past the absolute expiry.
+func (r *SessionsRepository) Touch(ctx context.Context, id string, idle time.Duration, client SessionClient) error {
 	_, err := r.db.Exec(ctx,
-		`INSERT INTO sessions (id, user_id, created_at, expires_at)
-		 VALUES ($1, $2, $3, $4)`,
-		session.ID, session.UserID, session.CreatedAt, session.ExpiresAt,
+		`UPDATE sessions
+		 SET last_seen_at = NOW(), expires_at = LEAST(NOW() + $2::interval, absolute_expires_at),
+		     ip = COALESCE($3, ip), user_agent = COALESCE($4, user_agent)
+		 WHERE id = $1`,
+		id, idle, nullIfEmpty(client.IP), nullIfEmpty(client.UserAgent),
 	)
+	return err
+}
+
+// Refresh trades a refresh token for new access and refresh tokens on the
+// same session. It returns pgx.ErrNoRows for unknown tokens or ended
+// sessions, and ErrRefreshTokenReused, after ending the session, for
+// tokens that were already used.
+func (r *SessionsRepository) Refresh(ctx context.Context, refreshToken string, idle time.Duration, client SessionClient) (*models.Session, error) {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
+
+	var sessionID string
+	var usedAt *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT session_id, used_at FROM session_refresh_tokens WHERE token_hash = $1 FOR UPDATE`,
+		hashSessionToken(refreshToken),
+	).Scan(&sessionID, &usedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if usedAt != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM sessions WHERE id = $1`, sessionID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	newRefreshToken, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := scanSession(tx.QueryRow(ctx,
+		`UPDATE sessions
+		 SET token_hash = $2, last_seen_at = NOW(), expires_at = LEAST(NOW() + $3::interval, absolute_expires_at),
+		     ip = COALESCE($4, ip), user_agent = COALESCE($5, user_agent)
+		 WHERE id = $1 AND absolute_expires_at > NOW()
+		 RETURNING `+sessionColumns,
+		sessionID, hashSessionToken(token), idle, nullIfEmpty(client.IP), nullIfEmpty(client.UserAgent),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE session_refresh_tokens SET used_at = NOW() WHERE token_hash = $1`,
+		hashSessionToken(refreshToken),
+	); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO session_refresh_tokens (token_hash, session_id) VALUES ($1, $2)`,
+		hashSessionToken(newRefreshToken), session.ID,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	session.Token = token
+	session.RefreshToken = newRefreshToken
 	return session, nil
 }
 
-func (r *SessionsRepository) GetByID(ctx context.Context, sessionID string) (*models.Session, error) {
-	session := &models.Session{}
-	err := r.db.QueryRow(ctx,
-		`SELECT id, user_id, created_at, expires_at
+// ListByUserID returns the user's sessions that have not reached their
+// absolute expiry, most recently used first.
+func (r *SessionsRepository) ListByUserID(ctx context.Context, userID int) ([]*models.Session, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+sessionColumns+`
 		 FROM sessions
-		 WHERE id = $1 AND expires_at > NOW()`,
-		sessionID,
-	).Scan(&session.ID, &session.UserID, &session.CreatedAt, &session.ExpiresAt)
+		 WHERE user_id = $1 AND absolute_expires_at > NOW()
+		 ORDER BY last_seen_at DESC`,
+		userID,
+	)
 	if err != nil {
 		return nil, err
 	}
-	return session, nil
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
 }
 
-func (r *SessionsRepository) Delete(ctx context.Context, sessionID string) error {
-	_, err := r.db.Exec(ctx, `DELETE FROM sessions WHERE id = $1`, sessionID)
+// DeleteByID ends one of the user's sessions. It returns pgx.ErrNoRows if
+// the user has no such session.
+func (r *SessionsRepository) DeleteByID(ctx context.Context, id string, userID int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM sessions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete ends the session an access token belongs to.
+func (r *SessionsRepository) Delete(ctx context.Context, token string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM sessions WHERE token_hash = $1`, hashSessionToken(token))
 	return err
 }
 
-func (r *SessionsRepository) DeleteExpired(ctx context.Context) error {
-	_, err := r.db.Exec(ctx, `DELETE FROM sessions WHERE expires_at < NOW()`)
+// DeleteByUserID ends all of a user's sessions except the one exceptToken
+// belongs to, which may be empty.
+func (r *SessionsRepository) DeleteByUserID(ctx context.Context, userID int, exceptToken string) error {
+	_, err := r.db.Exec(ctx,
+		`DELETE FROM sessions WHERE user_id = $1 AND token_hash != $2`,
+		userID, hashSessionToken(exceptToken),
+	)
 	return err
 }
+
+// DeleteExpired removes sessions past their absolute expiry. Sessions that
+// only went idle are kept, since their refresh token still works.
+// It returns the number of sessions deleted.
+func (r *SessionsRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM sessions WHERE absolute_expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func newSessionToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashSessionToken is how tokens are stored, so a database leak does not
+// expose usable sessions. Tokens are random, so a plain SHA-256 suffices.
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
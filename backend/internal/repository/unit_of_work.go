@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UnitOfWork runs domain writes in one transaction together with the events
+// they produce, so an event is recorded if and only if the change commits.
+type UnitOfWork struct {
+	db *pgxpool.Pool
+}
+
+func NewUnitOfWork(db *pgxpool.Pool) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Tx is the transaction handed to a unit of work.
+type Tx struct {
+	pgx.Tx
+}
+
+// Do runs fn in a transaction and commits if it returns nil.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&Tx{Tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RecordEvent writes an outbox entry for the relay and notifies listening
+// instances. payload is the entity as it is after the change.
+func (tx *Tx) RecordEvent(ctx context.Context, eventType string, fireID, commentID int, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO outbox (event_type, fire_id, comment_id, payload)
+		 VALUES ($1, $2, NULLIF($3::int, 0), $4)`,
+		eventType, fireID, commentID, data,
+	)
+	if err != nil {
+		return err
+	}
+
+	return notifyEvent(ctx, tx.Tx, eventType, fireID, commentID)
+}
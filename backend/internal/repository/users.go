@@ -3,10 +3,15 @@ package repository
 import (
 	"context"
 	"fire-tracker/internal/models"
+	"fmt"
+	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const userColumns = `id, COALESCE(username, ''), name, email, role, created_at, organisation_id, email_verified_at, suspended_at, suspended_reason, password_hash, phone`
+
 type UsersRepository struct {
 	db *pgxpool.Pool
 }
@@ -15,39 +20,192 @@ func NewUsersRepository(db *pgxpool.Pool) *UsersRepository {
 	return &UsersRepository{db: db}
 }
 
-func (r *UsersRepository) Create(ctx context.Context, name, role string) (*models.User, error) {
+func scanUser(row pgx.Row) (*models.User, error) {
 	user := &models.User{}
-	err := r.db.QueryRow(ctx,
-		`INSERT INTO users (name, role) VALUES ($1, $2)
-		 RETURNING id, name, role, created_at`,
-		name, role,
-	).Scan(&user.ID, &user.Name, &user.Role, &user.CreatedAt)
+	err := row.Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.CreatedAt,
+		&user.OrganisationID, &user.EmailVerifiedAt, &user.SuspendedAt, &user.SuspendedReason, &user.PasswordHash, &user.Phone)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
+// Create registers a password account. Usernames and emails are unique
+// regardless of case.
+func (r *UsersRepository) Create(ctx context.Context, username, name string, email *string, role, passwordHash string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`INSERT INTO users (username, name, email, role, password_hash, password_changed_at)
+		 VALUES ($1, $2, $3, $4, $5, NOW())
+		 RETURNING `+userColumns,
+		username, name, email, role, passwordHash,
+	))
+}
+
 func (r *UsersRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
-	user := &models.User{}
-	err := r.db.QueryRow(ctx,
-		`SELECT id, name, role, created_at FROM users WHERE id = $1`,
+	return scanUser(r.db.QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE id = $1`,
 		id,
-	).Scan(&user.ID, &user.Name, &user.Role, &user.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return user, nil
+	))
 }
 
-func (r *UsersRepository) GetByName(ctx context.Context, name string) (*models.User, error) {
-	user := &models.User{}
-	err := r.db.QueryRow(ctx,
-		`SELECT id, name, role, created_at FROM users WHERE name = $1`,
-		name,
-	).Scan(&user.ID, &user.Name, &user.Role, &user.CreatedAt)
+func (r *UsersRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE lower(username) = lower($1)`,
+		username,
+	))
+}
+
+func (r *UsersRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE lower(email) = lower($1)`,
+		email,
+	))
+}
+
+func (r *UsersRepository) SetPassword(ctx context.Context, id int, passwordHash string) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE users SET password_hash = $2, password_changed_at = NOW() WHERE id = $1`,
+		id, passwordHash,
+	)
+	return err
+}
+
+// MarkEmailVerified records that the user has shown they read their email.
+func (r *UsersRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND email IS NOT NULL AND email_verified_at IS NULL`,
+		id,
+	)
+	return err
+}
+
+// GetOrCreateByPhone returns the user identified by phone, creating a
+// citizen account named name on first contact.
+func (r *UsersRepository) GetOrCreateByPhone(ctx context.Context, phone, name string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`INSERT INTO users (name, role, phone) VALUES ($1, 'citizen', $2)
+		 ON CONFLICT (phone) DO UPDATE SET phone = EXCLUDED.phone
+		 RETURNING `+userColumns,
+		name, phone,
+	))
+}
+
+// UserFilter narrows List. Zero values match everything.
+type UserFilter struct {
+	Query          string // Matched against username, name and email
+	Role           string
+	OrganisationID *int
+	Suspended      *bool
+}
+
+// List returns users matching filter, newest first, and the total number of
+// matches.
+func (r *UsersRepository) List(ctx context.Context, filter UserFilter, limit, offset int) ([]*models.User, int, error) {
+	where := " WHERE TRUE"
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.Query != "" {
+		where += fmt.Sprintf(" AND (username ILIKE $%d OR name ILIKE $%d OR email ILIKE $%d)", argIndex, argIndex, argIndex)
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		argIndex++
+	}
+	if filter.Role != "" {
+		where += fmt.Sprintf(" AND role = $%d", argIndex)
+		args = append(args, filter.Role)
+		argIndex++
+	}
+	if filter.OrganisationID != nil {
+		where += fmt.Sprintf(" AND organisation_id = $%d", argIndex)
+		args = append(args, *filter.OrganisationID)
+		argIndex++
+	}
+	if filter.Suspended != nil {
+		if *filter.Suspended {
+			where += " AND suspended_at IS NOT NULL"
+		} else {
+			where += " AND suspended_at IS NULL"
+		}
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users` + where +
+		fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+	rows, err := r.db.Query(ctx, query, append(args, limit, offset)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return user, nil
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// UserUpdate holds the fields an administrator may change. Nil fields are
+// left as they are.
+type UserUpdate struct {
+	Username *string
+	Name     *string
+	Role     *string
+}
+
+func (r *UsersRepository) Update(ctx context.Context, id int, update UserUpdate) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`UPDATE users SET username = COALESCE($2, username), name = COALESCE($3, name), role = COALESCE($4, role)
+		 WHERE id = $1
+		 RETURNING `+userColumns,
+		id, update.Username, update.Name, update.Role,
+	))
+}
+
+// SetOrganisation makes the user a member of an organisation, or of none
+// when organisationID is nil. It returns pgx.ErrNoRows if the user does not
+// exist.
+func (r *UsersRepository) SetOrganisation(ctx context.Context, id int, organisationID *int) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`UPDATE users SET organisation_id = $2
+		 WHERE id = $1
+		 RETURNING `+userColumns,
+		id, organisationID,
+	))
+}
+
+// Suspend blocks the user from signing in. It returns pgx.ErrNoRows if the
+// user does not exist.
+func (r *UsersRepository) Suspend(ctx context.Context, id, suspendedBy int, reason *string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`UPDATE users SET suspended_at = COALESCE(suspended_at, NOW()), suspended_reason = $3, suspended_by = $2
+		 WHERE id = $1
+		 RETURNING `+userColumns,
+		id, suspendedBy, reason,
+	))
+}
+
+func (r *UsersRepository) Unsuspend(ctx context.Context, id int) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`UPDATE users SET suspended_at = NULL, suspended_reason = NULL, suspended_by = NULL
+		 WHERE id = $1
+		 RETURNING `+userColumns,
+		id,
+	))
+}
+
+// escapeLike makes s match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
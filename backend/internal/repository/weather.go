@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fire-tracker/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WeatherRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWeatherRepository(db *pgxpool.Pool) *WeatherRepository {
+	return &WeatherRepository{db: db}
+}
+
+func (r *WeatherRepository) Create(ctx context.Context, snapshot *models.WeatherSnapshot) (*models.WeatherSnapshot, error) {
+	created := &models.WeatherSnapshot{}
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO weather_snapshots (fire_id, wind_speed, wind_direction, temperature, humidity, source, observed_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, fire_id, wind_speed, wind_direction, temperature, humidity, source, observed_at, created_at`,
+		snapshot.FireID, snapshot.WindSpeed, snapshot.WindDirection, snapshot.Temperature,
+		snapshot.Humidity, snapshot.Source, snapshot.ObservedAt,
+	).Scan(&created.ID, &created.FireID, &created.WindSpeed, &created.WindDirection, &created.Temperature,
+		&created.Humidity, &created.Source, &created.ObservedAt, &created.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *WeatherRepository) GetLatestByFireID(ctx context.Context, fireID int) (*models.WeatherSnapshot, error) {
+	snapshot := &models.WeatherSnapshot{}
+	err := r.db.QueryRow(ctx,
+		`SELECT id, fire_id, wind_speed, wind_direction, temperature, humidity, source, observed_at, created_at
+		 FROM weather_snapshots
+		 WHERE fire_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT 1`,
+		fireID,
+	).Scan(&snapshot.ID, &snapshot.FireID, &snapshot.WindSpeed, &snapshot.WindDirection, &snapshot.Temperature,
+		&snapshot.Humidity, &snapshot.Source, &snapshot.ObservedAt, &snapshot.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const webhookSubscriptionColumns = `id, url, secret, event_types, ST_AsGeoJSON(region::geometry)::json, active, created_by, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, outbox_id, event_type, payload, status, attempts,
+	response_status, last_error, next_attempt_at, delivered_at, created_at`
+
+type WebhooksRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhooksRepository(db *pgxpool.Pool) *WebhooksRepository {
+	return &WebhooksRepository{db: db}
+}
+
+func scanWebhookSubscription(row pgx.Row) (*models.WebhookSubscription, error) {
+	sub := &models.WebhookSubscription{}
+	err := row.Scan(&sub.ID, &sub.URL, &sub.Secret, &sub.EventTypes, &sub.Region, &sub.Active,
+		&sub.CreatedBy, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.OutboxID, &delivery.EventType,
+		&delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.ResponseStatus,
+		&delivery.LastError, &delivery.NextAttemptAt, &delivery.DeliveredAt, &delivery.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// regionParam encodes an optional polygon for ST_GeomFromGeoJSON.
+func regionParam(region *models.Polygon) (*string, error) {
+	if region == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(region)
+	if err != nil {
+		return nil, err
+	}
+	value := string(data)
+	return &value, nil
+}
+
+func (r *WebhooksRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	region, err := regionParam(sub.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWebhookSubscription(r.db.QueryRow(ctx,
+		`INSERT INTO webhook_subscriptions (url, secret, event_types, region, active, created_by)
+		 VALUES ($1, $2, $3, ST_SetSRID(ST_GeomFromGeoJSON($4), 4326)::geography, $5, $6)
+		 RETURNING `+webhookSubscriptionColumns,
+		sub.URL, sub.Secret, sub.EventTypes, region, sub.Active, sub.CreatedBy,
+	))
+}
+
+func (r *WebhooksRepository) GetSubscription(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	return scanWebhookSubscription(r.db.QueryRow(ctx,
+		`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`,
+		id,
+	))
+}
+
+func (r *WebhooksRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*models.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// UpdateSubscription replaces everything except the secret.
+func (r *WebhooksRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	region, err := regionParam(sub.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWebhookSubscription(r.db.QueryRow(ctx,
+		`UPDATE webhook_subscriptions
+		 SET url = $1, event_types = $2, region = ST_SetSRID(ST_GeomFromGeoJSON($3), 4326)::geography,
+		     active = $4, updated_at = NOW()
+		 WHERE id = $5
+		 RETURNING `+webhookSubscriptionColumns,
+		sub.URL, sub.EventTypes, region, sub.Active, sub.ID,
+	))
+}
+
+func (r *WebhooksRepository) DeleteSubscription(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetMatchingSubscriptions returns active subscriptions for the event type
+// whose region, if any, contains the point.
+func (r *WebhooksRepository) GetMatchingSubscriptions(ctx context.Context, eventType string, latitude, longitude float64) ([]*models.WebhookSubscription, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+webhookSubscriptionColumns+`
+		 FROM webhook_subscriptions
+		 WHERE active AND $1 = ANY(event_types)
+		   AND (region IS NULL OR ST_Covers(region, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography))`,
+		eventType, longitude, latitude,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*models.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// EnqueueDelivery adds a pending delivery. Enqueueing the same outbox entry
+// for a subscription twice is a no-op.
+func (r *WebhooksRepository) EnqueueDelivery(ctx context.Context, subscriptionID int, outboxID *int64, eventType string, payload json.RawMessage, nextAttemptAt time.Time) (*models.WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(r.db.QueryRow(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, outbox_id, event_type, payload, next_attempt_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (subscription_id, outbox_id) DO NOTHING
+		 RETURNING `+webhookDeliveryColumns,
+		subscriptionID, outboxID, eventType, payload, nextAttemptAt,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return delivery, err
+}
+
+func (r *WebhooksRepository) ListDeliveries(ctx context.Context, subscriptionID, limit, offset int) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+webhookDeliveryColumns+`
+		 FROM webhook_deliveries
+		 WHERE subscription_id = $1
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $2 OFFSET $3`,
+		subscriptionID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimDueDeliveries leases up to limit pending deliveries whose next
+// attempt is due, counting the attempt, and loads their subscriptions.
+func (r *WebhooksRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx,
+		`WITH claimed AS (
+		     UPDATE webhook_deliveries
+		     SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+		     WHERE id IN (
+		         SELECT d.id FROM webhook_deliveries d
+		         JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		         WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.active
+		         ORDER BY d.id
+		         LIMIT $1
+		         FOR UPDATE OF d SKIP LOCKED
+		     )
+		     RETURNING `+webhookDeliveryColumns+`
+		 )
+		 SELECT c.*, s.url, s.secret
+		 FROM claimed c
+		 JOIN webhook_subscriptions s ON s.id = c.subscription_id
+		 ORDER BY c.id`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{Subscription: &models.WebhookSubscription{}}
+		err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.OutboxID, &delivery.EventType,
+			&delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.ResponseStatus,
+			&delivery.LastError, &delivery.NextAttemptAt, &delivery.DeliveredAt, &delivery.CreatedAt,
+			&delivery.Subscription.URL, &delivery.Subscription.Secret)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Subscription.ID = delivery.SubscriptionID
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt stores the outcome of a send. status is "delivered",
+// "failed" or "pending"; nextAttemptAt is only used for "pending".
+func (r *WebhooksRepository) RecordAttempt(ctx context.Context, id int64, attempts int, status string, responseStatus *int, lastError *string, nextAttemptAt time.Time) (*models.WebhookDelivery, error) {
+	return scanWebhookDelivery(r.db.QueryRow(ctx,
+		`UPDATE webhook_deliveries
+		 SET attempts = $2, status = $3, response_status = $4, last_error = $5, next_attempt_at = $6,
+		     delivered_at = CASE WHEN $3 = 'delivered' THEN NOW() ELSE delivered_at END
+		 WHERE id = $1
+		 RETURNING `+webhookDeliveryColumns,
+		id, attempts, status, responseStatus, lastError, nextAttemptAt,
+	))
+}
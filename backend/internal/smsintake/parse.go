@@ -0,0 +1,72 @@
+// Package smsintake interprets free-text fire reports received by SMS.
+package smsintake
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// "#123 more smoke now" refers to an existing fire.
+	fireRefPattern = regexp.MustCompile(`^\s*#(\d+)\b[\s:,.-]*`)
+	// Decimal coordinates such as "34.935, 32.872" or a pasted map link
+	// "...?q=34.935,32.872". At least two decimals are required so
+	// distances like "2.5 km" are not mistaken for coordinates.
+	coordinatesPattern = regexp.MustCompile(`(-?\d{1,2}\.\d{2,})\s*[,;/ ]\s*(-?\d{1,3}\.\d{2,})`)
+	whitespacePattern  = regexp.MustCompile(`\s+`)
+	phoneStripPattern  = regexp.MustCompile(`[\s().-]`)
+)
+
+// Message is a parsed SMS.
+type Message struct {
+	FireID    *int     // Set when the message starts with "#<id>"
+	Latitude  *float64 // Set with Longitude when the message contains coordinates
+	Longitude *float64
+	Text      string // Message text without the fire reference and coordinates
+}
+
+// Parse extracts an optional fire reference and coordinates from text.
+// Village names are left in Text for the caller to resolve.
+func Parse(text string) Message {
+	var msg Message
+
+	if m := fireRefPattern.FindStringSubmatch(text); m != nil {
+		if id, err := strconv.Atoi(m[1]); err == nil {
+			msg.FireID = &id
+			text = text[len(m[0]):]
+		}
+	}
+
+	if m := coordinatesPattern.FindStringSubmatchIndex(text); m != nil {
+		latitude, latErr := strconv.ParseFloat(text[m[2]:m[3]], 64)
+		longitude, lonErr := strconv.ParseFloat(text[m[4]:m[5]], 64)
+		if latErr == nil && lonErr == nil && latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180 {
+			msg.Latitude = &latitude
+			msg.Longitude = &longitude
+			text = text[:m[0]] + " " + text[m[1]:]
+		}
+	}
+
+	msg.Text = strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+	return msg
+}
+
+// NormalizePhone removes formatting from a phone number, e.g.
+// "+357 99 123-456" becomes "+35799123456".
+func NormalizePhone(phone string) string {
+	phone = phoneStripPattern.ReplaceAllString(strings.TrimSpace(phone), "")
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	return phone
+}
+
+// MaskPhone hides all but the last three digits of a phone number for
+// display names.
+func MaskPhone(phone string) string {
+	if len(phone) <= 3 {
+		return phone
+	}
+	return strings.Repeat("•", 4) + phone[len(phone)-3:]
+}
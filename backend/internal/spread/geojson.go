@@ -0,0 +1,44 @@
+package spread
+
+// FeatureCollection is a minimal GeoJSON feature collection.
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   *Polygon               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type Polygon struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// ToGeoJSON converts projections into a feature collection with one polygon
+// per horizon.
+func ToGeoJSON(projections []*Projection) *FeatureCollection {
+	collection := &FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]*Feature, 0, len(projections)),
+	}
+	for _, p := range projections {
+		collection.Features = append(collection.Features, &Feature{
+			Type: "Feature",
+			Geometry: &Polygon{
+				Type:        "Polygon",
+				Coordinates: [][][2]float64{p.Perimeter},
+			},
+			Properties: map[string]interface{}{
+				"hours":         p.Hours,
+				"head_distance": p.HeadDistance,
+				"back_distance": p.BackDistance,
+				"width":         p.Width,
+				"area":          p.Area,
+			},
+		})
+	}
+	return collection
+}
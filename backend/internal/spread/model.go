@@ -0,0 +1,103 @@
+// Package spread projects the growth of a fire perimeter over time.
+package spread
+
+import (
+	"math"
+)
+
+const metersPerDegreeLat = 111320.0
+
+// EllipticalModel is a simple wind-driven model where the fire grows as an
+// ellipse with the ignition point at its rear focus. The head rate of spread
+// grows linearly with wind speed and the length-to-breadth ratio follows
+// Anderson (1983).
+type EllipticalModel struct {
+	BaseRate   float64 // Rate of spread with no wind, m/h
+	WindFactor float64 // Additional head rate per m/s of wind, as a fraction of BaseRate
+	Vertices   int     // Number of points used to approximate each ellipse
+}
+
+func NewEllipticalModel(baseRate, windFactor float64) *EllipticalModel {
+	return &EllipticalModel{
+		BaseRate:   baseRate,
+		WindFactor: windFactor,
+		Vertices:   64,
+	}
+}
+
+// Wind is the wind acting on the fire.
+type Wind struct {
+	Speed     float64 `json:"speed"`     // m/s
+	Direction float64 `json:"direction"` // Degrees the wind is blowing from
+}
+
+// Projection is the projected perimeter after a given number of hours.
+type Projection struct {
+	Hours        float64
+	HeadDistance float64 // m, downwind from the ignition point
+	BackDistance float64 // m, upwind from the ignition point
+	Width        float64 // m, across the widest point
+	Area         float64 // m^2
+	Perimeter    [][2]float64
+}
+
+// Project returns the perimeter after the given number of hours for a fire
+// ignited at latitude/longitude.
+func (m *EllipticalModel) Project(latitude, longitude float64, wind Wind, hours float64) *Projection {
+	lengthToBreadth := m.lengthToBreadth(wind.Speed)
+	eccentricity := math.Sqrt(1 - 1/(lengthToBreadth*lengthToBreadth))
+
+	headRate := m.BaseRate * (1 + m.WindFactor*math.Max(wind.Speed, 0))
+	backRate := headRate * (1 - eccentricity) / (1 + eccentricity)
+
+	head := headRate * hours
+	back := backRate * hours
+	semiMajor := (head + back) / 2
+	semiMinor := semiMajor / lengthToBreadth
+
+	// The fire spreads away from where the wind is blowing from.
+	heading := (wind.Direction + 180) * math.Pi / 180
+	centerOffset := (head - back) / 2
+
+	vertices := m.Vertices
+	if vertices < 8 {
+		vertices = 8
+	}
+
+	perimeter := make([][2]float64, 0, vertices+1)
+	for i := 0; i < vertices; i++ {
+		theta := 2 * math.Pi * float64(i) / float64(vertices)
+		along := centerOffset + semiMajor*math.Cos(theta)
+		across := semiMinor * math.Sin(theta)
+
+		// Rotate from the wind-aligned frame into east/north offsets.
+		east := along*math.Sin(heading) + across*math.Cos(heading)
+		north := along*math.Cos(heading) - across*math.Sin(heading)
+		perimeter = append(perimeter, offset(latitude, longitude, east, north))
+	}
+	perimeter = append(perimeter, perimeter[0])
+
+	return &Projection{
+		Hours:        hours,
+		HeadDistance: head,
+		BackDistance: back,
+		Width:        2 * semiMinor,
+		Area:         math.Pi * semiMajor * semiMinor,
+		Perimeter:    perimeter,
+	}
+}
+
+// lengthToBreadth returns the ellipse length-to-breadth ratio for a wind
+// speed in m/s.
+func (m *EllipticalModel) lengthToBreadth(windSpeed float64) float64 {
+	mph := math.Max(windSpeed, 0) * 2.23694
+	ratio := 0.936*math.Exp(0.2566*mph) + 0.461*math.Exp(-0.1548*mph) - 0.397
+	return math.Min(math.Max(ratio, 1), 8)
+}
+
+// offset moves a point by east/north meters and returns [longitude, latitude].
+func offset(latitude, longitude, east, north float64) [2]float64 {
+	lat := latitude + north/metersPerDegreeLat
+	lon := longitude + east/(metersPerDegreeLat*math.Cos(latitude*math.Pi/180))
+	return [2]float64{lon, lat}
+}
@@ -0,0 +1,40 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// FileProvider returns the conditions stored in a JSON file, regardless of
+// location. The file is re-read on every call so it can be edited while the
+// server is running. Intended for local development and demos.
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) Name() string {
+	return "file"
+}
+
+func (p *FileProvider) Current(ctx context.Context, latitude, longitude float64) (*Conditions, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions Conditions
+	if err := json.Unmarshal(data, &conditions); err != nil {
+		return nil, err
+	}
+
+	if conditions.ObservedAt.IsZero() {
+		conditions.ObservedAt = time.Now().UTC()
+	}
+	return &conditions, nil
+}
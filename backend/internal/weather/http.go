@@ -0,0 +1,80 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// HTTPProvider fetches conditions from an Open-Meteo compatible forecast API.
+type HTTPProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHTTPProvider(baseURL string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPProvider) Name() string {
+	return "open-meteo"
+}
+
+type openMeteoResponse struct {
+	Current struct {
+		Time          string  `json:"time"`
+		Temperature   float64 `json:"temperature_2m"`
+		Humidity      float64 `json:"relative_humidity_2m"`
+		WindSpeed     float64 `json:"wind_speed_10m"`
+		WindDirection float64 `json:"wind_direction_10m"`
+	} `json:"current"`
+}
+
+func (p *HTTPProvider) Current(ctx context.Context, latitude, longitude float64) (*Conditions, error) {
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(latitude, 'f', 6, 64))
+	query.Set("longitude", strconv.FormatFloat(longitude, 'f', 6, 64))
+	query.Set("current", "temperature_2m,relative_humidity_2m,wind_speed_10m,wind_direction_10m")
+	query.Set("wind_speed_unit", "ms")
+	query.Set("timezone", "GMT")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/v1/forecast?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("weather provider returned status %d", resp.StatusCode)
+	}
+
+	var body openMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode weather response: %w", err)
+	}
+
+	observedAt, err := time.Parse("2006-01-02T15:04", body.Current.Time)
+	if err != nil {
+		observedAt = time.Now().UTC()
+	}
+
+	return &Conditions{
+		WindSpeed:     body.Current.WindSpeed,
+		WindDirection: body.Current.WindDirection,
+		Temperature:   body.Current.Temperature,
+		Humidity:      body.Current.Humidity,
+		ObservedAt:    observedAt,
+	}, nil
+}
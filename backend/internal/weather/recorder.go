@@ -0,0 +1,59 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+)
+
+// Recorder fetches conditions for a fire and stores them as a snapshot. It
+// consumes fire events from the outbox so a snapshot is taken for every
+// report and status change even if the process restarts in between.
+type Recorder struct {
+	provider    Provider
+	weatherRepo *repository.WeatherRepository
+}
+
+func NewRecorder(provider Provider, weatherRepo *repository.WeatherRepository) *Recorder {
+	return &Recorder{
+		provider:    provider,
+		weatherRepo: weatherRepo,
+	}
+}
+
+func (r *Recorder) Name() string {
+	return "weather"
+}
+
+func (r *Recorder) Handle(ctx context.Context, entry *models.OutboxEntry) error {
+	if entry.EventType != events.FireCreated && entry.EventType != events.FireStatusChanged {
+		return nil
+	}
+
+	var fire models.Fire
+	if err := json.Unmarshal(entry.Payload, &fire); err != nil {
+		return err
+	}
+
+	_, err := r.Record(ctx, &fire)
+	return err
+}
+
+func (r *Recorder) Record(ctx context.Context, fire *models.Fire) (*models.WeatherSnapshot, error) {
+	conditions, err := r.provider.Current(ctx, fire.Latitude, fire.Longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.weatherRepo.Create(ctx, &models.WeatherSnapshot{
+		FireID:        fire.ID,
+		WindSpeed:     conditions.WindSpeed,
+		WindDirection: conditions.WindDirection,
+		Temperature:   conditions.Temperature,
+		Humidity:      conditions.Humidity,
+		Source:        r.provider.Name(),
+		ObservedAt:    conditions.ObservedAt,
+	})
+}
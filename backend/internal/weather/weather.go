@@ -0,0 +1,21 @@
+package weather
+
+import (
+	"context"
+	"time"
+)
+
+// Conditions are the current weather conditions at a point.
+type Conditions struct {
+	WindSpeed     float64   `json:"wind_speed"`     // m/s
+	WindDirection float64   `json:"wind_direction"` // Degrees the wind is blowing from
+	Temperature   float64   `json:"temperature"`    // Celsius
+	Humidity      float64   `json:"humidity"`       // Relative humidity, percent
+	ObservedAt    time.Time `json:"observed_at"`
+}
+
+// Provider looks up current conditions for a location.
+type Provider interface {
+	Name() string
+	Current(ctx context.Context, latitude, longitude float64) (*Conditions, error)
+}
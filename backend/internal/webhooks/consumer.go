@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/events"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"time"
+)
+
+// EventTypes are the events partners can subscribe to.
+var EventTypes = []string{events.FireCreated, events.FireStatusChanged, events.FireMerged, events.FireTransferred, events.CommentAdded, events.CommentDeleted}
+
+// Consumer turns outbox entries into pending deliveries for every matching
+// subscription. Sending happens separately in the Dispatcher so one slow
+// receiver cannot hold up the outbox.
+type Consumer struct {
+	webhooksRepo *repository.WebhooksRepository
+	firesRepo    *repository.FiresRepository
+}
+
+func NewConsumer(webhooksRepo *repository.WebhooksRepository, firesRepo *repository.FiresRepository) *Consumer {
+	return &Consumer{
+		webhooksRepo: webhooksRepo,
+		firesRepo:    firesRepo,
+	}
+}
+
+func (c *Consumer) Name() string {
+	return "webhooks"
+}
+
+func (c *Consumer) Handle(ctx context.Context, entry *models.OutboxEntry) error {
+	latitude, longitude, err := c.locate(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	subs, err := c.webhooksRepo.GetMatchingSubscriptions(ctx, entry.EventType, latitude, longitude)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if _, err := c.webhooksRepo.EnqueueDelivery(ctx, sub.ID, &entry.ID, entry.EventType, entry.Payload, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Consumer) locate(ctx context.Context, entry *models.OutboxEntry) (float64, float64, error) {
+	if entry.EventType == events.CommentAdded || entry.EventType == events.CommentDeleted {
+		fire, err := c.firesRepo.GetByID(ctx, entry.FireID)
+		if err != nil {
+			return 0, 0, err
+		}
+		return fire.Latitude, fire.Longitude, nil
+	}
+
+	var fire models.Fire
+	if err := json.Unmarshal(entry.Payload, &fire); err != nil {
+		return 0, 0, err
+	}
+	return fire.Latitude, fire.Longitude, nil
+}
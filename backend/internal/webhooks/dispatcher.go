@@ -0,0 +1,152 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PingEvent is sent by the test-ping action.
+const PingEvent = "ping"
+
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Concurrency  int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Lease        time.Duration // Must exceed the sender timeout
+}
+
+// Dispatcher sends pending deliveries and reschedules failures with
+// exponential backoff until MaxAttempts is reached.
+type Dispatcher struct {
+	webhooksRepo *repository.WebhooksRepository
+	sender       *Sender
+	config       DispatcherConfig
+	logger       *zap.Logger
+}
+
+func NewDispatcher(webhooksRepo *repository.WebhooksRepository, sender *Sender, config DispatcherConfig, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		webhooksRepo: webhooksRepo,
+		sender:       sender,
+		config:       config,
+		logger:       logger,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) poll(ctx context.Context) {
+	deliveries, err := d.webhooksRepo.ClaimDueDeliveries(ctx, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		d.logger.Error("failed to claim webhook deliveries", zap.Error(err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, d.config.Concurrency)
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.attempt(ctx, delivery, true)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+// Ping sends a test delivery to the subscription immediately and returns
+// the recorded result.
+func (d *Dispatcher) Ping(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"subscription_id": sub.ID,
+		"message":         "Test delivery from Fire Tracker",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Schedule the stored delivery past the lease so the poller does not
+	// pick it up while we are sending it here.
+	delivery, err := d.webhooksRepo.EnqueueDelivery(ctx, sub.ID, nil, PingEvent, payload, time.Now().Add(d.config.Lease))
+	if err != nil {
+		return nil, err
+	}
+	delivery.Subscription = sub
+	delivery.Attempts = 1
+
+	return d.attempt(ctx, delivery, false), nil
+}
+
+// attempt sends a delivery and records the outcome. Failed deliveries are
+// rescheduled if retry is set and attempts remain.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery, retry bool) *models.WebhookDelivery {
+	logger := d.logger.With(
+		zap.Int64("delivery_id", delivery.ID),
+		zap.Int("subscription_id", delivery.SubscriptionID),
+		zap.String("event_type", delivery.EventType),
+		zap.Int("attempt", delivery.Attempts),
+	)
+
+	statusCode, sendErr := d.sender.Send(ctx, delivery)
+
+	var responseStatus *int
+	if statusCode != 0 {
+		responseStatus = &statusCode
+	}
+
+	status := "delivered"
+	nextAttemptAt := time.Now()
+	var lastError *string
+	if sendErr != nil {
+		msg := sendErr.Error()
+		lastError = &msg
+		if !retry || delivery.Attempts >= d.config.MaxAttempts {
+			status = "failed"
+			logger.Warn("webhook delivery failed permanently", zap.Error(sendErr))
+		} else {
+			status = "pending"
+			nextAttemptAt = nextAttemptAt.Add(d.backoff(delivery.Attempts))
+			logger.Info("webhook delivery failed, will retry", zap.Time("next_attempt_at", nextAttemptAt), zap.Error(sendErr))
+		}
+	}
+
+	updated, err := d.webhooksRepo.RecordAttempt(ctx, delivery.ID, delivery.Attempts, status, responseStatus, lastError, nextAttemptAt)
+	if err != nil {
+		logger.Error("failed to record webhook attempt", zap.Error(err))
+		return delivery
+	}
+	return updated
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := float64(d.config.BaseBackoff) * math.Pow(2, float64(attempt-1))
+	if delay > float64(d.config.MaxBackoff) {
+		return d.config.MaxBackoff
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fire-tracker/internal/models"
+	"fire-tracker/internal/repository"
+	"fire-tracker/internal/testdb"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{config: DispatcherConfig{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}}
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 20: 10 * time.Second} {
+		if got := d.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+// newDispatcherTest returns a dispatcher with a subscription posting to a
+// receiver that answers with the status in the returned variable.
+func newDispatcherTest(t *testing.T, config DispatcherConfig) (*Dispatcher, *repository.WebhooksRepository, *models.WebhookSubscription, *atomic.Int32, *atomic.Int32) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	var status, received atomic.Int32
+	status.Store(http.StatusOK)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(receiver.Close)
+
+	owner, err := repository.NewUsersRepository(db).Create(ctx, "owner", "Owner", nil, "admin", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooksRepo := repository.NewWebhooksRepository(db)
+	sub, err := webhooksRepo.CreateSubscription(ctx, &models.WebhookSubscription{
+		URL:        receiver.URL,
+		Secret:     "secret",
+		EventTypes: []string{"fire.created"},
+		Active:     true,
+		CreatedBy:  owner.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatcher := NewDispatcher(webhooksRepo, NewSender(5*time.Second), config, zap.NewNop())
+	return dispatcher, webhooksRepo, sub, &status, &received
+}
+
+func lastDelivery(t *testing.T, webhooksRepo *repository.WebhooksRepository, subscriptionID int) *models.WebhookDelivery {
+	t.Helper()
+	deliveries, err := webhooksRepo.ListDeliveries(context.Background(), subscriptionID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("expected one logged delivery, got %d", len(deliveries))
+	}
+	return deliveries[0]
+}
+
+func TestDispatcherRetriesAfterServerError(t *testing.T) {
+	config := DispatcherConfig{BatchSize: 10, Concurrency: 2, MaxAttempts: 3, BaseBackoff: time.Hour, MaxBackoff: time.Hour, Lease: time.Minute}
+	d, webhooksRepo, sub, status, received := newDispatcherTest(t, config)
+	ctx := context.Background()
+
+	if _, err := webhooksRepo.EnqueueDelivery(ctx, sub.ID, nil, "fire.created", json.RawMessage(`{"id":1}`), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	status.Store(http.StatusInternalServerError)
+	before := time.Now()
+	d.poll(ctx)
+
+	delivery := lastDelivery(t, webhooksRepo, sub.ID)
+	if delivery.Status != "pending" || delivery.Attempts != 1 {
+		t.Fatalf("after a 500: status %q after %d attempts, want pending after 1", delivery.Status, delivery.Attempts)
+	}
+	if delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusInternalServerError || delivery.LastError == nil {
+		t.Errorf("the failed attempt was not logged: %+v", delivery)
+	}
+	if wait := delivery.NextAttemptAt.Sub(before); wait < 59*time.Minute || wait > 61*time.Minute {
+		t.Errorf("next attempt in %v, want the one-hour backoff", wait)
+	}
+
+	// Not due yet, so the next poll leaves it alone
+	d.poll(ctx)
+	if received.Load() != 1 {
+		t.Fatalf("receiver got %d requests before the backoff elapsed, want 1", received.Load())
+	}
+
+	// Once due, it is sent again and delivered
+	if _, err := webhooksRepo.RecordAttempt(ctx, delivery.ID, delivery.Attempts, "pending", delivery.ResponseStatus, delivery.LastError, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	status.Store(http.StatusOK)
+	d.poll(ctx)
+
+	delivery = lastDelivery(t, webhooksRepo, sub.ID)
+	if delivery.Status != "delivered" || delivery.Attempts != 2 || delivery.DeliveredAt == nil {
+		t.Fatalf("after a 200: %+v, want delivered after 2 attempts", delivery)
+	}
+	if delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusOK {
+		t.Errorf("logged response status %v, want 200", delivery.ResponseStatus)
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	config := DispatcherConfig{BatchSize: 10, Concurrency: 1, MaxAttempts: 2, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Lease: time.Minute}
+	d, webhooksRepo, sub, status, received := newDispatcherTest(t, config)
+	ctx := context.Background()
+	status.Store(http.StatusBadGateway)
+
+	if _, err := webhooksRepo.EnqueueDelivery(ctx, sub.ID, nil, "fire.created", json.RawMessage(`{}`), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		d.poll(ctx)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	delivery := lastDelivery(t, webhooksRepo, sub.ID)
+	if delivery.Status != "failed" || delivery.Attempts != 2 {
+		t.Fatalf("got status %q after %d attempts, want failed after 2", delivery.Status, delivery.Attempts)
+	}
+	if received.Load() != 2 {
+		t.Errorf("receiver got %d requests, want 2", received.Load())
+	}
+}
@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fire-tracker/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verify checks a signature header the way a receiver would.
+func verify(t *testing.T, secret, header string, body []byte) time.Time {
+	t.Helper()
+	var ts, mac string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			mac = value
+		}
+	}
+	seconds, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		t.Fatalf("bad timestamp in %q", header)
+	}
+
+	expected := hmac.New(sha256.New, []byte(secret))
+	expected.Write([]byte(ts + "."))
+	expected.Write(body)
+	got, err := hex.DecodeString(mac)
+	if err != nil || !hmac.Equal(got, expected.Sum(nil)) {
+		t.Fatalf("signature %q does not match the body", header)
+	}
+	return time.Unix(seconds, 0)
+}
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"fire.created"}`)
+
+	header := Sign("secret", at, body)
+	if !strings.HasPrefix(header, "t=1700000000,v1=") {
+		t.Fatalf("unexpected header %q", header)
+	}
+	verify(t, "secret", header, body)
+
+	if Sign("other", at, body) == header {
+		t.Error("different secrets gave the same signature")
+	}
+	if Sign("secret", at.Add(time.Second), body) == header {
+		t.Error("different timestamps gave the same signature")
+	}
+}
+
+func TestSenderSignsBody(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer receiver.Close()
+
+	delivery := &models.WebhookDelivery{
+		ID:           42,
+		EventType:    "fire.created",
+		Payload:      json.RawMessage(`{"id":7}`),
+		CreatedAt:    time.Now(),
+		Subscription: &models.WebhookSubscription{URL: receiver.URL, Secret: "s3cret"},
+	}
+	status, err := NewSender(5*time.Second).Send(context.Background(), delivery)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Send: status %d, error %v", status, err)
+	}
+
+	if got.Header.Get("X-Webhook-Event") != "fire.created" || got.Header.Get("X-Webhook-Delivery") != "42" {
+		t.Errorf("unexpected headers %v", got.Header)
+	}
+	signedAt := verify(t, "s3cret", got.Header.Get(SignatureHeader), body)
+	if time.Since(signedAt) > time.Minute {
+		t.Errorf("signature timestamp %v is not current", signedAt)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil || env.ID != 42 || string(env.Data) != `{"id":7}` {
+		t.Errorf("unexpected body %s", body)
+	}
+}
+
+func TestSenderReportsErrorStatus(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	delivery := &models.WebhookDelivery{Subscription: &models.WebhookSubscription{URL: receiver.URL}}
+	status, err := NewSender(5*time.Second).Send(context.Background(), delivery)
+	if status != http.StatusServiceUnavailable || err == nil || !strings.Contains(err.Error(), "down for maintenance") {
+		t.Fatalf("Send: status %d, error %v", status, err)
+	}
+}
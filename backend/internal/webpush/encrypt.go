@@ -0,0 +1,146 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	saltLength   = 16
+	recordSize   = 4096
+	headerLength = saltLength + 4 + 1 + 65
+	// MaxPayloadSize is the largest plaintext that fits in the single
+	// record push services are required to accept.
+	MaxPayloadSize = recordSize - headerLength - 16 - 1
+)
+
+// Encrypt encrypts payload for a subscription with the aes128gcm content
+// coding (RFC 8188) keyed as described in RFC 8291. userPublic is the
+// browser's p256dh key and authSecret its auth secret.
+func Encrypt(payload, userPublic, authSecret []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, errors.New("push payload too large")
+	}
+
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encrypt(payload, userPublic, authSecret, serverKey, salt)
+}
+
+func encrypt(payload, userPublic, authSecret []byte, serverKey *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	userKey, err := ecdh.P256().NewPublicKey(userPublic)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := serverKey.ECDH(userKey)
+	if err != nil {
+		return nil, err
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	gcm, nonce, err := contentCipher(sharedSecret, authSecret, salt, userPublic, serverPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single record, terminated by the 0x02 last-record delimiter.
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, headerLength)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// Decrypt reverses Encrypt given the browser's private key and auth secret.
+// It is what a user agent does on receipt and exists for test push
+// services.
+func Decrypt(body []byte, userKey *ecdh.PrivateKey, authSecret []byte) ([]byte, error) {
+	if len(body) < saltLength+5 {
+		return nil, errors.New("push body too short")
+	}
+	salt := body[:saltLength]
+	idLength := int(body[saltLength+4])
+	if len(body) < saltLength+5+idLength {
+		return nil, errors.New("push body too short")
+	}
+	serverPublic := body[saltLength+5 : saltLength+5+idLength]
+	ciphertext := body[saltLength+5+idLength:]
+
+	serverKey, err := ecdh.P256().NewPublicKey(serverPublic)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := userKey.ECDH(serverKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, nonce, err := contentCipher(sharedSecret, authSecret, salt, userKey.PublicKey().Bytes(), serverPublic)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Strip padding and the record delimiter.
+	end := bytes.LastIndexByte(plaintext, 0x02)
+	if end == -1 {
+		return nil, errors.New("missing record delimiter")
+	}
+	return plaintext[:end], nil
+}
+
+// contentCipher derives the content encryption key and nonce.
+func contentCipher(sharedSecret, authSecret, salt, userPublic, serverPublic []byte) (cipher.AEAD, []byte, error) {
+	prkKey, err := hkdf.Extract(sha256.New, sharedSecret, authSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyInfo := "WebPush: info\x00" + string(userPublic) + string(serverPublic)
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gcm, nonce, nil
+}
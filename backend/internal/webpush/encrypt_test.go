@@ -0,0 +1,88 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+func decode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestEncryptRFC8291 checks the worked example in RFC 8291, Appendix A.
+func TestEncryptRFC8291(t *testing.T) {
+	serverKey, err := ecdh.P256().NewPrivateKey(decode(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	userKey, err := ecdh.P256().NewPrivateKey(decode(t, "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	userPublic := decode(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4")
+	if !bytes.Equal(userKey.PublicKey().Bytes(), userPublic) {
+		t.Fatal("user key pair does not match the RFC")
+	}
+	authSecret := decode(t, "BTBZMqHH6r4Tts7J_aSIgg")
+	salt := decode(t, "DGv6ra1nlYgDCS1FRnbzlw")
+	plaintext := []byte("When I grow up, I want to be a watermelon")
+	want := decode(t, "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN")
+
+	body, err := encrypt(plaintext, userPublic, authSecret, serverKey, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, want) {
+		t.Fatalf("encrypted body differs from the RFC:\n got %s\nwant %s",
+			base64.RawURLEncoding.EncodeToString(body), base64.RawURLEncoding.EncodeToString(want))
+	}
+
+	decrypted, err := Decrypt(want, userKey, authSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("decrypted %q, want %q", decrypted, plaintext)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	userKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	for _, payload := range [][]byte{{}, []byte(`{"title":"Fire reported"}`), bytes.Repeat([]byte("x"), MaxPayloadSize)} {
+		body, err := Encrypt(payload, userKey.PublicKey().Bytes(), authSecret)
+		if err != nil {
+			t.Fatalf("Encrypt(%d bytes): %v", len(payload), err)
+		}
+		got, err := Decrypt(body, userKey, authSecret)
+		if err != nil {
+			t.Fatalf("Decrypt(%d bytes): %v", len(payload), err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Fatalf("round trip of %d bytes changed the payload", len(payload))
+		}
+	}
+
+	// Another auth secret cannot read it
+	body, _ := Encrypt([]byte("secret"), userKey.PublicKey().Bytes(), authSecret)
+	if _, err := Decrypt(body, userKey, make([]byte, 16)); err == nil {
+		t.Error("decrypted with the wrong auth secret")
+	}
+
+	if _, err := Encrypt(make([]byte, MaxPayloadSize+1), userKey.PublicKey().Bytes(), authSecret); err == nil {
+		t.Error("encrypted a payload larger than one record")
+	}
+}
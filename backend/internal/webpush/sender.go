@@ -0,0 +1,88 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fire-tracker/internal/models"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const maxErrorBodyBytes = 512
+
+// ErrSubscriptionGone is returned when the push service reports that a
+// subscription no longer exists. It should be deleted.
+var ErrSubscriptionGone = errors.New("push subscription expired or unsubscribed")
+
+type Sender struct {
+	keys   *VAPIDKeys
+	client *http.Client
+	ttl    time.Duration
+}
+
+// NewSender creates a sender. ttl is how long push services keep messages
+// for offline browsers.
+func NewSender(keys *VAPIDKeys, ttl, timeout time.Duration) *Sender {
+	return &Sender{
+		keys: keys,
+		client: &http.Client{
+			Timeout: timeout,
+			// Endpoints are checked against the known push services when
+			// registered; a redirect could lead anywhere.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		ttl: ttl,
+	}
+}
+
+// Send encrypts payload for sub and posts it to the subscription's push
+// service.
+func (s *Sender) Send(ctx context.Context, sub *models.PushSubscription, payload []byte) error {
+	userPublic, err := decodeBase64(sub.P256dh)
+	if err != nil {
+		return fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64(sub.Auth)
+	if err != nil {
+		return fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	body, err := Encrypt(payload, userPublic, authSecret)
+	if err != nil {
+		return err
+	}
+	authorization, err := s.keys.authorization(sub.Endpoint, time.Now().Add(12*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(s.ttl.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return fmt.Errorf("push service returned status %d: %s", resp.StatusCode, snippet)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,45 @@
+package webpush_test
+
+import (
+	"context"
+	"errors"
+	"fire-tracker/internal/notify/notifytest"
+	"fire-tracker/internal/webpush"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSenderDeliversToPushService(t *testing.T) {
+	service := notifytest.NewPushService()
+	defer service.Close()
+	keys, err := webpush.GenerateVAPIDKeys("mailto:ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := webpush.NewSender(keys, time.Hour, 5*time.Second)
+
+	sub, err := service.NewSubscription(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Send(context.Background(), sub, []byte(`{"title":"Fire reported"}`)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	messages := service.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("push service got %d messages, want 1", len(messages))
+	}
+	if string(messages[0].Payload) != `{"title":"Fire reported"}` {
+		t.Errorf("push service decrypted %q", messages[0].Payload)
+	}
+	if !strings.Contains(messages[0].Authorization, "k="+keys.PublicKey()) {
+		t.Errorf("VAPID header %q does not carry the public key", messages[0].Authorization)
+	}
+
+	service.Unsubscribe(sub.Endpoint)
+	if err := sender.Send(context.Background(), sub, []byte(`{}`)); !errors.Is(err, webpush.ErrSubscriptionGone) {
+		t.Fatalf("Send to a removed endpoint: got %v, want ErrSubscriptionGone", err)
+	}
+}
@@ -0,0 +1,134 @@
+// Package webpush sends encrypted Web Push messages (RFC 8291) authorised
+// with VAPID (RFC 8292).
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// VAPIDKeys identify this server to push services. Browsers bind each
+// subscription to the public key, so rotating keys invalidates every stored
+// subscription.
+type VAPIDKeys struct {
+	private *ecdsa.PrivateKey
+	public  []byte // Uncompressed P-256 point
+	subject string // mailto: or https: contact for the push service operator
+}
+
+// ParseVAPIDKeys decodes a base64url P-256 private key (the 32-byte
+// scalar) and checks it against the base64url public key, if given.
+func ParseVAPIDKeys(publicKey, privateKey, subject string) (*VAPIDKeys, error) {
+	raw, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	keys := newVAPIDKeys(key, subject)
+
+	if publicKey != "" {
+		public, err := decodeBase64(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VAPID public key: %w", err)
+		}
+		if string(public) != string(keys.public) {
+			return nil, errors.New("VAPID public key does not match the private key")
+		}
+	}
+	return keys, nil
+}
+
+// GenerateVAPIDKeys creates a new key pair.
+func GenerateVAPIDKeys(subject string) (*VAPIDKeys, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newVAPIDKeys(key, subject), nil
+}
+
+func newVAPIDKeys(key *ecdh.PrivateKey, subject string) *VAPIDKeys {
+	public := key.PublicKey().Bytes()
+	return &VAPIDKeys{
+		private: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(public[1:33]),
+				Y:     new(big.Int).SetBytes(public[33:]),
+			},
+			D: new(big.Int).SetBytes(key.Bytes()),
+		},
+		public:  public,
+		subject: subject,
+	}
+}
+
+// PublicKey returns the base64url public key browsers pass to
+// pushManager.subscribe as applicationServerKey.
+func (k *VAPIDKeys) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.public)
+}
+
+// PrivateKey returns the base64url private key for storing in
+// configuration.
+func (k *VAPIDKeys) PrivateKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.private.D.FillBytes(make([]byte, 32)))
+}
+
+// authorization builds the VAPID Authorization header value for a push
+// service endpoint.
+func (k *VAPIDKeys) authorization(endpoint string, expiry time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": expiry.Unix(),
+		"sub": k.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// JWS uses the raw 64-byte r || s signature, not ASN.1.
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + k.PublicKey(), nil
+}
+
+// decodeBase64 accepts base64url with or without padding, which is how
+// browsers and key generators variously export keys.
+func decodeBase64(value string) ([]byte, error) {
+	if decoded, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.URLEncoding.DecodeString(value)
+}
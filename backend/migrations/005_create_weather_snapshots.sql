@@ -0,0 +1,14 @@
+-- Create weather snapshots table
+CREATE TABLE IF NOT EXISTS weather_snapshots (
+    id SERIAL PRIMARY KEY,
+    fire_id INTEGER REFERENCES fires(id) ON DELETE CASCADE,
+    wind_speed DOUBLE PRECISION NOT NULL,
+    wind_direction DOUBLE PRECISION NOT NULL,
+    temperature DOUBLE PRECISION NOT NULL,
+    humidity DOUBLE PRECISION NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    observed_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_weather_snapshots_fire_id ON weather_snapshots(fire_id, created_at DESC);
@@ -0,0 +1,16 @@
+-- Create evacuation zones table
+CREATE TABLE IF NOT EXISTS evacuation_zones (
+    id SERIAL PRIMARY KEY,
+    fire_id INTEGER REFERENCES fires(id) ON DELETE SET NULL,
+    created_by INTEGER REFERENCES users(id),
+    area GEOGRAPHY(POLYGON, 4326) NOT NULL,
+    level VARCHAR(50) NOT NULL CHECK (level IN ('prepare', 'evacuate')),
+    message TEXT NOT NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_evacuation_zones_area ON evacuation_zones USING GIST(area);
+CREATE INDEX idx_evacuation_zones_fire_id ON evacuation_zones(fire_id);
+CREATE INDEX idx_evacuation_zones_expires_at ON evacuation_zones(expires_at);
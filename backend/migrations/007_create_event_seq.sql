@@ -0,0 +1,2 @@
+-- Global sequence for change notifications sent on the fire_events channel
+CREATE SEQUENCE IF NOT EXISTS event_seq;
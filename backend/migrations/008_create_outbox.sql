@@ -0,0 +1,29 @@
+-- Create outbox table for domain events written in the same transaction as the change
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    fire_id INTEGER,
+    comment_id INTEGER,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_undispatched ON outbox(id) WHERE dispatched_at IS NULL;
+
+-- Create per-consumer delivery bookkeeping
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    outbox_id BIGINT NOT NULL REFERENCES outbox(id) ON DELETE CASCADE,
+    consumer VARCHAR(100) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP,
+    failed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (outbox_id, consumer)
+);
+
+CREATE INDEX idx_outbox_deliveries_pending ON outbox_deliveries(consumer, next_attempt_at)
+    WHERE delivered_at IS NULL AND failed_at IS NULL;
@@ -0,0 +1,36 @@
+-- Create citizen watch areas
+CREATE TABLE IF NOT EXISTS area_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    center GEOGRAPHY(POINT, 4326),
+    radius_m DOUBLE PRECISION,
+    area GEOGRAPHY(POLYGON, 4326),
+    channels JSONB NOT NULL DEFAULT '[]',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CHECK ((center IS NOT NULL AND radius_m > 0 AND area IS NULL) OR (center IS NULL AND radius_m IS NULL AND area IS NOT NULL))
+);
+
+CREATE INDEX idx_area_subscriptions_user_id ON area_subscriptions(user_id);
+CREATE INDEX idx_area_subscriptions_center ON area_subscriptions USING GIST(center);
+CREATE INDEX idx_area_subscriptions_area ON area_subscriptions USING GIST(area);
+
+-- Create notification queue
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subscription_id INTEGER REFERENCES area_subscriptions(id) ON DELETE SET NULL,
+    fire_id INTEGER REFERENCES fires(id) ON DELETE CASCADE,
+    event_type VARCHAR(100) NOT NULL,
+    fire_status VARCHAR(50) NOT NULL,
+    channel VARCHAR(50) NOT NULL,
+    address TEXT NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    dedup_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_pending ON notifications(id) WHERE status = 'pending';
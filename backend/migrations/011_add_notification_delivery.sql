@@ -0,0 +1,12 @@
+-- Preferred language for alerts from a watch area
+ALTER TABLE area_subscriptions ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'en';
+
+-- Delivery bookkeeping for the notification send queue
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'en';
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS last_error TEXT;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS sent_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_notifications_pending;
+CREATE INDEX idx_notifications_pending ON notifications(next_attempt_at) WHERE status = 'pending';
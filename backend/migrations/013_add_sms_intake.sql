@@ -0,0 +1,38 @@
+-- Users created from inbound SMS are identified by phone number
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR(32) UNIQUE;
+
+-- Gazetteer for resolving village names in text reports
+CREATE TABLE IF NOT EXISTS places (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    alt_names TEXT[] NOT NULL DEFAULT '{}',
+    location GEOGRAPHY(POINT, 4326) NOT NULL
+);
+
+CREATE INDEX idx_places_location ON places USING GIST(location);
+
+INSERT INTO places (name, alt_names, location) VALUES
+    ('Nicosia', '{"Lefkosia", "Λευκωσία"}', ST_SetSRID(ST_MakePoint(33.3823, 35.1856), 4326)::geography),
+    ('Limassol', '{"Lemesos", "Λεμεσός"}', ST_SetSRID(ST_MakePoint(33.0226, 34.7071), 4326)::geography),
+    ('Larnaca', '{"Larnaka", "Λάρνακα"}', ST_SetSRID(ST_MakePoint(33.6232, 34.9003), 4326)::geography),
+    ('Paphos', '{"Pafos", "Πάφος"}', ST_SetSRID(ST_MakePoint(32.4297, 34.7720), 4326)::geography),
+    ('Platres', '{"Pano Platres", "Πλάτρες"}', ST_SetSRID(ST_MakePoint(32.8640, 34.8891), 4326)::geography),
+    ('Troodos', '{"Τρόοδος"}', ST_SetSRID(ST_MakePoint(32.8720, 34.9350), 4326)::geography),
+    ('Kakopetria', '{"Κακοπετριά"}', ST_SetSRID(ST_MakePoint(32.9030, 34.9886), 4326)::geography),
+    ('Pedoulas', '{"Πεδουλάς"}', ST_SetSRID(ST_MakePoint(32.8297, 34.9686), 4326)::geography),
+    ('Prodromos', '{"Πρόδρομος"}', ST_SetSRID(ST_MakePoint(32.8350, 34.9530), 4326)::geography),
+    ('Kalopanayiotis', '{"Kalopanagiotis", "Καλοπαναγιώτης"}', ST_SetSRID(ST_MakePoint(32.8290, 34.9920), 4326)::geography),
+    ('Omodos', '{"Όμοδος"}', ST_SetSRID(ST_MakePoint(32.8086, 34.8492), 4326)::geography),
+    ('Agros', '{"Αγρός"}', ST_SetSRID(ST_MakePoint(33.0160, 34.9170), 4326)::geography),
+    ('Kyperounta', '{"Κυπερούντα"}', ST_SetSRID(ST_MakePoint(33.0460, 34.9330), 4326)::geography),
+    ('Palechori', '{"Παλαιχώρι"}', ST_SetSRID(ST_MakePoint(33.0850, 34.9260), 4326)::geography),
+    ('Fikardou', '{"Φικάρδου"}', ST_SetSRID(ST_MakePoint(33.1830, 35.0040), 4326)::geography),
+    ('Lefkara', '{"Pano Lefkara", "Λεύκαρα"}', ST_SetSRID(ST_MakePoint(33.3069, 34.8672), 4326)::geography),
+    ('Dali', '{"Idalion", "Δάλι"}', ST_SetSRID(ST_MakePoint(33.4210, 35.0230), 4326)::geography),
+    ('Athienou', '{"Αθηένου"}', ST_SetSRID(ST_MakePoint(33.5420, 35.0610), 4326)::geography),
+    ('Kiti', '{"Κίτι"}', ST_SetSRID(ST_MakePoint(33.5700, 34.8490), 4326)::geography),
+    ('Pissouri', '{"Πισσούρι"}', ST_SetSRID(ST_MakePoint(32.7010, 34.6690), 4326)::geography),
+    ('Peyia', '{"Pegeia", "Πέγεια"}', ST_SetSRID(ST_MakePoint(32.3830, 34.8830), 4326)::geography),
+    ('Polis Chrysochous', '{"Polis", "Πόλις Χρυσοχούς"}', ST_SetSRID(ST_MakePoint(32.4253, 35.0365), 4326)::geography),
+    ('Paralimni', '{"Παραλίμνι"}', ST_SetSRID(ST_MakePoint(33.9820, 35.0378), 4326)::geography),
+    ('Ayia Napa', '{"Agia Napa", "Αγία Νάπα"}', ST_SetSRID(ST_MakePoint(33.9999, 34.9823), 4326)::geography);
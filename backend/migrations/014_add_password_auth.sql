@@ -0,0 +1,39 @@
+-- Password accounts
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(254);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP;
+
+-- Existing name-only users keep their name as username where it is usable
+-- and unique; the rest get "user-<id>". They have no password until they
+-- set one through a reset link, which an administrator can issue for them.
+WITH candidates AS (
+    SELECT id, lower(regexp_replace(name, '[^a-zA-Z0-9_.-]+', '', 'g')) AS base
+    FROM users
+    WHERE username IS NULL AND phone IS NULL
+), ranked AS (
+    SELECT id, base, row_number() OVER (PARTITION BY base ORDER BY id) AS n
+    FROM candidates
+)
+UPDATE users u
+SET username = CASE
+    WHEN length(r.base) BETWEEN 3 AND 32 AND r.n = 1 AND r.base !~ '^user-[0-9]+$' THEN r.base
+    ELSE 'user-' || u.id
+END
+FROM ranked r
+WHERE u.id = r.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(lower(username));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(lower(email));
+
+-- Single-use password reset tokens, stored hashed
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
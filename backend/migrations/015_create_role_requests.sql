@@ -0,0 +1,30 @@
+-- Invite codes that grant a role without review
+CREATE TABLE IF NOT EXISTS role_invites (
+    id SERIAL PRIMARY KEY,
+    code_hash TEXT NOT NULL UNIQUE,
+    role VARCHAR(50) NOT NULL CHECK (role IN ('firefighter')),
+    note TEXT,
+    max_uses INTEGER NOT NULL DEFAULT 1 CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Requests for a role, reviewed by an administrator or approved by an invite
+CREATE TABLE IF NOT EXISTS role_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL CHECK (role IN ('firefighter')),
+    status VARCHAR(50) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied')),
+    message TEXT,
+    invite_id INTEGER REFERENCES role_invites(id) ON DELETE SET NULL,
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_role_requests_pending ON role_requests(user_id) WHERE status = 'pending';
+CREATE INDEX idx_role_requests_status ON role_requests(status, created_at);
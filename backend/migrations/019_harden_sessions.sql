@@ -0,0 +1,27 @@
+-- Session tokens are stored as SHA-256 hashes; the id is now only a public
+-- handle for the session. Existing sessions keep working because their
+-- token was the id.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS token_hash TEXT;
+UPDATE sessions SET token_hash = encode(sha256(convert_to(id::text, 'UTF8')), 'hex') WHERE token_hash IS NULL;
+ALTER TABLE sessions ALTER COLUMN token_hash SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_token_hash ON sessions(token_hash);
+
+-- expires_at slides forward with use; absolute_expires_at caps the lifetime
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS absolute_expires_at TIMESTAMP;
+UPDATE sessions SET absolute_expires_at = expires_at WHERE absolute_expires_at IS NULL;
+ALTER TABLE sessions ALTER COLUMN absolute_expires_at SET NOT NULL;
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP DEFAULT NOW();
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip VARCHAR(64);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT;
+
+-- Refresh tokens are single use. Presenting a used one means it was stolen
+-- or replayed, and ends the session.
+CREATE TABLE IF NOT EXISTS session_refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_session_refresh_tokens_session_id ON session_refresh_tokens(session_id);
@@ -0,0 +1,8 @@
+-- Token buckets shared by all instances when RATE_LIMIT_STORE=postgres
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY, -- <limit name>:<ip|user>:<value>
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
@@ -0,0 +1,13 @@
+-- Reports made without an account have no reporter
+ALTER TABLE fires ADD COLUMN anonymous BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE fires ADD CONSTRAINT fires_anonymous_reporter_check CHECK (NOT anonymous OR reporter_id IS NULL);
+
+-- Proof-of-work challenges handed out for anonymous reports; each is good for one report
+CREATE TABLE IF NOT EXISTS report_challenges (
+    challenge TEXT PRIMARY KEY,
+    difficulty INTEGER NOT NULL, -- Leading zero bits required in SHA-256(challenge || ':' || nonce)
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_report_challenges_expires_at ON report_challenges(expires_at);
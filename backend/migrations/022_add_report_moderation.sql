@@ -0,0 +1,16 @@
+-- Reports from untrusted reporters wait for a firefighter before going public
+ALTER TABLE fires ADD COLUMN moderation_status VARCHAR(20) NOT NULL DEFAULT 'approved'
+    CHECK (moderation_status IN ('pending', 'approved', 'rejected'));
+ALTER TABLE fires ADD COLUMN moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE fires ADD COLUMN moderated_at TIMESTAMP;
+
+-- Firefighters' verdict on whether the report was real; feeds reporter trust
+ALTER TABLE fires ADD COLUMN verdict VARCHAR(20) CHECK (verdict IN ('confirmed', 'false', 'prank'));
+ALTER TABLE fires ADD COLUMN verdict_note TEXT;
+ALTER TABLE fires ADD COLUMN verdict_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE fires ADD COLUMN verdict_at TIMESTAMP;
+
+CREATE INDEX idx_fires_moderation_pending ON fires(created_at) WHERE moderation_status = 'pending';
+
+-- Reports firefighters already acted on count as confirmed
+UPDATE fires SET verdict = 'confirmed', verdict_at = updated_at WHERE status IN ('seen', 'closed');
@@ -0,0 +1,5 @@
+-- Roles are bundles of permissions (see internal/authz); plain users become
+-- citizens, and dispatchers and commanders join firefighters as staff
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+UPDATE users SET role = 'citizen' WHERE role = 'user';
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('citizen', 'firefighter', 'dispatcher', 'commander', 'admin'));
@@ -0,0 +1,23 @@
+-- Agencies sharing the deployment, such as fire services, forestry
+-- departments and civil defence
+CREATE TABLE IF NOT EXISTS organisations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    jurisdiction GEOGRAPHY(POLYGON, 4326),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organisations_name ON organisations(lower(name));
+CREATE INDEX IF NOT EXISTS idx_organisations_jurisdiction ON organisations USING GIST(jurisdiction);
+
+-- Staff belong to at most one organisation and manage only its fires.
+-- Organisations with members cannot be deleted, so staff never silently
+-- lose their organisation
+ALTER TABLE users ADD COLUMN IF NOT EXISTS organisation_id INTEGER REFERENCES organisations(id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_users_organisation_id ON users(organisation_id);
+
+-- The organisation responsible for a fire, picked by jurisdiction when it is
+-- reported; NULL fires are managed by administrators only
+ALTER TABLE fires ADD COLUMN IF NOT EXISTS organisation_id INTEGER REFERENCES organisations(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_fires_organisation_id ON fires(organisation_id);
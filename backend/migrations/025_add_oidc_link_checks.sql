@@ -0,0 +1,27 @@
+-- Single sign-on only links to a local account by email once the email is
+-- known to belong to its owner: a provider vouched for it, or a reset link
+-- sent to it was used
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+UPDATE users u SET email_verified_at = NOW()
+FROM user_identities i
+WHERE i.user_id = u.id AND u.email_verified_at IS NULL AND lower(i.email) = lower(u.email);
+
+-- Links handed out by administrators prove nothing about the email address
+ALTER TABLE password_reset_tokens ADD COLUMN IF NOT EXISTS emailed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Logins started by a signed-in user to link a provider account to theirs
+ALTER TABLE oidc_login_states ADD COLUMN IF NOT EXISTS link_user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+
+-- Provider accounts waiting for the user who started the link to confirm
+-- it with their own session
+CREATE TABLE IF NOT EXISTS oidc_pending_links (
+    code_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email VARCHAR(254),
+    groups TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
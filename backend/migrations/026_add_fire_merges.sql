@@ -0,0 +1,5 @@
+-- Duplicate reports are folded into the fire they describe, which keeps
+-- their comments; the duplicate is closed and points at it
+ALTER TABLE fires ADD COLUMN IF NOT EXISTS merged_into_id INTEGER REFERENCES fires(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_fires_merged_into ON fires(merged_into_id) WHERE merged_into_id IS NOT NULL;
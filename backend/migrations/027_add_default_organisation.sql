@@ -0,0 +1,31 @@
+-- The default organisation looks after fires outside every jurisdiction and
+-- is the organisation of staff who were never given one, so every fire has
+-- staff who may manage it
+ALTER TABLE organisations ADD COLUMN IF NOT EXISTS is_default BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organisations_default ON organisations(is_default) WHERE is_default;
+
+INSERT INTO organisations (name, is_default)
+SELECT 'Default', TRUE
+WHERE NOT EXISTS (SELECT 1 FROM organisations WHERE is_default)
+ON CONFLICT DO NOTHING;
+
+-- An organisation already named Default becomes the default one
+UPDATE organisations SET is_default = TRUE
+WHERE lower(name) = 'default'
+  AND NOT EXISTS (SELECT 1 FROM organisations WHERE is_default);
+
+-- Fires reported before organisations existed go to the organisation with
+-- the smallest jurisdiction containing them, or to the default one
+UPDATE fires f
+SET organisation_id = COALESCE(
+    (SELECT o.id FROM organisations o
+     WHERE ST_Covers(o.jurisdiction, f.location)
+     ORDER BY ST_Area(o.jurisdiction), o.id
+     LIMIT 1),
+    (SELECT id FROM organisations WHERE is_default))
+WHERE f.organisation_id IS NULL;
+
+UPDATE users
+SET organisation_id = (SELECT id FROM organisations WHERE is_default)
+WHERE organisation_id IS NULL
+  AND role IN ('firefighter', 'dispatcher', 'commander');
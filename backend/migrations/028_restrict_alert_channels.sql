@@ -0,0 +1,19 @@
+-- Email and SMS alerts only go to the subscriber's own verified email
+-- address or the phone number they texted in from; channels registered
+-- for anyone else before this was enforced are dropped
+UPDATE area_subscriptions s
+SET channels = (
+    SELECT COALESCE(jsonb_agg(c ORDER BY n), '[]'::jsonb)
+    FROM jsonb_array_elements(s.channels) WITH ORDINALITY AS e(c, n), users u
+    WHERE u.id = s.user_id
+      AND NOT (c->>'type' = 'email'
+               AND (u.email_verified_at IS NULL OR lower(c->>'address') IS DISTINCT FROM lower(u.email)))
+      AND NOT (c->>'type' = 'sms' AND regexp_replace(c->>'address', '^00', '+') IS DISTINCT FROM u.phone)
+), updated_at = NOW()
+WHERE EXISTS (
+    SELECT 1 FROM jsonb_array_elements(s.channels) c
+    WHERE c->>'type' IN ('email', 'sms')
+);
+
+-- Watch areas left without a channel stop alerting
+UPDATE area_subscriptions SET active = FALSE WHERE channels = '[]'::jsonb;
@@ -0,0 +1,8 @@
+-- Sessions from before token hashing kept their bearer token as the id,
+-- which the account's session list shows and revocation takes. They get a
+-- fresh id; the token keeps working through token_hash. Sessions whose
+-- token has been rotated since no longer match and need no change, and
+-- only those have refresh tokens pointing at them.
+UPDATE sessions
+SET id = gen_random_uuid()
+WHERE token_hash = encode(sha256(convert_to(id::text, 'UTF8')), 'hex');
@@ -0,0 +1,44 @@
+'use client';
+
+import { useEffect } from 'react';
+import { useRouter } from 'next/navigation';
+import { useAuth } from '@/lib/auth-context';
+import { apiClient } from '@/lib/api';
+
+// Single sign-on lands here with the session tokens in the URL fragment, or
+// with a code to confirm when a signed-in user linked a provider account.
+export default function AuthCallbackPage() {
+  const { refetch } = useAuth();
+  const router = useRouter();
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    const token = params.get('token');
+    const linkCode = params.get('link_code');
+    const rawReturnTo = params.get('return_to') || '/';
+    const returnTo = rawReturnTo.startsWith('/') && !rawReturnTo.startsWith('//') ? rawReturnTo : '/';
+    window.history.replaceState(null, '', window.location.pathname);
+
+    if (linkCode) {
+      apiClient
+        .confirmOIDCLink(linkCode)
+        .catch((error) => alert(error instanceof Error ? error.message : 'Failed to link account'))
+        .finally(() => router.replace(returnTo));
+      return;
+    }
+
+    if (!token) {
+      router.replace('/login');
+      return;
+    }
+
+    apiClient.setToken(token, params.get('refresh_token'));
+    refetch().then(() => router.replace(returnTo));
+  }, []);
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-gray-50 to-gray-100">
+      <p className="text-gray-600 font-medium">Signing you in...</p>
+    </div>
+  );
+}
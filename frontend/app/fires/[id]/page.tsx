@@ -4,16 +4,17 @@ import { useState, useEffect } from 'react';
 import { useRouter, useParams } from 'next/navigation';
 import { useAuth } from '@/lib/auth-context';
 import { apiClient } from '@/lib/api';
-import type { Fire, Comment } from '@/lib/types';
+import type { Fire, Comment, Organisation } from '@/lib/types';
 
 export default function FireDetailPage() {
-  const { user, loading: authLoading } = useAuth();
+  const { user, can, loading: authLoading } = useAuth();
   const router = useRouter();
   const params = useParams();
   const fireId = parseInt(params.id as string);
 
   const [fire, setFire] = useState<Fire | null>(null);
   const [comments, setComments] = useState<Comment[]>([]);
+  const [organisations, setOrganisations] = useState<Organisation[]>([]);
   const [loading, setLoading] = useState(true);
   const [commentText, setCommentText] = useState('');
   const [submitting, setSubmitting] = useState(false);
@@ -32,12 +33,14 @@ export default function FireDetailPage() {
 
   const fetchData = async () => {
     try {
-      const [fireData, commentsData] = await Promise.all([
+      const [fireData, commentsData, organisationsData] = await Promise.all([
         apiClient.getFire(fireId),
         apiClient.getComments(fireId),
+        apiClient.getOrganisations(),
       ]);
       setFire(fireData.fire);
       setComments(commentsData.comments);
+      setOrganisations(organisationsData.organisations);
     } catch (error) {
       console.error('Failed to fetch fire details:', error);
     } finally {
@@ -55,6 +58,30 @@ export default function FireDetailPage() {
     }
   };
 
+  const handleVerdict = async (verdict: 'false' | 'prank') => {
+    const label = verdict === 'prank' ? 'a prank' : 'a false report';
+    if (!confirm(`Mark this report as ${label}? This lowers the reporter's trust score.`)) {
+      return;
+    }
+    try {
+      const { fire: updatedFire } = await apiClient.setFireVerdict(fireId, verdict);
+      setFire(updatedFire);
+    } catch (error) {
+      console.error('Failed to record verdict:', error);
+      alert('Failed to record verdict');
+    }
+  };
+
+  const handleTransfer = async (organisationId: number | null) => {
+    try {
+      const { fire: updatedFire } = await apiClient.transferFire(fireId, organisationId);
+      setFire(updatedFire);
+    } catch (error) {
+      console.error('Failed to transfer fire:', error);
+      alert('Failed to transfer fire');
+    }
+  };
+
   const handleCommentSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     if (!commentText.trim()) return;
@@ -73,6 +100,18 @@ export default function FireDetailPage() {
     }
   };
 
+  const handleDeleteComment = async (comment: Comment) => {
+    if (!confirm('Delete this comment?')) return;
+
+    try {
+      await apiClient.deleteComment(fireId, comment.id);
+      setComments((prev) => prev.filter((c) => c.id !== comment.id));
+    } catch (error) {
+      console.error('Failed to delete comment:', error);
+      alert('Failed to delete comment');
+    }
+  };
+
   if (authLoading || loading) {
     return (
       <div className="h-screen flex items-center justify-center bg-gradient-to-br from-gray-50 to-gray-100">
@@ -91,6 +130,13 @@ export default function FireDetailPage() {
     return null;
   }
 
+  const organisation = organisations.find((o) => o.id === fire.organisation_id);
+  // Staff only manage their organisation's fires, and staff without one work
+  // for the default organisation; administrators manage all
+  const staffOrganisation = user.organisation_id ?? organisations.find((o) => o.default)?.id;
+  const manages =
+    user.role === 'admin' || (staffOrganisation != null && fire.organisation_id === staffOrganisation);
+
   return (
     <div className="min-h-screen bg-gradient-to-br from-gray-50 to-gray-100">
       <div className="max-w-4xl mx-auto px-4 sm:px-6 lg:px-8 py-8">
@@ -160,14 +206,20 @@ export default function FireDetailPage() {
                   <span className="text-2xl">👤</span>
                   <div className="flex-1">
                     <span className="text-sm font-semibold text-purple-900 uppercase tracking-wide">Reported By</span>
-                    <p className="text-gray-900 mt-2 font-medium">{fire.reporter?.name}</p>
+                    <p className="text-gray-900 mt-2 font-medium">{fire.anonymous ? 'Anonymous report' : fire.reporter?.name}</p>
                   </div>
                 </div>
               </div>
             </div>
           </div>
 
-          {user.role === 'firefighter' && fire.status !== 'closed' && (
+          {organisation && (
+            <p className="text-sm text-gray-600 mb-6">
+              🏢 Handled by <span className="font-semibold">{organisation.name}</span>
+            </p>
+          )}
+
+          {manages && can('fire.status.update') && fire.status !== 'closed' && (
             <div className="flex flex-wrap gap-3 pt-6 border-t border-gray-200">
               {fire.status === 'reported' && (
                 <button
@@ -185,6 +237,51 @@ export default function FireDetailPage() {
               </button>
             </div>
           )}
+
+          {manages && can('fire.moderate') && (
+            <div className="flex flex-wrap items-center gap-3 pt-6 mt-6 border-t border-gray-200">
+              {fire.verdict ? (
+                <span className="text-sm text-gray-600">
+                  Verdict: <span className="font-semibold capitalize">{fire.verdict === 'false' ? 'false report' : fire.verdict}</span>
+                </span>
+              ) : (
+                <>
+                  <button
+                    onClick={() => handleVerdict('false')}
+                    className="px-4 py-2 rounded-lg text-sm font-medium bg-white text-gray-700 hover:bg-gray-50 border border-gray-200"
+                  >
+                    🚫 False Report
+                  </button>
+                  <button
+                    onClick={() => handleVerdict('prank')}
+                    className="px-4 py-2 rounded-lg text-sm font-medium bg-white text-gray-700 hover:bg-gray-50 border border-gray-200"
+                  >
+                    🃏 Prank
+                  </button>
+                </>
+              )}
+            </div>
+          )}
+
+          {manages && can('fire.transfer') && organisations.length > 0 && (
+            <div className="flex flex-wrap items-center gap-3 pt-6 mt-6 border-t border-gray-200">
+              <label htmlFor="organisation" className="text-sm text-gray-600">
+                Hand over to
+              </label>
+              <select
+                id="organisation"
+                value={fire.organisation_id ?? ''}
+                onChange={(e) => handleTransfer(e.target.value ? parseInt(e.target.value) : null)}
+                className="px-3 py-2 rounded-lg text-sm border border-gray-200 bg-white text-gray-700"
+              >
+                {organisations.map((o) => (
+                  <option key={o.id} value={o.id}>
+                    {o.name}
+                  </option>
+                ))}
+              </select>
+            </div>
+          )}
         </div>
 
         <div className="bg-white shadow-card rounded-2xl p-8 border border-gray-100">
@@ -245,6 +342,14 @@ export default function FireDetailPage() {
                       <span className="text-xs text-gray-500">
                         {new Date(comment.created_at).toLocaleString()}
                       </span>
+                      {can('comment.delete') && (
+                        <button
+                          onClick={() => handleDeleteComment(comment)}
+                          className="ml-auto text-xs text-gray-500 hover:text-red-600 font-medium"
+                        >
+                          Delete
+                        </button>
+                      )}
                     </div>
                     <p className="text-gray-700 leading-relaxed">{comment.text}</p>
                   </div>
@@ -155,7 +155,7 @@ export default function FiresPage() {
               <div className="space-y-2 text-xs">
                 <div className="flex items-center gap-2 text-gray-600">
                   <span className="text-sm">👤</span>
-                  <span className="font-medium">{fire.reporter?.name}</span>
+                  <span className="font-medium">{fire.anonymous ? 'Anonymous' : fire.reporter?.name}</span>
                 </div>
                 <div className="flex items-center gap-2 text-gray-600">
                   <span className="text-sm">📍</span>
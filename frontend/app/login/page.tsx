@@ -1,24 +1,55 @@
 'use client';
 
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
+import Link from 'next/link';
 import { useRouter } from 'next/navigation';
 import { useAuth } from '@/lib/auth-context';
+import { apiClient } from '@/lib/api';
+
+const inputClassName =
+  'w-full px-4 py-3 border border-gray-300 rounded-xl focus:outline-none focus:ring-2 focus:ring-red-500 focus:border-transparent transition-all text-gray-900 placeholder-gray-400';
 
 export default function LoginPage() {
+  const [mode, setMode] = useState<'login' | 'register'>('login');
+  const [username, setUsername] = useState('');
+  const [password, setPassword] = useState('');
   const [name, setName] = useState('');
+  const [email, setEmail] = useState('');
   const [role, setRole] = useState<'user' | 'firefighter'>('user');
+  const [inviteCode, setInviteCode] = useState('');
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState('');
-  const { login } = useAuth();
+  const [ssoName, setSsoName] = useState<string | null>(null);
+  const { login, register, refetch } = useAuth();
   const router = useRouter();
 
+  useEffect(() => {
+    const ssoError = new URLSearchParams(window.location.search).get('sso_error');
+    if (ssoError) {
+      setError(ssoError);
+    }
+    apiClient
+      .getOIDCConfig()
+      .then((config) => setSsoName(config.enabled ? config.name || 'single sign-on' : null))
+      .catch(() => setSsoName(null));
+  }, []);
+
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     setError('');
     setLoading(true);
 
     try {
-      await login(name, role);
+      if (mode === 'register') {
+        await register({ username, password, name: name || undefined, email: email || undefined });
+        if (role === 'firefighter') {
+          // Without an invite code this files a request for review.
+          await apiClient.requestRole({ role, invite_code: inviteCode || undefined });
+          await refetch();
+        }
+      } else {
+        await login(username, password);
+      }
       router.push('/');
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Login failed');
@@ -36,62 +67,134 @@ export default function LoginPage() {
               <span className="text-3xl">🔥</span>
             </div>
             <h2 className="text-3xl font-bold text-gray-900">
-              Welcome Back
+              {mode === 'register' ? 'Create Account' : 'Welcome Back'}
             </h2>
             <p className="mt-2 text-sm text-gray-600">
-              Sign in to Cyprus Fire Tracker
+              {mode === 'register' ? 'Join Cyprus Fire Tracker' : 'Sign in to Cyprus Fire Tracker'}
             </p>
           </div>
 
           <form className="space-y-5" onSubmit={handleSubmit}>
             <div className="space-y-4">
               <div>
-                <label htmlFor="name" className="block text-sm font-medium text-gray-700 mb-2">
-                  Your Name
+                <label htmlFor="username" className="block text-sm font-medium text-gray-700 mb-2">
+                  Username
                 </label>
                 <input
-                  id="name"
-                  name="name"
+                  id="username"
+                  name="username"
                   type="text"
+                  autoComplete="username"
                   required
-                  className="w-full px-4 py-3 border border-gray-300 rounded-xl focus:outline-none focus:ring-2 focus:ring-red-500 focus:border-transparent transition-all text-gray-900 placeholder-gray-400"
-                  placeholder="Enter your name"
-                  value={name}
-                  onChange={(e) => setName(e.target.value)}
+                  className={inputClassName}
+                  placeholder="Enter your username"
+                  value={username}
+                  onChange={(e) => setUsername(e.target.value)}
                 />
               </div>
 
               <div>
-                <label htmlFor="role" className="block text-sm font-medium text-gray-700 mb-2">
-                  Select Your Role
+                <label htmlFor="password" className="block text-sm font-medium text-gray-700 mb-2">
+                  Password
                 </label>
-                <div className="grid grid-cols-2 gap-3">
-                  <button
-                    type="button"
-                    onClick={() => setRole('user')}
-                    className={`px-4 py-3 rounded-xl border-2 font-medium transition-all ${
-                      role === 'user'
-                        ? 'border-red-500 bg-red-50 text-red-700'
-                        : 'border-gray-200 bg-white text-gray-700 hover:border-gray-300'
-                    }`}
-                  >
-                    <div className="text-2xl mb-1">👤</div>
-                    <div className="text-sm">Citizen</div>
-                  </button>
-                  <button
-                    type="button"
-                    onClick={() => setRole('firefighter')}
-                    className={`px-4 py-3 rounded-xl border-2 font-medium transition-all ${
-                      role === 'firefighter'
-                        ? 'border-red-500 bg-red-50 text-red-700'
-                        : 'border-gray-200 bg-white text-gray-700 hover:border-gray-300'
-                    }`}
-                  >
-                    <div className="text-2xl mb-1">🚒</div>
-                    <div className="text-sm">Firefighter</div>
-                  </button>
-                </div>
+                <input
+                  id="password"
+                  name="password"
+                  type="password"
+                  autoComplete={mode === 'login' ? 'current-password' : 'new-password'}
+                  required
+                  minLength={mode === 'login' ? undefined : 8}
+                  className={inputClassName}
+                  placeholder={mode === 'login' ? 'Enter your password' : 'At least 8 characters'}
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                />
               </div>
+
+              {mode === 'register' && (
+                <>
+                  <div>
+                    <label htmlFor="name" className="block text-sm font-medium text-gray-700 mb-2">
+                      Display Name
+                    </label>
+                    <input
+                      id="name"
+                      name="name"
+                      type="text"
+                      className={inputClassName}
+                      placeholder="Defaults to your username"
+                      value={name}
+                      onChange={(e) => setName(e.target.value)}
+                    />
+                  </div>
+
+                  <div>
+                    <label htmlFor="email" className="block text-sm font-medium text-gray-700 mb-2">
+                      Email
+                    </label>
+                    <input
+                      id="email"
+                      name="email"
+                      type="email"
+                      autoComplete="email"
+                      className={inputClassName}
+                      placeholder="Optional, for password resets"
+                      value={email}
+                      onChange={(e) => setEmail(e.target.value)}
+                    />
+                  </div>
+
+                  <div>
+                    <label htmlFor="role" className="block text-sm font-medium text-gray-700 mb-2">
+                      Select Your Role
+                    </label>
+                    <div className="grid grid-cols-2 gap-3">
+                      <button
+                        type="button"
+                        onClick={() => setRole('user')}
+                        className={`px-4 py-3 rounded-xl border-2 font-medium transition-all ${
+                          role === 'user'
+                            ? 'border-red-500 bg-red-50 text-red-700'
+                            : 'border-gray-200 bg-white text-gray-700 hover:border-gray-300'
+                        }`}
+                      >
+                        <div className="text-2xl mb-1">👤</div>
+                        <div className="text-sm">Citizen</div>
+                      </button>
+                      <button
+                        type="button"
+                        onClick={() => setRole('firefighter')}
+                        className={`px-4 py-3 rounded-xl border-2 font-medium transition-all ${
+                          role === 'firefighter'
+                            ? 'border-red-500 bg-red-50 text-red-700'
+                            : 'border-gray-200 bg-white text-gray-700 hover:border-gray-300'
+                        }`}
+                      >
+                        <div className="text-2xl mb-1">🚒</div>
+                        <div className="text-sm">Firefighter</div>
+                      </button>
+                    </div>
+                  </div>
+
+                  {role === 'firefighter' && (
+                    <div>
+                      <label htmlFor="invite-code" className="block text-sm font-medium text-gray-700 mb-2">
+                        Invite Code
+                      </label>
+                      <input
+                        id="invite-code"
+                        name="invite-code"
+                        type="text"
+                        autoComplete="off"
+                        className={inputClassName}
+                        placeholder="Leave empty to request approval"
+                        value={inviteCode}
+                        onChange={(e) => setInviteCode(e.target.value)}
+                      />
+                    </div>
+                  )}
+                </>
+              )}
             </div>
 
             {error && (
@@ -111,14 +214,49 @@ export default function LoginPage() {
                     <circle className="opacity-25" cx="12" cy="12" r="10" stroke="currentColor" strokeWidth="4" fill="none" />
                     <path className="opacity-75" fill="currentColor" d="M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z" />
                   </svg>
-                  Signing in...
+                  {mode === 'login' ? 'Signing in...' : 'Saving...'}
                 </span>
+              ) : mode === 'register' ? (
+                'Create Account'
               ) : (
                 'Sign In'
               )}
             </button>
           </form>
 
+          {mode === 'login' && ssoName && (
+            <a
+              href={apiClient.oidcLoginURL()}
+              className="block w-full py-3 px-4 text-center border-2 border-gray-200 hover:border-gray-300 bg-white text-gray-800 font-medium rounded-xl transition-all"
+            >
+              🚒 Sign in with {ssoName}
+            </a>
+          )}
+
+          <div className="flex justify-between text-sm">
+            <button
+              type="button"
+              onClick={() => {
+                setError('');
+                setMode(mode === 'login' ? 'register' : 'login');
+              }}
+              className="text-red-600 hover:text-red-700 font-medium"
+            >
+              {mode === 'login' ? 'Create an account' : 'I already have an account'}
+            </button>
+            {mode === 'login' && (
+              <Link href="/reset-password" className="text-gray-600 hover:text-gray-800">
+                Forgot password?
+              </Link>
+            )}
+          </div>
+
+          <div className="text-center">
+            <Link href="/report" className="text-sm text-red-600 hover:text-red-700 font-medium">
+              🔥 Report a fire without an account
+            </Link>
+          </div>
+
           <div className="text-center pt-4 border-t border-gray-200">
             <p className="text-xs text-gray-500">
               🚨 Emergency Response System • Cyprus Fire Tracker
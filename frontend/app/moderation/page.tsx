@@ -0,0 +1,143 @@
+'use client';
+
+import { useState, useEffect } from 'react';
+import { useRouter } from 'next/navigation';
+import { useAuth } from '@/lib/auth-context';
+import { apiClient } from '@/lib/api';
+import type { Fire } from '@/lib/types';
+
+export default function ModerationPage() {
+  const { user, can, loading: authLoading } = useAuth();
+  const router = useRouter();
+  const [fires, setFires] = useState<Fire[]>([]);
+  const [loading, setLoading] = useState(true);
+
+  const isStaff = can('fire.moderate');
+
+  useEffect(() => {
+    if (!authLoading && !isStaff) {
+      router.push(user ? '/' : '/login');
+    }
+  }, [user, isStaff, authLoading, router]);
+
+  useEffect(() => {
+    if (isStaff) {
+      fetchQueue();
+    }
+  }, [isStaff]);
+
+  const fetchQueue = async () => {
+    try {
+      const { fires } = await apiClient.getModerationQueue();
+      setFires(fires);
+    } catch (error) {
+      console.error('Failed to fetch moderation queue:', error);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleApprove = async (fire: Fire) => {
+    try {
+      await apiClient.approveFire(fire.id);
+      setFires((prev) => prev.filter((f) => f.id !== fire.id));
+    } catch (error) {
+      console.error('Failed to approve report:', error);
+      alert('Failed to approve report');
+    }
+  };
+
+  const handleReject = async (fire: Fire, verdict: 'false' | 'prank') => {
+    try {
+      await apiClient.rejectFire(fire.id, verdict);
+      setFires((prev) => prev.filter((f) => f.id !== fire.id));
+    } catch (error) {
+      console.error('Failed to reject report:', error);
+      alert('Failed to reject report');
+    }
+  };
+
+  if (authLoading || loading) {
+    return (
+      <div className="h-screen flex items-center justify-center bg-gradient-to-br from-gray-50 to-gray-100">
+        <p className="text-gray-600 font-medium">Loading moderation queue...</p>
+      </div>
+    );
+  }
+
+  if (!isStaff) {
+    return null;
+  }
+
+  return (
+    <div className="min-h-screen bg-gradient-to-br from-gray-50 to-gray-100">
+      <div className="max-w-4xl mx-auto px-4 sm:px-6 lg:px-8 py-8">
+        <div className="mb-8">
+          <h1 className="text-3xl font-bold text-gray-900">Moderation Queue</h1>
+          <p className="text-sm text-gray-600 mt-0.5">
+            Reports from new, anonymous or low-trust reporters wait here before they go public
+          </p>
+        </div>
+
+        {fires.length === 0 && (
+          <div className="bg-white shadow-card rounded-2xl p-8 border border-gray-100 text-center text-gray-600">
+            ✓ Nothing to review
+          </div>
+        )}
+
+        <div className="space-y-4">
+          {fires.map((fire) => (
+            <div key={fire.id} className="bg-white shadow-card rounded-2xl p-6 border border-gray-100">
+              <div className="flex justify-between items-start gap-4 mb-3">
+                <div>
+                  <h3 className="text-lg font-bold text-gray-900">Fire #{fire.id}</h3>
+                  <p className="text-xs text-gray-500">{new Date(fire.created_at).toLocaleString()}</p>
+                </div>
+                <div className="text-right text-xs text-gray-600">
+                  {fire.anonymous || !fire.reporter ? (
+                    <span className="font-medium">Anonymous</span>
+                  ) : (
+                    <>
+                      <div className="font-medium">{fire.reporter.name}</div>
+                      {fire.reporter.trust && (
+                        <div>
+                          Trust {Math.round(fire.reporter.trust.score * 100)}% ·{' '}
+                          {fire.reporter.trust.confirmed} confirmed,{' '}
+                          {fire.reporter.trust.false + fire.reporter.trust.pranks} dismissed
+                        </div>
+                      )}
+                    </>
+                  )}
+                </div>
+              </div>
+              <p className="text-sm text-gray-700 mb-2">{fire.description}</p>
+              <p className="text-xs font-mono text-gray-500 mb-4">
+                {fire.latitude.toFixed(4)}, {fire.longitude.toFixed(4)}
+              </p>
+              <div className="flex flex-wrap gap-2">
+                <button
+                  onClick={() => handleApprove(fire)}
+                  className="px-4 py-2 rounded-lg text-sm font-medium bg-gradient-to-r from-green-500 to-green-600 text-white shadow-md"
+                >
+                  ✓ Approve
+                </button>
+                <button
+                  onClick={() => handleReject(fire, 'false')}
+                  className="px-4 py-2 rounded-lg text-sm font-medium bg-white text-gray-700 hover:bg-gray-50 border border-gray-200"
+                >
+                  🚫 False Report
+                </button>
+                <button
+                  onClick={() => handleReject(fire, 'prank')}
+                  className="px-4 py-2 rounded-lg text-sm font-medium bg-white text-gray-700 hover:bg-gray-50 border border-gray-200"
+                >
+                  🃏 Prank
+                </button>
+              </div>
+            </div>
+          ))}
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -28,6 +28,14 @@ export default function HomePage() {
     fetchFires();
   }, []);
 
+  useEffect(() => {
+    return apiClient.subscribeEvents((type) => {
+      if (!type.startsWith('comment.')) {
+        fetchFires();
+      }
+    });
+  }, []);
+
   const fetchFires = async () => {
     try {
       const { fires } = await apiClient.getFires();
@@ -46,7 +54,10 @@ export default function HomePage() {
     }
   };
 
-  const handleFormSuccess = () => {
+  const handleFormSuccess = (fire: Fire) => {
+    if (fire.moderation_status === 'pending') {
+      alert('Thank you! Your report will appear on the map once a firefighter has reviewed it.');
+    }
     setShowForm(false);
     setSelectedLocation(null);
     fetchFires();
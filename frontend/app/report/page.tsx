@@ -0,0 +1,87 @@
+'use client';
+
+import { useState, useEffect } from 'react';
+import Link from 'next/link';
+import { FireMap } from '@/components/map/fire-map';
+import { FireForm } from '@/components/fires/fire-form';
+import { apiClient } from '@/lib/api';
+import type { Fire } from '@/lib/types';
+
+// Reporting without an account, for emergencies where signing up first
+// would cost time.
+export default function AnonymousReportPage() {
+  const [fires, setFires] = useState<Fire[]>([]);
+  const [submitted, setSubmitted] = useState<Fire | null>(null);
+  const [selectedLocation, setSelectedLocation] = useState<{ lat: number; lng: number } | null>(
+    null
+  );
+
+  useEffect(() => {
+    apiClient
+      .getFires()
+      .then(({ fires }) => setFires(fires))
+      .catch((error) => console.error('Failed to fetch fires:', error));
+  }, []);
+
+  const handleSuccess = (fire: Fire) => {
+    setSelectedLocation(null);
+    setSubmitted(fire);
+  };
+
+  return (
+    <div className="h-[calc(100vh-64px)] flex">
+      <div className="flex-1 relative">
+        <FireMap
+          fires={fires}
+          onMapClick={(lat, lng) => setSelectedLocation({ lat, lng })}
+          selectedLocation={selectedLocation}
+        />
+      </div>
+      <div className="w-96 bg-white border-l border-gray-200 shadow-xl overflow-y-auto">
+        <div className="sticky top-0 bg-white border-b border-gray-200 px-6 py-4 z-10">
+          <div className="flex items-center gap-3">
+            <div className="w-10 h-10 bg-gradient-to-br from-red-500 to-orange-600 rounded-xl flex items-center justify-center shadow-md">
+              <span className="text-xl">🔥</span>
+            </div>
+            <div>
+              <h2 className="text-xl font-bold text-gray-900">Report a Fire</h2>
+              <p className="text-xs text-gray-500">No account needed</p>
+            </div>
+          </div>
+        </div>
+        <div className="p-6 space-y-6">
+          {submitted ? (
+            <div className="rounded-xl bg-green-50 border-2 border-green-200 p-4 space-y-3">
+              <p className="text-sm text-green-800 font-medium">
+                ✓ Thank you, your report was sent to the fire service.
+                {submitted.moderation_status === 'pending' &&
+                  ' It will appear on the map once a firefighter has reviewed it.'}
+              </p>
+              <button
+                onClick={() => setSubmitted(null)}
+                className="text-sm text-green-700 hover:text-green-800 font-medium"
+              >
+                Report another fire
+              </button>
+            </div>
+          ) : (
+            <FireForm
+              anonymous
+              selectedLocation={selectedLocation}
+              onLocationSelect={setSelectedLocation}
+              onSuccess={handleSuccess}
+            />
+          )}
+          <p className="text-xs text-gray-500">
+            Your browser solves a short anti-spam puzzle before the report is sent. For life-threatening
+            emergencies call 112.{' '}
+            <Link href="/login" className="text-red-600 hover:text-red-700 font-medium">
+              Sign in
+            </Link>{' '}
+            to follow your reports and get alerts.
+          </p>
+        </div>
+      </div>
+    </div>
+  );
+}
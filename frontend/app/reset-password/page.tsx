@@ -0,0 +1,104 @@
+'use client';
+
+import { Suspense, useState } from 'react';
+import Link from 'next/link';
+import { useSearchParams } from 'next/navigation';
+import { apiClient } from '@/lib/api';
+
+const inputClassName =
+  'w-full px-4 py-3 border border-gray-300 rounded-xl focus:outline-none focus:ring-2 focus:ring-red-500 focus:border-transparent transition-all text-gray-900 placeholder-gray-400';
+
+function ResetPasswordForm() {
+  const token = useSearchParams().get('token');
+  const [value, setValue] = useState('');
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState('');
+  const [done, setDone] = useState(false);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setLoading(true);
+
+    try {
+      if (token) {
+        await apiClient.confirmPasswordReset(token, value);
+      } else {
+        await apiClient.requestPasswordReset(value);
+      }
+      setDone(true);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Request failed');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  if (done) {
+    return (
+      <div className="space-y-4 text-center">
+        <p className="text-gray-700">
+          {token
+            ? 'Your password has been changed. Sign in with your new password.'
+            : 'If the account has an email address, a reset link is on its way.'}
+        </p>
+        <Link href="/login" className="text-red-600 hover:text-red-700 font-medium">
+          Back to sign in
+        </Link>
+      </div>
+    );
+  }
+
+  return (
+    <form className="space-y-5" onSubmit={handleSubmit}>
+      <div>
+        <label htmlFor="value" className="block text-sm font-medium text-gray-700 mb-2">
+          {token ? 'New Password' : 'Username'}
+        </label>
+        <input
+          id="value"
+          name="value"
+          type={token ? 'password' : 'text'}
+          autoComplete={token ? 'new-password' : 'username'}
+          required
+          minLength={token ? 8 : undefined}
+          className={inputClassName}
+          placeholder={token ? 'At least 8 characters' : 'Enter your username'}
+          value={value}
+          onChange={(e) => setValue(e.target.value)}
+        />
+      </div>
+
+      {error && (
+        <div className="rounded-xl bg-red-50 border border-red-200 p-4">
+          <p className="text-sm text-red-800 font-medium">{error}</p>
+        </div>
+      )}
+
+      <button
+        type="submit"
+        disabled={loading}
+        className="w-full py-3 px-4 bg-gradient-to-r from-red-500 to-orange-600 hover:from-red-600 hover:to-orange-700 text-white font-medium rounded-xl shadow-lg hover:shadow-xl transition-all disabled:opacity-50 disabled:cursor-not-allowed"
+      >
+        {loading ? 'Please wait...' : token ? 'Set New Password' : 'Send Reset Link'}
+      </button>
+    </form>
+  );
+}
+
+export default function ResetPasswordPage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-gray-50 to-gray-100 py-12 px-4 sm:px-6 lg:px-8">
+      <div className="max-w-md w-full">
+        <div className="bg-white rounded-2xl shadow-xl p-8 space-y-8">
+          <div className="text-center">
+            <h2 className="text-3xl font-bold text-gray-900">Reset Password</h2>
+          </div>
+          <Suspense fallback={null}>
+            <ResetPasswordForm />
+          </Suspense>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,160 @@
+'use client';
+
+import { useState, useEffect } from 'react';
+import { useRouter } from 'next/navigation';
+import { useAuth } from '@/lib/auth-context';
+import { apiClient } from '@/lib/api';
+import type { DeviceSession } from '@/lib/types';
+
+export default function SessionsPage() {
+  const { user, loading: authLoading } = useAuth();
+  const router = useRouter();
+  const [sessions, setSessions] = useState<DeviceSession[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+  const [ssoName, setSSOName] = useState<string | null>(null);
+
+  useEffect(() => {
+    if (!authLoading && !user) {
+      router.push('/login');
+    }
+  }, [user, authLoading, router]);
+
+  useEffect(() => {
+    if (user) {
+      fetchSessions();
+      apiClient
+        .getOIDCConfig()
+        .then((config) => setSSOName(config.enabled ? config.name || 'single sign-on' : null))
+        .catch(() => setSSOName(null));
+    }
+  }, [user]);
+
+  const handleLinkSSO = async () => {
+    try {
+      const { url } = await apiClient.startOIDCLink('/sessions');
+      window.location.href = url;
+    } catch (error) {
+      console.error('Failed to start linking:', error);
+      setError('Failed to start linking');
+    }
+  };
+
+  const fetchSessions = async () => {
+    try {
+      const { sessions } = await apiClient.getSessions();
+      setSessions(sessions);
+    } catch (error) {
+      console.error('Failed to fetch sessions:', error);
+      setError('Failed to load sessions');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleRevoke = async (session: DeviceSession) => {
+    try {
+      await apiClient.revokeSession(session.id);
+      if (session.current) {
+        window.location.href = '/login';
+        return;
+      }
+      setSessions((prev) => prev.filter((s) => s.id !== session.id));
+    } catch (error) {
+      console.error('Failed to revoke session:', error);
+      setError('Failed to revoke session');
+    }
+  };
+
+  const handleLogoutAll = async () => {
+    if (!confirm('Sign out of every device, including this one?')) {
+      return;
+    }
+    try {
+      await apiClient.logoutAll();
+    } finally {
+      window.location.href = '/login';
+    }
+  };
+
+  if (authLoading || loading) {
+    return (
+      <div className="h-screen flex items-center justify-center bg-gradient-to-br from-gray-50 to-gray-100">
+        <p className="text-gray-600 font-medium">Loading sessions...</p>
+      </div>
+    );
+  }
+
+  if (!user) {
+    return null;
+  }
+
+  return (
+    <div className="min-h-screen bg-gradient-to-br from-gray-50 to-gray-100">
+      <div className="max-w-3xl mx-auto px-4 sm:px-6 lg:px-8 py-8">
+        <div className="flex flex-col sm:flex-row justify-between items-start sm:items-center gap-4 mb-8">
+          <div>
+            <h1 className="text-3xl font-bold text-gray-900">Signed-in Devices</h1>
+            <p className="text-sm text-gray-600 mt-0.5">Review and revoke active sessions on your account</p>
+          </div>
+          <div className="flex gap-2">
+            {ssoName && (
+              <button
+                onClick={handleLinkSSO}
+                className="px-4 py-2 rounded-lg text-sm font-medium bg-white text-gray-700 hover:bg-gray-50 border border-gray-200"
+              >
+                Link {ssoName}
+              </button>
+            )}
+            <button
+              onClick={handleLogoutAll}
+              className="px-4 py-2 rounded-lg text-sm font-medium bg-gradient-to-r from-red-500 to-red-600 text-white shadow-md hover:shadow-lg transition-all"
+            >
+              Log out everywhere
+            </button>
+          </div>
+        </div>
+
+        {error && (
+          <div className="mb-4 rounded-lg bg-red-50 border border-red-200 px-4 py-3 text-sm text-red-700">
+            {error}
+          </div>
+        )}
+
+        <div className="space-y-4">
+          {sessions.map((session) => (
+            <div
+              key={session.id}
+              className="bg-white shadow-card rounded-2xl p-6 border border-gray-100 flex justify-between items-start gap-4"
+            >
+              <div className="min-w-0">
+                <div className="flex items-center gap-2">
+                  <h3 className="text-sm font-bold text-gray-900 truncate">
+                    {session.user_agent || 'Unknown device'}
+                  </h3>
+                  {session.current && (
+                    <span className="px-2 py-0.5 rounded-full text-xs font-medium bg-green-100 text-green-700">
+                      This device
+                    </span>
+                  )}
+                </div>
+                <p className="text-xs text-gray-500 mt-1">
+                  {session.ip || 'Unknown IP'} · Last active {new Date(session.last_seen_at).toLocaleString()}
+                </p>
+                <p className="text-xs text-gray-400 mt-0.5">
+                  Signed in {new Date(session.created_at).toLocaleString()}
+                </p>
+              </div>
+              <button
+                onClick={() => handleRevoke(session)}
+                className="px-3 py-1.5 rounded-lg text-xs font-medium bg-white text-gray-700 hover:bg-gray-50 border border-gray-200"
+              >
+                {session.current ? 'Log out' : 'Revoke'}
+              </button>
+            </div>
+          ))}
+        </div>
+      </div>
+    </div>
+  );
+}
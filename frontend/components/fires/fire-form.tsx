@@ -2,14 +2,16 @@
 
 import { useState } from 'react';
 import { apiClient } from '@/lib/api';
+import type { Fire } from '@/lib/types';
 
 interface FireFormProps {
-  onSuccess?: () => void;
+  onSuccess?: (fire: Fire) => void;
   selectedLocation: { lat: number; lng: number } | null;
   onLocationSelect: (lat: number, lng: number) => void;
+  anonymous?: boolean;
 }
 
-export function FireForm({ onSuccess, selectedLocation, onLocationSelect }: FireFormProps) {
+export function FireForm({ onSuccess, selectedLocation, onLocationSelect, anonymous = false }: FireFormProps) {
   const [description, setDescription] = useState('');
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState('');
@@ -25,14 +27,17 @@ export function FireForm({ onSuccess, selectedLocation, onLocationSelect }: Fire
     setLoading(true);
 
     try {
-      await apiClient.createFire({
+      const report = {
         latitude: selectedLocation.lat,
         longitude: selectedLocation.lng,
         description,
-      });
+      };
+      const { fire } = anonymous
+        ? await apiClient.reportAnonymously(report)
+        : await apiClient.createFire(report);
       setDescription('');
       onLocationSelect(0, 0);
-      if (onSuccess) onSuccess();
+      if (onSuccess) onSuccess(fire);
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Failed to report fire');
     } finally {
@@ -105,7 +110,7 @@ export function FireForm({ onSuccess, selectedLocation, onLocationSelect }: Fire
               <circle className="opacity-25" cx="12" cy="12" r="10" stroke="currentColor" strokeWidth="4" fill="none" />
               <path className="opacity-75" fill="currentColor" d="M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z" />
             </svg>
-            Reporting Fire...
+            {anonymous ? 'Verifying and Reporting...' : 'Reporting Fire...'}
           </span>
         ) : (
           <span className="flex items-center justify-center gap-2">
@@ -5,7 +5,7 @@ import { useAuth } from '@/lib/auth-context';
 import { usePathname } from 'next/navigation';
 
 export function Header() {
-  const { user, logout } = useAuth();
+  const { user, can, logout } = useAuth();
   const pathname = usePathname();
 
   const isActive = (path: string) => pathname === path;
@@ -48,6 +48,28 @@ export function Header() {
                 >
                   📋 All Fires
                 </Link>
+                <Link
+                  href="/sessions"
+                  className={`px-4 py-2 rounded-lg text-sm font-medium transition-colors ${
+                    isActive('/sessions')
+                      ? 'bg-red-50 text-red-700'
+                      : 'text-gray-600 hover:text-gray-900 hover:bg-gray-50'
+                  }`}
+                >
+                  🔐 Devices
+                </Link>
+                {can('fire.moderate') && (
+                  <Link
+                    href="/moderation"
+                    className={`px-4 py-2 rounded-lg text-sm font-medium transition-colors ${
+                      isActive('/moderation')
+                        ? 'bg-red-50 text-red-700'
+                        : 'text-gray-600 hover:text-gray-900 hover:bg-gray-50'
+                    }`}
+                  >
+                    🛡️ Moderation
+                  </Link>
+                )}
               </nav>
             )}
           </div>
@@ -67,6 +89,21 @@ export function Header() {
                         🚒 Firefighter
                       </span>
                     )}
+                    {user.role === 'dispatcher' && (
+                      <span className="text-xs text-red-600 font-medium leading-none mt-0.5">
+                        📞 Dispatcher
+                      </span>
+                    )}
+                    {user.role === 'commander' && (
+                      <span className="text-xs text-red-600 font-medium leading-none mt-0.5">
+                        ⭐ Commander
+                      </span>
+                    )}
+                    {user.role === 'admin' && (
+                      <span className="text-xs text-blue-600 font-medium leading-none mt-0.5">
+                        🛡️ Admin
+                      </span>
+                    )}
                   </div>
                 </div>
                 <button
@@ -1,28 +1,64 @@
-import { User, Fire, Comment, Session } from './types';
+import { User, Permission, Fire, Comment, Session, RegisterData, RoleRequest, DeviceSession, ReportChallenge, FireVerdict, Organisation } from './types';
+import { solveChallenge } from './pow';
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
 
 class ApiClient {
   private token: string | null = null;
+  private refreshToken: string | null = null;
+  private refreshing: Promise<boolean> | null = null;
 
   constructor() {
     if (typeof window !== 'undefined') {
       this.token = localStorage.getItem('auth_token');
+      this.refreshToken = localStorage.getItem('refresh_token');
     }
   }
 
-  setToken(token: string | null) {
+  setToken(token: string | null, refreshToken: string | null = null) {
     this.token = token;
+    this.refreshToken = token ? refreshToken : null;
     if (typeof window !== 'undefined') {
-      if (token) {
-        localStorage.setItem('auth_token', token);
-      } else {
-        localStorage.removeItem('auth_token');
+      for (const [key, value] of [
+        ['auth_token', this.token],
+        ['refresh_token', this.refreshToken],
+      ] as const) {
+        if (value) {
+          localStorage.setItem(key, value);
+        } else {
+          localStorage.removeItem(key);
+        }
       }
     }
   }
 
-  private async request<T>(endpoint: string, options: RequestInit = {}): Promise<T> {
+  // Refresh tokens are single use, so concurrent requests share one refresh.
+  private refreshSession(): Promise<boolean> {
+    if (!this.refreshing) {
+      const refreshToken = this.refreshToken;
+      this.refreshing = fetch(`${API_URL}/api/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      })
+        .then(async (response) => {
+          if (!response.ok) {
+            this.setToken(null);
+            return false;
+          }
+          const session: Session = await response.json();
+          this.setToken(session.token, session.refresh_token);
+          return true;
+        })
+        .catch(() => false)
+        .finally(() => {
+          this.refreshing = null;
+        });
+    }
+    return this.refreshing;
+  }
+
+  private async request<T>(endpoint: string, options: RequestInit = {}, retry = true): Promise<T> {
     const headers: HeadersInit = {
       'Content-Type': 'application/json',
       ...options.headers,
@@ -37,6 +73,18 @@ class ApiClient {
       headers,
     });
 
+    if (response.status === 401 && retry && this.refreshToken) {
+      if (await this.refreshSession()) {
+        return this.request<T>(endpoint, options, false);
+      }
+    }
+
+    if (response.status === 429) {
+      const seconds = Number(response.headers.get('Retry-After')) || 60;
+      const wait = seconds < 120 ? `${seconds} seconds` : `${Math.ceil(seconds / 60)} minutes`;
+      throw new Error(`Too many requests. Please try again in ${wait}.`);
+    }
+
     if (!response.ok) {
       const error = await response.text();
       throw new Error(error || response.statusText);
@@ -50,17 +98,100 @@ class ApiClient {
   }
 
   // Auth
-  async login(name: string, role: 'user' | 'firefighter'): Promise<Session> {
+  async login(username: string, password: string): Promise<Session> {
     const response = await this.request<Session>('/api/auth/login', {
       method: 'POST',
-      body: JSON.stringify({ name, role }),
+      body: JSON.stringify({ username, password }),
     });
-    this.setToken(response.token);
+    this.setToken(response.token, response.refresh_token);
     return response;
   }
 
-  async me(): Promise<{ user: User }> {
-    return this.request<{ user: User }>('/api/auth/me');
+  async register(data: RegisterData): Promise<Session> {
+    const response = await this.request<Session>('/api/auth/register', {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+    this.setToken(response.token, response.refresh_token);
+    return response;
+  }
+
+  async changePassword(currentPassword: string, newPassword: string): Promise<void> {
+    await this.request('/api/auth/password', {
+      method: 'POST',
+      body: JSON.stringify({ current_password: currentPassword, new_password: newPassword }),
+    });
+  }
+
+  async requestPasswordReset(username: string): Promise<void> {
+    await this.request('/api/auth/password-reset', {
+      method: 'POST',
+      body: JSON.stringify({ username }),
+    });
+  }
+
+  async confirmPasswordReset(token: string, newPassword: string): Promise<void> {
+    await this.request('/api/auth/password-reset/confirm', {
+      method: 'POST',
+      body: JSON.stringify({ token, new_password: newPassword }),
+    });
+  }
+
+  async requestRole(data: {
+    role: 'firefighter';
+    invite_code?: string;
+    message?: string;
+  }): Promise<{ request: RoleRequest }> {
+    return this.request('/api/me/role-request', {
+      method: 'POST',
+      body: JSON.stringify(data),
+    });
+  }
+
+  async getRoleRequest(): Promise<{ request: RoleRequest }> {
+    return this.request('/api/me/role-request');
+  }
+
+  async getSessions(): Promise<{ sessions: DeviceSession[] }> {
+    return this.request('/api/auth/sessions');
+  }
+
+  async revokeSession(id: string): Promise<void> {
+    await this.request(`/api/auth/sessions/${id}`, { method: 'DELETE' });
+  }
+
+  async logoutAll(): Promise<void> {
+    try {
+      await this.request('/api/auth/logout-all', { method: 'POST' });
+    } finally {
+      this.setToken(null);
+    }
+  }
+
+  async getOIDCConfig(): Promise<{ enabled: boolean; name?: string }> {
+    return this.request('/api/auth/oidc');
+  }
+
+  oidcLoginURL(returnTo = '/'): string {
+    return `${API_URL}/api/auth/oidc/login?return_to=${encodeURIComponent(returnTo)}`;
+  }
+
+  async startOIDCLink(returnTo = '/'): Promise<{ url: string }> {
+    return this.request('/api/auth/oidc/link', {
+      method: 'POST',
+      body: JSON.stringify({ return_to: returnTo }),
+    });
+  }
+
+  async confirmOIDCLink(code: string): Promise<void> {
+    await this.request('/api/auth/oidc/link/confirm', {
+      method: 'POST',
+      body: JSON.stringify({ code }),
+    });
+  }
+
+  async me(): Promise<{ user: User; permissions: Permission[] }> {
+    return this.request<{ user: User; permissions: Permission[] }>('/api/auth/me');
   }
 
   async logout(): Promise<void> {
@@ -89,6 +220,59 @@ class ApiClient {
     });
   }
 
+  async setFireVerdict(id: number, verdict: FireVerdict, note?: string): Promise<{ fire: Fire }> {
+    return this.request<{ fire: Fire }>(`/api/fires/${id}/verdict`, {
+      method: 'POST',
+      body: JSON.stringify({ verdict, note }),
+    });
+  }
+
+  async transferFire(id: number, organisationId: number | null): Promise<{ fire: Fire }> {
+    return this.request<{ fire: Fire }>(`/api/fires/${id}/organisation`, {
+      method: 'PUT',
+      body: JSON.stringify({ organisation_id: organisationId }),
+    });
+  }
+
+  // Organisations
+  async getOrganisations(): Promise<{ organisations: Organisation[] }> {
+    return this.request<{ organisations: Organisation[] }>('/api/organisations');
+  }
+
+  // Moderation
+  async getModerationQueue(): Promise<{ fires: Fire[]; total: number }> {
+    return this.request<{ fires: Fire[]; total: number }>('/api/moderation/fires');
+  }
+
+  async approveFire(id: number): Promise<{ fire: Fire }> {
+    return this.request<{ fire: Fire }>(`/api/moderation/fires/${id}/approve`, { method: 'POST' });
+  }
+
+  async rejectFire(id: number, verdict: 'false' | 'prank', note?: string): Promise<{ fire: Fire }> {
+    return this.request<{ fire: Fire }>(`/api/moderation/fires/${id}/reject`, {
+      method: 'POST',
+      body: JSON.stringify({ verdict, note }),
+    });
+  }
+
+  // Anonymous reports must solve a proof-of-work challenge first, which
+  // takes a few seconds of CPU time.
+  async reportAnonymously(data: {
+    latitude: number;
+    longitude: number;
+    description: string;
+  }): Promise<{ fire: Fire }> {
+    const { challenge } = await this.request<{ challenge: ReportChallenge }>(
+      '/api/fires/anonymous/challenge',
+      { method: 'POST' }
+    );
+    const nonce = await solveChallenge(challenge.challenge, challenge.difficulty);
+    return this.request<{ fire: Fire }>('/api/fires/anonymous', {
+      method: 'POST',
+      body: JSON.stringify({ ...data, challenge: challenge.challenge, nonce }),
+    });
+  }
+
   async updateFireStatus(
     id: number,
     status: 'seen' | 'closed'
@@ -99,6 +283,18 @@ class ApiClient {
     });
   }
 
+  // Events
+  subscribeEvents(onEvent: (type: string, data: unknown) => void): () => void {
+    const source = new EventSource(`${API_URL}/api/events`);
+    const types = ['fire.created', 'fire.status_changed', 'fire.merged', 'fire.transferred', 'comment.added', 'comment.deleted', 'reset'];
+    for (const type of types) {
+      source.addEventListener(type, (event) => {
+        onEvent(type, JSON.parse((event as MessageEvent).data));
+      });
+    }
+    return () => source.close();
+  }
+
   // Comments
   async getComments(fireId: number): Promise<{ comments: Comment[] }> {
     return this.request<{ comments: Comment[] }>(`/api/fires/${fireId}/comments`);
@@ -110,6 +306,10 @@ class ApiClient {
       body: JSON.stringify({ text }),
     });
   }
+
+  async deleteComment(fireId: number, commentId: number): Promise<void> {
+    await this.request(`/api/fires/${fireId}/comments/${commentId}`, { method: 'DELETE' });
+  }
 }
 
 export const apiClient = new ApiClient();
@@ -1,13 +1,16 @@
 'use client';
 
 import React, { createContext, useContext, useState, useEffect } from 'react';
-import { User } from './types';
+import { User, Permission, Session, RegisterData } from './types';
 import { apiClient } from './api';
 
 interface AuthContextType {
   user: User | null;
+  permissions: Permission[];
+  can: (permission: Permission) => boolean;
   loading: boolean;
-  login: (name: string, role: 'user' | 'firefighter') => Promise<void>;
+  login: (username: string, password: string) => Promise<Session>;
+  register: (data: RegisterData) => Promise<void>;
   logout: () => Promise<void>;
   refetch: () => Promise<void>;
 }
@@ -16,14 +19,17 @@ const AuthContext = createContext<AuthContextType | undefined>(undefined);
 
 export function AuthProvider({ children }: { children: React.ReactNode }) {
   const [user, setUser] = useState<User | null>(null);
+  const [permissions, setPermissions] = useState<Permission[]>([]);
   const [loading, setLoading] = useState(true);
 
   const fetchUser = async () => {
     try {
-      const { user } = await apiClient.me();
+      const { user, permissions } = await apiClient.me();
       setUser(user);
+      setPermissions(permissions);
     } catch (error) {
       setUser(null);
+      setPermissions([]);
       apiClient.setToken(null);
     } finally {
       setLoading(false);
@@ -34,19 +40,30 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     fetchUser();
   }, []);
 
-  const login = async (name: string, role: 'user' | 'firefighter') => {
-    const session = await apiClient.login(name, role);
+  const login = async (username: string, password: string) => {
+    const session = await apiClient.login(username, password);
     setUser(session.user);
+    await fetchUser();
+    return session;
+  };
+
+  const register = async (data: RegisterData) => {
+    const session = await apiClient.register(data);
+    setUser(session.user);
+    await fetchUser();
   };
 
   const logout = async () => {
     await apiClient.logout();
     setUser(null);
+    setPermissions([]);
   };
 
+  const can = (permission: Permission) => permissions.includes(permission);
+
   return (
     <AuthContext.Provider
-      value={{ user, loading, login, logout, refetch: fetchUser }}
+      value={{ user, permissions, can, loading, login, register, logout, refetch: fetchUser }}
     >
       {children}
     </AuthContext.Provider>
@@ -0,0 +1,22 @@
+// Finds a nonce such that SHA-256(`${challenge}:${nonce}`) starts with
+// `difficulty` zero bits, matching the backend's hashcash check.
+export async function solveChallenge(challenge: string, difficulty: number): Promise<string> {
+  const encoder = new TextEncoder();
+  for (let nonce = 0; ; nonce++) {
+    const digest = await crypto.subtle.digest('SHA-256', encoder.encode(`${challenge}:${nonce}`));
+    if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+      return nonce.toString();
+    }
+  }
+}
+
+function leadingZeroBits(bytes: Uint8Array): number {
+  let bits = 0;
+  for (const byte of bytes) {
+    if (byte !== 0) {
+      return bits + Math.clz32(byte) - 24;
+    }
+    bits += 8;
+  }
+  return bits;
+}
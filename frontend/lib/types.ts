@@ -1,22 +1,89 @@
 export interface User {
   id: number;
+  username?: string;
   name: string;
-  role: 'user' | 'firefighter';
+  email?: string;
+  role: Role;
   created_at: string;
+  organisation_id?: number;
+  suspended_at?: string;
+  suspended_reason?: string;
+  trust?: TrustScore;
 }
 
+export type Role = 'citizen' | 'firefighter' | 'dispatcher' | 'commander' | 'admin';
+
+// Mirrors the backend's authz package.
+export type Permission =
+  | 'fire.report'
+  | 'fire.status.update'
+  | 'fire.moderate'
+  | 'zone.manage'
+  | 'comment.create'
+  | 'comment.delete'
+  | 'fire.transfer'
+  | 'user.manage'
+  | 'organisation.manage'
+  | 'webhook.manage'
+  | 'apikey.manage'
+  | 'job.view';
+
+export interface TrustScore {
+  score: number;
+  reports: number;
+  confirmed: number;
+  false: number;
+  pranks: number;
+}
+
+export type FireVerdict = 'confirmed' | 'false' | 'prank';
+
 export interface Fire {
   id: number;
-  reporter_id: number;
+  reporter_id: number | null;
   reporter?: User;
+  anonymous: boolean;
   latitude: number;
   longitude: number;
   description: string;
   status: 'reported' | 'seen' | 'closed';
+  organisation_id: number | null;
+  merged_into_id?: number;
+  moderation_status: 'pending' | 'approved' | 'rejected';
+  verdict?: FireVerdict;
+  verdict_at?: string;
+  created_at: string;
+  updated_at: string;
+  weather?: WeatherSnapshot;
+}
+
+export interface Organisation {
+  id: number;
+  name: string;
+  jurisdiction: { type: 'Polygon'; coordinates: [number, number][][] } | null;
+  default: boolean;
   created_at: string;
   updated_at: string;
 }
 
+export interface ReportChallenge {
+  challenge: string;
+  difficulty: number;
+  expires_at: string;
+}
+
+export interface WeatherSnapshot {
+  id: number;
+  fire_id: number;
+  wind_speed: number;
+  wind_direction: number;
+  temperature: number;
+  humidity: number;
+  source: string;
+  observed_at: string;
+  created_at: string;
+}
+
 export interface Comment {
   id: number;
   fire_id: number;
@@ -28,5 +95,36 @@ export interface Comment {
 
 export interface Session {
   token: string;
+  refresh_token: string;
+  expires_at: string;
   user: User;
 }
+
+export interface DeviceSession {
+  id: string;
+  created_at: string;
+  expires_at: string;
+  absolute_expires_at: string;
+  last_seen_at: string;
+  ip: string | null;
+  user_agent: string | null;
+  current: boolean;
+}
+
+export interface RegisterData {
+  username: string;
+  password: string;
+  name?: string;
+  email?: string;
+}
+
+export interface RoleRequest {
+  id: number;
+  user_id: number;
+  role: 'firefighter';
+  status: 'pending' | 'approved' | 'denied';
+  message: string | null;
+  review_note: string | null;
+  reviewed_at: string | null;
+  created_at: string;
+}